@@ -0,0 +1,404 @@
+package bolt
+
+import (
+	"database/sql/driver"
+	"strings"
+	"sync"
+	"time"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// BreakerState is the state of a circuit breaker.
+type BreakerState int
+
+const (
+	// BreakerClosed lets every call through while measuring their error rate and latency.
+	BreakerClosed BreakerState = iota
+	// BreakerOpen fails every call with ErrCircuitOpen until the open timeout elapses.
+	BreakerOpen
+	// BreakerHalfOpen lets probes through to decide whether to close or open the breaker again.
+	BreakerHalfOpen
+)
+
+func (s BreakerState) String() string {
+	switch s {
+	case BreakerClosed:
+		return "closed"
+	case BreakerOpen:
+		return "open"
+	case BreakerHalfOpen:
+		return "half-open"
+	}
+	return "unknown"
+}
+
+// BreakerConfig configures a circuit breaker. Zero values are replaced by the defaults described on each field.
+type BreakerConfig struct {
+	// Window is the period the error rate and latency are measured over before the counts are reset, 10s by default.
+	Window time.Duration
+	// MinRequests is the number of calls within a window needed before the breaker can trip, 10 by default.
+	MinRequests int
+	// ErrorRate is the share of failed calls within a window that trips the breaker, 0.5 by default.
+	ErrorRate float64
+	// SlowCallDuration is the latency at which a call counts as slow. Latency is not considered if it is zero.
+	SlowCallDuration time.Duration
+	// SlowCallRate is the share of slow calls within a window that trips the breaker, 0.5 by default.
+	SlowCallRate float64
+	// OpenTimeout is how long the breaker stays open before probing, 30s by default.
+	OpenTimeout time.Duration
+	// HalfOpenProbes is the number of successful probes needed to close the breaker, 1 by default.
+	HalfOpenProbes int
+	// ProbeQuery, such as "RETURN 1", is run by the breaker itself to probe the database when half-open. If it is
+	// empty, calls are let through one at a time while half-open, and the first statement each runs is the probe.
+	ProbeQuery string
+	// IsFailure decides whether an error counts towards the error rate. By default every error counts except
+	// Neo.ClientError failures, which are caused by the statement rather than the database.
+	IsFailure func(err error) bool
+	// OnStateChange is called after the breaker changes state.
+	OnStateChange func(from, to BreakerState)
+}
+
+// WithCircuitBreaker wraps pool acquisition and statement execution in a circuit breaker. Each statement counts as one
+// call, as does a failure to open the connection for one. While the breaker is open calls fail fast with an error
+// whose cause is ErrCircuitOpen.
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	return func(d *DB) {
+		pool := &breakerPool{DBPool: d.pool, breaker: newCircuitBreaker(cfg)}
+		pool.breaker.probe = pool.probe
+		d.breaker = pool.breaker
+		d.pool = pool
+	}
+}
+
+// BreakerState returns the state of the circuit breaker, which is always closed if none is configured.
+func (d *DB) BreakerState() BreakerState {
+	if d.breaker == nil {
+		return BreakerClosed
+	}
+	d.breaker.mutex.Lock()
+	defer d.breaker.mutex.Unlock()
+	return d.breaker.state
+}
+
+func isClientError(err error) bool {
+	return strings.Contains(err.Error(), "Neo.ClientError")
+}
+
+type circuitBreaker struct {
+	cfg   BreakerConfig
+	now   func() time.Time
+	probe func() error
+
+	mutex       sync.Mutex
+	state       BreakerState
+	windowStart time.Time
+	calls       int
+	failures    int
+	slow        int
+	openedAt    time.Time
+	probing     bool
+	successes   int
+	changes     [][2]BreakerState
+}
+
+func newCircuitBreaker(cfg BreakerConfig) *circuitBreaker {
+	if cfg.Window <= 0 {
+		cfg.Window = 10 * time.Second
+	}
+	if cfg.MinRequests <= 0 {
+		cfg.MinRequests = 10
+	}
+	if cfg.ErrorRate <= 0 {
+		cfg.ErrorRate = 0.5
+	}
+	if cfg.SlowCallRate <= 0 {
+		cfg.SlowCallRate = 0.5
+	}
+	if cfg.OpenTimeout <= 0 {
+		cfg.OpenTimeout = 30 * time.Second
+	}
+	if cfg.HalfOpenProbes <= 0 {
+		cfg.HalfOpenProbes = 1
+	}
+	if cfg.IsFailure == nil {
+		cfg.IsFailure = func(err error) bool { return !isClientError(err) }
+	}
+	return &circuitBreaker{cfg: cfg, now: time.Now}
+}
+
+// unlock releases the mutex then notifies OnStateChange of the changes made while it was held, so the callback may
+// call back into the DB.
+func (b *circuitBreaker) unlock() {
+	changes := b.changes
+	b.changes = nil
+	b.mutex.Unlock()
+
+	if b.cfg.OnStateChange != nil {
+		for _, c := range changes {
+			b.cfg.OnStateChange(c[0], c[1])
+		}
+	}
+}
+
+func (b *circuitBreaker) setState(state BreakerState) {
+	if state == b.state {
+		return
+	}
+	b.changes = append(b.changes, [2]BreakerState{b.state, state})
+	b.state = state
+	b.probing, b.successes = false, 0
+	b.resetWindow()
+	if state == BreakerOpen {
+		b.openedAt = b.now()
+	}
+}
+
+func (b *circuitBreaker) resetWindow() {
+	b.windowStart = b.now()
+	b.calls, b.failures, b.slow = 0, 0, 0
+}
+
+// enter returns ErrCircuitOpen if a call can not be made in the current state. Once the open timeout has passed the
+// breaker is half-open and lets one probe through at a time, rejecting other calls until it finishes. With a
+// ProbeQuery the breaker runs the probe itself, otherwise the call is the probe and true is returned, in which case
+// its outcome must be recorded as a probe or the probe cancelled.
+func (b *circuitBreaker) enter() (bool, error) {
+	b.mutex.Lock()
+	defer b.unlock()
+
+	if b.state == BreakerOpen {
+		if b.now().Sub(b.openedAt) < b.cfg.OpenTimeout {
+			return false, ErrCircuitOpen
+		}
+		b.setState(BreakerHalfOpen)
+	}
+	if b.state == BreakerClosed {
+		return false, nil
+	}
+
+	if b.probing {
+		return false, ErrCircuitOpen
+	}
+	b.probing = true
+	if b.cfg.ProbeQuery == "" {
+		return true, nil
+	}
+
+	b.mutex.Unlock()
+	err := b.runProbes()
+	b.mutex.Lock()
+
+	if err != nil {
+		b.setState(BreakerOpen)
+		return false, ErrCircuitOpen
+	}
+	b.setState(BreakerClosed)
+	return false, nil
+}
+
+func (b *circuitBreaker) runProbes() error {
+	for i := 0; i < b.cfg.HalfOpenProbes; i++ {
+		if err := b.probe(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// cancelProbe lets another call through as the probe when the call that entered as one ends without a statement.
+func (b *circuitBreaker) cancelProbe() {
+	b.mutex.Lock()
+	defer b.unlock()
+	if b.state == BreakerHalfOpen {
+		b.probing = false
+	}
+}
+
+// record updates the breaker with the outcome of a call that enter let through, which was the probe if probe is set.
+func (b *circuitBreaker) record(err error, latency time.Duration, probe bool) {
+	failed := err != nil && b.cfg.IsFailure(err)
+	slow := b.cfg.SlowCallDuration > 0 && latency >= b.cfg.SlowCallDuration
+
+	b.mutex.Lock()
+	defer b.unlock()
+
+	switch b.state {
+	case BreakerClosed:
+		if b.now().Sub(b.windowStart) >= b.cfg.Window {
+			b.resetWindow()
+		}
+		b.calls++
+		if failed {
+			b.failures++
+		}
+		if slow {
+			b.slow++
+		}
+		if b.calls < b.cfg.MinRequests {
+			return
+		}
+		if float64(b.failures)/float64(b.calls) >= b.cfg.ErrorRate ||
+			(b.cfg.SlowCallDuration > 0 && float64(b.slow)/float64(b.calls) >= b.cfg.SlowCallRate) {
+			b.setState(BreakerOpen)
+		}
+
+	case BreakerHalfOpen:
+		if !probe {
+			return
+		}
+		if failed || slow {
+			b.setState(BreakerOpen)
+			return
+		}
+		b.probing = false
+		b.successes++
+		if b.successes >= b.cfg.HalfOpenProbes {
+			b.setState(BreakerClosed)
+		}
+	}
+}
+
+// do runs f if the breaker allows it, recording its outcome.
+func (b *circuitBreaker) do(f func() error) error {
+	probe, err := b.enter()
+	if err != nil {
+		return err
+	}
+	return b.measure(probe, f)
+}
+
+// measure runs f and records its outcome.
+func (b *circuitBreaker) measure(probe bool, f func() error) error {
+	start := b.now()
+	err := f()
+	b.record(err, b.now().Sub(start), probe)
+	return err
+}
+
+type breakerPool struct {
+	DBPool
+	breaker *circuitBreaker
+}
+
+// OpenPool fails fast while the breaker is open. A connection that is opened is not recorded as a call, as the
+// statements run on it are, but a failure is since no statement will be run for it. While half-open, the connection
+// opened for the probe runs it as its first statement, and failing to open it fails the probe.
+func (p *breakerPool) OpenPool() (neo4j.Conn, error) {
+	probe, err := p.breaker.enter()
+	if err != nil {
+		return nil, err
+	}
+	start := p.breaker.now()
+	conn, err := p.DBPool.OpenPool()
+	if err != nil {
+		p.breaker.record(err, p.breaker.now().Sub(start), probe)
+		return nil, err
+	}
+	return &breakerConn{Conn: conn, breaker: p.breaker, probe: probe}, nil
+}
+
+func (p *breakerPool) probe() error {
+	conn, err := p.DBPool.OpenPool()
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	_, _, _, err = conn.QueryNeoAll(p.breaker.cfg.ProbeQuery, nil)
+	return err
+}
+
+type breakerConn struct {
+	neo4j.Conn
+	breaker *circuitBreaker
+	// probe is set on the connection opened for the half-open probe until its first statement has run.
+	probe bool
+}
+
+func (c *breakerConn) unwrap() neo4j.Conn {
+	return c.Conn
+}
+
+// run runs f as the probe if the connection was opened for one, otherwise through the breaker.
+func (c *breakerConn) run(f func() error) error {
+	if !c.probe {
+		return c.breaker.do(f)
+	}
+	c.probe = false
+	return c.breaker.measure(true, f)
+}
+
+func (c *breakerConn) QueryNeo(query string, params map[string]interface{}) (neo4j.Rows, error) {
+	var rows neo4j.Rows
+	err := c.run(func() error {
+		var err error
+		rows, err = c.Conn.QueryNeo(query, params)
+		return err
+	})
+	return rows, err
+}
+
+func (c *breakerConn) ExecNeo(query string, params map[string]interface{}) (neo4j.Result, error) {
+	var res neo4j.Result
+	err := c.run(func() error {
+		var err error
+		res, err = c.Conn.ExecNeo(query, params)
+		return err
+	})
+	return res, err
+}
+
+func (c *breakerConn) PrepareNeo(query string) (neo4j.Stmt, error) {
+	stmt, err := c.Conn.PrepareNeo(query)
+	if err != nil {
+		return nil, err
+	}
+	return &breakerStmt{Stmt: stmt, conn: c}, nil
+}
+
+func (c *breakerConn) Begin() (driver.Tx, error) {
+	var tx driver.Tx
+	err := c.run(func() error {
+		var err error
+		tx, err = c.Conn.Begin()
+		return err
+	})
+	return tx, err
+}
+
+// Close cancels the probe if the connection was opened for one but ran no statement.
+func (c *breakerConn) Close() error {
+	if c.probe {
+		c.probe = false
+		c.breaker.cancelProbe()
+	}
+	return c.Conn.Close()
+}
+
+type breakerStmt struct {
+	neo4j.Stmt
+	conn *breakerConn
+}
+
+func (s *breakerStmt) QueryNeo(params map[string]interface{}) (neo4j.Rows, error) {
+	var rows neo4j.Rows
+	err := s.conn.run(func() error {
+		var err error
+		rows, err = s.Stmt.QueryNeo(params)
+		return err
+	})
+	return rows, err
+}
+
+func (s *breakerStmt) ExecNeo(params map[string]interface{}) (neo4j.Result, error) {
+	var res neo4j.Result
+	err := s.conn.run(func() error {
+		var err error
+		res, err = s.Stmt.ExecNeo(params)
+		return err
+	})
+	return res, err
+}
@@ -0,0 +1,216 @@
+package bolt
+
+import (
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	"github.com/ONSdigital/dp-bolt/bolt/mock/mockrows"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type clock struct {
+	now time.Time
+}
+
+func (c *clock) Now() time.Time {
+	return c.now
+}
+
+func (c *clock) Advance(d time.Duration) {
+	c.now = c.now.Add(d)
+}
+
+// newBreakerDB returns a DB with a circuit breaker on a fake clock. queryErr is returned by every query while it is
+// set, and each query advances the clock by queryLatency.
+func newBreakerDB(cfg BreakerConfig, queryErr *error, queryLatency *time.Duration) (*DB, *clock, *mock.DBPoolMock, *[][2]BreakerState) {
+	c := &clock{now: time.Date(2018, 1, 1, 0, 0, 0, 0, time.UTC)}
+	var changes [][2]BreakerState
+	cfg.OnStateChange = func(from, to BreakerState) {
+		changes = append(changes, [2]BreakerState{from, to})
+	}
+
+	pool := &mock.DBPoolMock{
+		OpenPoolFunc: func() (neo4j.Conn, error) {
+			return &mock.NeoConnMock{
+				CloseFunc: closeNoErr,
+				QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+					c.Advance(*queryLatency)
+					if *queryErr != nil {
+						return nil, *queryErr
+					}
+					return mockrows.New("n").Row(int64(1)), nil
+				},
+				QueryNeoAllFunc: func(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+					return nil, nil, nil, *queryErr
+				},
+			}, nil
+		},
+	}
+
+	db := New(pool, WithCircuitBreaker(cfg))
+	db.breaker.now = c.Now
+	db.breaker.resetWindow()
+	return db, c, pool, &changes
+}
+
+func TestCircuitBreaker_ErrorRate(t *testing.T) {
+	Convey("given a circuit breaker tripping when half of 4 calls fail", t, func() {
+		var queryErr error
+		var latency time.Duration
+		db, c, pool, changes := newBreakerDB(BreakerConfig{MinRequests: 4, ErrorRate: 0.5, OpenTimeout: time.Minute}, &queryErr, &latency)
+
+		Convey("when client errors are returned", func() {
+			queryErr = errors.New("Neo.ClientError.Statement.SyntaxError")
+			for i := 0; i < 4; i++ {
+				db.QueryForResult("RETURN 1", nil, nil)
+			}
+
+			Convey("then the breaker stays closed", func() {
+				So(db.BreakerState(), ShouldEqual, BreakerClosed)
+			})
+		})
+
+		Convey("when fewer than 4 statements fail", func() {
+			queryErr = errors.New("connection refused")
+			for i := 0; i < 3; i++ {
+				So(db.QueryForResult("RETURN 1", nil, nil), ShouldNotBeNil)
+			}
+
+			Convey("then the breaker stays closed as opening each connection is not counted as a call", func() {
+				So(db.BreakerState(), ShouldEqual, BreakerClosed)
+			})
+		})
+
+		Convey("when connections can not be opened", func() {
+			pool.OpenPoolFunc = func() (neo4j.Conn, error) {
+				return nil, errors.New("connection refused")
+			}
+			for i := 0; i < 4; i++ {
+				So(db.QueryForResult("RETURN 1", nil, nil), ShouldNotBeNil)
+			}
+
+			Convey("then each failure is counted as a call and the breaker opens", func() {
+				So(db.BreakerState(), ShouldEqual, BreakerOpen)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 4)
+			})
+
+			Convey("then after the open timeout one call probes the pool and its failure opens the breaker again", func() {
+				c.Advance(time.Minute)
+				for i := 0; i < 10; i++ {
+					So(db.QueryForResult("RETURN 1", nil, nil), ShouldNotBeNil)
+				}
+				So(db.BreakerState(), ShouldEqual, BreakerOpen)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 5)
+				So(*changes, ShouldResemble, [][2]BreakerState{
+					{BreakerClosed, BreakerOpen},
+					{BreakerOpen, BreakerHalfOpen},
+					{BreakerHalfOpen, BreakerOpen},
+				})
+			})
+		})
+
+		Convey("when the database fails", func() {
+			queryErr = errors.New("connection refused")
+			for i := 0; i < 4; i++ {
+				So(db.QueryForResult("RETURN 1", nil, nil), ShouldNotBeNil)
+			}
+
+			Convey("then the breaker opens and calls fail fast", func() {
+				So(db.BreakerState(), ShouldEqual, BreakerOpen)
+				So(*changes, ShouldResemble, [][2]BreakerState{{BreakerClosed, BreakerOpen}})
+
+				opened := len(pool.OpenPoolCalls())
+				err := db.QueryForResult("RETURN 1", nil, nil)
+				So(errors.Cause(err), ShouldEqual, ErrCircuitOpen)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, opened)
+			})
+
+			Convey("then a successful probe after the open timeout closes the breaker", func() {
+				c.Advance(time.Minute)
+				queryErr = nil
+
+				So(db.QueryForResult("RETURN 1", nil, nil), ShouldBeNil)
+				So(db.BreakerState(), ShouldEqual, BreakerClosed)
+				So(*changes, ShouldResemble, [][2]BreakerState{
+					{BreakerClosed, BreakerOpen},
+					{BreakerOpen, BreakerHalfOpen},
+					{BreakerHalfOpen, BreakerClosed},
+				})
+			})
+
+			Convey("then after the open timeout calls are rejected while the probe is in flight", func() {
+				c.Advance(time.Minute)
+				probe, err := db.pool.OpenPool()
+				So(err, ShouldBeNil)
+				_, err = db.pool.OpenPool()
+				So(err, ShouldEqual, ErrCircuitOpen)
+
+				So(probe.Close(), ShouldBeNil)
+				next, err := db.pool.OpenPool()
+				So(err, ShouldBeNil)
+				So(next.Close(), ShouldBeNil)
+				So(db.BreakerState(), ShouldEqual, BreakerHalfOpen)
+			})
+
+			Convey("then the statement run after the open timeout is the probe and its failure opens the breaker again", func() {
+				c.Advance(time.Minute)
+
+				err := db.QueryForResult("RETURN 1", nil, nil)
+				So(err, ShouldNotBeNil)
+				So(errors.Cause(err), ShouldNotEqual, ErrCircuitOpen)
+				So(db.BreakerState(), ShouldEqual, BreakerOpen)
+				So(*changes, ShouldResemble, [][2]BreakerState{
+					{BreakerClosed, BreakerOpen},
+					{BreakerOpen, BreakerHalfOpen},
+					{BreakerHalfOpen, BreakerOpen},
+				})
+			})
+		})
+	})
+}
+
+func TestCircuitBreaker_Latency(t *testing.T) {
+	Convey("given a circuit breaker tripping on slow calls", t, func() {
+		var queryErr error
+		latency := 2 * time.Second
+		db, _, _, _ := newBreakerDB(BreakerConfig{MinRequests: 4, SlowCallDuration: time.Second}, &queryErr, &latency)
+
+		Convey("when calls are slow", func() {
+			for i := 0; i < 4; i++ {
+				So(db.QueryForResult("RETURN 1", nil, nil), ShouldBeNil)
+			}
+
+			Convey("then the breaker opens", func() {
+				So(db.BreakerState(), ShouldEqual, BreakerOpen)
+			})
+		})
+	})
+}
+
+func TestCircuitBreaker_ProbeQuery(t *testing.T) {
+	Convey("given an open circuit breaker with a probe query", t, func() {
+		queryErr := errors.New("connection refused")
+		var latency time.Duration
+		db, c, _, changes := newBreakerDB(BreakerConfig{MinRequests: 1, ProbeQuery: "RETURN 1", OpenTimeout: time.Minute}, &queryErr, &latency)
+		db.QueryForResult("MATCH (n) RETURN n", nil, nil)
+		So(db.BreakerState(), ShouldEqual, BreakerOpen)
+
+		Convey("when the probe fails after the open timeout", func() {
+			c.Advance(time.Minute)
+			err := db.QueryForResult("MATCH (n) RETURN n", nil, nil)
+
+			Convey("then the call fails fast and the breaker opens again", func() {
+				So(errors.Cause(err), ShouldEqual, ErrCircuitOpen)
+				So(db.BreakerState(), ShouldEqual, BreakerOpen)
+				So(*changes, ShouldResemble, [][2]BreakerState{
+					{BreakerClosed, BreakerOpen},
+					{BreakerOpen, BreakerHalfOpen},
+					{BreakerHalfOpen, BreakerOpen},
+				})
+			})
+		})
+	})
+}
@@ -0,0 +1,296 @@
+package bolt
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/pkg/errors"
+)
+
+// CacheEntry holds the materialised rows of a cached query. Results only hold values, so an entry keeps nothing of
+// the query it came from alive.
+type CacheEntry struct {
+	Columns []string
+	Results []Result
+	Tags    []string
+	Expires time.Time
+	Size    int
+}
+
+// CacheStore is the storage used by a ResultCache. Implementations must be safe for concurrent use.
+type CacheStore interface {
+	// Get returns the entry stored against key, if present and not expired.
+	Get(key string) (*CacheEntry, bool)
+	// Set stores the entry against key, evicting other entries if the store is full.
+	Set(key string, entry *CacheEntry)
+	// InvalidateTags removes every entry carrying at least one of the provided tags.
+	InvalidateTags(tags ...string)
+	// Purge removes every entry.
+	Purge()
+}
+
+// ResultCacheStats is a snapshot of the result cache counters.
+type ResultCacheStats struct {
+	Hits   int64
+	Misses int64
+}
+
+// ResultCache is a read-through cache of query results. Entries are keyed on the query text and its normalised
+// params.
+type ResultCache struct {
+	store  CacheStore
+	ttl    time.Duration
+	mutex  sync.Mutex
+	hits   int64
+	misses int64
+	// generation is incremented on every invalidation so results read before it are not stored after it.
+	generation int64
+}
+
+// NewResultCache creates a ResultCache backed by store. Entries expire after ttl, a ttl of 0 means entries only leave
+// the cache when evicted or invalidated.
+func NewResultCache(store CacheStore, ttl time.Duration) *ResultCache {
+	return &ResultCache{store: store, ttl: ttl}
+}
+
+// WithResultCache enables the read-through result cache for queries made through DB.Cached and invalidation of it by
+// tagged statements passed to DB.Exec.
+func WithResultCache(cache *ResultCache) Option {
+	return func(d *DB) {
+		d.resultCache = cache
+	}
+}
+
+// Stats returns the current hit and miss counts of the cache.
+func (c *ResultCache) Stats() ResultCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	return ResultCacheStats{Hits: c.hits, Misses: c.misses}
+}
+
+// InvalidateTags removes every cached result carrying at least one of the provided tags.
+func (c *ResultCache) InvalidateTags(tags ...string) {
+	if len(tags) > 0 {
+		c.invalidate()
+		c.store.InvalidateTags(tags...)
+	}
+}
+
+// Purge removes every cached result.
+func (c *ResultCache) Purge() {
+	c.invalidate()
+	c.store.Purge()
+}
+
+func (c *ResultCache) invalidate() {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	c.generation++
+}
+
+func (c *ResultCache) get(key string) (*CacheEntry, int64, bool) {
+	entry, ok := c.store.Get(key)
+
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+	if ok {
+		c.hits++
+	} else {
+		c.misses++
+	}
+	return entry, c.generation, ok
+}
+
+func (c *ResultCache) set(key string, columns []string, results []Result, tags []string, generation int64) {
+	c.mutex.Lock()
+	stale := generation != c.generation
+	c.mutex.Unlock()
+	if stale {
+		return
+	}
+
+	entry := &CacheEntry{Columns: columns, Results: results, Tags: tags, Size: len(key)}
+	for _, c := range columns {
+		entry.Size += len(c)
+	}
+	for _, r := range results {
+		entry.Size += sizeOf(r.Data) + sizeOf(r.Meta)
+	}
+	if c.ttl > 0 {
+		entry.Expires = time.Now().Add(c.ttl)
+	}
+	c.store.Set(key, entry)
+}
+
+// CachedQueries executes queries through the result cache of a DB, tagging any results it stores.
+type CachedQueries struct {
+	db   *DB
+	tags []string
+}
+
+// Cached returns CachedQueries for idempotent queries whose results may be served from the result cache. Results
+// are tagged with the provided tags so they can be invalidated when a statement touching them is executed. If the
+// DB has no result cache the queries are passed straight through to the database.
+func (d *DB) Cached(tags ...string) *CachedQueries {
+	return &CachedQueries{db: d, tags: tags}
+}
+
+// QueryForResults executes the provided query to return 1 or more results, using the cached rows if present.
+func (q *CachedQueries) QueryForResults(query string, params map[string]interface{}, mapResult ResultMapper) error {
+	return q.query(query, params, mapResult, false)
+}
+
+// QueryForResult executes the provided query to return a single result, using the cached rows if present.
+func (q *CachedQueries) QueryForResult(query string, params map[string]interface{}, mapResult ResultMapper) error {
+	return q.query(query, params, mapResult, true)
+}
+
+func (q *CachedQueries) query(query string, params map[string]interface{}, mapResult ResultMapper, singleResult bool) error {
+	cache := q.db.resultCache
+	if cache == nil {
+		return q.db.query(query, params, mapResult, singleResult)
+	}
+
+	key, err := cacheKey(query, params)
+	if err != nil {
+		// params that can not be normalised are never cached
+		return q.db.query(query, params, mapResult, singleResult)
+	}
+
+	entry, generation, ok := cache.get(key)
+	if ok {
+		return mapResults(entry.Columns, entry.Results, mapResult, singleResult)
+	}
+
+	var columns []string
+	var results []Result
+	err = q.db.query(query, params, func(r *Result) error {
+		if len(results) == 0 {
+			columns = r.Columns()
+		}
+		results = append(results, Result{Data: r.Data, Meta: r.Meta, Index: r.Index})
+		return nil
+	}, false)
+	if err != nil && err != ErrNoResults {
+		return err
+	}
+
+	cache.set(key, columns, results, q.tags, generation)
+	return mapResults(columns, results, mapResult, singleResult)
+}
+
+// mapResults replays materialised rows through mapResult with the same semantics as a query against the database.
+// Each mapper is given its own copy of the values, so changes it makes are not seen by later hits.
+func mapResults(columns []string, results []Result, mapResult ResultMapper, singleResult bool) error {
+	if len(results) == 0 {
+		return ErrNoResults
+	}
+
+	for i, r := range results {
+		if singleResult && i > 0 {
+			return NonUniqueResult
+		}
+
+		if mapResult != nil {
+			data, _ := copyValue(r.Data).([]interface{})
+			res := NewResult(columns, data, r.Index)
+			res.Meta, _ = copyValue(r.Meta).(map[string]interface{})
+			if err := mapResult(res); err != nil {
+				return errors.WithMessage(err, "mapResult returned an error")
+			}
+		}
+	}
+	return nil
+}
+
+// cacheKey builds a key from the query text and its params as they are sent to the driver. The params are written
+// as JSONValue writes them, so a float keeps its decimal point and never shares a key with the equal integer.
+// encoding/json writes map keys in sorted order so logically equal params always produce the same key.
+func cacheKey(query string, params map[string]interface{}) (string, error) {
+	encoded, err := encodeParams(params)
+	if err != nil {
+		return "", err
+	}
+	tagged, err := toJSONMap(encoded)
+	if err != nil {
+		return "", err
+	}
+	b, err := json.Marshal(tagged)
+	if err != nil {
+		return "", err
+	}
+	return query + "\x00" + string(b), nil
+}
+
+// copyValue copies a value returned by the driver, including the lists, maps and graph values within it.
+func copyValue(v interface{}) interface{} {
+	switch val := v.(type) {
+	case []interface{}:
+		if val == nil {
+			return val
+		}
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			list[i] = copyValue(item)
+		}
+		return list
+	case map[string]interface{}:
+		if val == nil {
+			return val
+		}
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = copyValue(item)
+		}
+		return m
+	case graph.Node:
+		val.Labels = append([]string(nil), val.Labels...)
+		val.Properties, _ = copyValue(val.Properties).(map[string]interface{})
+		return val
+	case graph.Relationship:
+		val.Properties, _ = copyValue(val.Properties).(map[string]interface{})
+		return val
+	case graph.UnboundRelationship:
+		val.Properties, _ = copyValue(val.Properties).(map[string]interface{})
+		return val
+	case graph.Path:
+		nodes := make([]graph.Node, len(val.Nodes))
+		for i, n := range val.Nodes {
+			nodes[i] = copyValue(n).(graph.Node)
+		}
+		rels := make([]graph.UnboundRelationship, len(val.Relationships))
+		for i, r := range val.Relationships {
+			rels[i] = copyValue(r).(graph.UnboundRelationship)
+		}
+		val.Nodes, val.Relationships = nodes, rels
+		val.Sequence = append([]int(nil), val.Sequence...)
+		return val
+	}
+	return v
+}
+
+// sizeOf approximates the number of bytes held by a value returned by the driver.
+func sizeOf(v interface{}) int {
+	switch val := v.(type) {
+	case nil:
+		return 0
+	case string:
+		return len(val)
+	case []interface{}:
+		size := 0
+		for _, item := range val {
+			size += sizeOf(item)
+		}
+		return size
+	case map[string]interface{}:
+		size := 0
+		for k, item := range val {
+			size += len(k) + sizeOf(item)
+		}
+		return size
+	default:
+		return 8
+	}
+}
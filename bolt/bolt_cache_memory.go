@@ -0,0 +1,119 @@
+package bolt
+
+import (
+	"container/list"
+	"sync"
+	"time"
+)
+
+// MemoryStore is an in-memory CacheStore bounded by a maximum number of entries and an approximate maximum size in
+// bytes. The least recently used entries are evicted first once either bound is exceeded.
+type MemoryStore struct {
+	mutex      sync.Mutex
+	maxEntries int
+	maxBytes   int
+	bytes      int
+	entries    map[string]*list.Element
+	order      *list.List
+}
+
+type memoryItem struct {
+	key   string
+	entry *CacheEntry
+}
+
+// NewMemoryStore creates a MemoryStore. A maxEntries or maxBytes of 0 leaves that bound unlimited.
+func NewMemoryStore(maxEntries, maxBytes int) *MemoryStore {
+	return &MemoryStore{
+		maxEntries: maxEntries,
+		maxBytes:   maxBytes,
+		entries:    make(map[string]*list.Element),
+		order:      list.New(),
+	}
+}
+
+// Get returns the entry stored against key, if present and not expired.
+func (s *MemoryStore) Get(key string) (*CacheEntry, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	e, ok := s.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	item := e.Value.(*memoryItem)
+	if !item.entry.Expires.IsZero() && time.Now().After(item.entry.Expires) {
+		s.remove(e)
+		return nil, false
+	}
+
+	s.order.MoveToFront(e)
+	return item.entry, true
+}
+
+// Set stores the entry against key, evicting the least recently used entries while the store is over its bounds.
+// An entry larger than maxBytes is not stored.
+func (s *MemoryStore) Set(key string, entry *CacheEntry) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if e, ok := s.entries[key]; ok {
+		s.remove(e)
+	}
+
+	if s.maxBytes > 0 && entry.Size > s.maxBytes {
+		return
+	}
+
+	s.entries[key] = s.order.PushFront(&memoryItem{key: key, entry: entry})
+	s.bytes += entry.Size
+
+	for (s.maxEntries > 0 && s.order.Len() > s.maxEntries) || (s.maxBytes > 0 && s.bytes > s.maxBytes) {
+		s.remove(s.order.Back())
+	}
+}
+
+// InvalidateTags removes every entry carrying at least one of the provided tags.
+func (s *MemoryStore) InvalidateTags(tags ...string) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	invalid := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		invalid[tag] = true
+	}
+
+	for _, e := range s.entries {
+		for _, tag := range e.Value.(*memoryItem).entry.Tags {
+			if invalid[tag] {
+				s.remove(e)
+				break
+			}
+		}
+	}
+}
+
+// Purge removes every entry.
+func (s *MemoryStore) Purge() {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.entries = make(map[string]*list.Element)
+	s.order.Init()
+	s.bytes = 0
+}
+
+// Len returns the number of entries in the store.
+func (s *MemoryStore) Len() int {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.order.Len()
+}
+
+func (s *MemoryStore) remove(e *list.Element) {
+	item := e.Value.(*memoryItem)
+	s.order.Remove(e)
+	delete(s.entries, item.key)
+	s.bytes -= item.entry.Size
+}
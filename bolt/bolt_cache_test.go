@@ -0,0 +1,212 @@
+package bolt
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newCacheTestPool() (*mock.DBPoolMock, *mock.NeoConnMock) {
+	conn := &mock.NeoConnMock{
+		QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+			rowsStub := &mock.RowsStub{
+				Rows: []mock.RowValues{
+					{Data: expectedData, Meta: expectedMeta},
+					{Err: io.EOF},
+				},
+			}
+			return &mock.NeoRowsMock{
+				NextNeoFunc: rowsStub.Next,
+				ColumnsFunc: func() []string { return []string{"n"} },
+				CloseFunc:   closeNoErr,
+			}, nil
+		},
+		ExecNeoFunc: func(query string, params map[string]interface{}) (neo4j.Result, error) {
+			return &mock.NeoResultMock{
+				RowsAffectedFunc: func() (int64, error) { return 1, nil },
+				MetadataFunc:     func() map[string]interface{} { return nil },
+			}, nil
+		},
+		CloseFunc: closeNoErr,
+	}
+	pool := &mock.DBPoolMock{
+		OpenPoolFunc: func() (neo4j.Conn, error) {
+			return conn, nil
+		},
+	}
+	return pool, conn
+}
+
+func TestCachedQueries_QueryForResult(t *testing.T) {
+	Convey("given a DB with a result cache", t, func() {
+		pool, conn := newCacheTestPool()
+		cache := NewResultCache(NewMemoryStore(10, 0), time.Minute)
+		db := New(pool, WithResultCache(cache))
+
+		resultMapper := ResultMapperMock{
+			MapResultFunc: func(r *Result) error {
+				return nil
+			},
+		}
+
+		Convey("when the same query is made twice with equal params", func() {
+			err := db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", Params{"a": 1, "b": "x"}, resultMapper.Do)
+			So(err, ShouldBeNil)
+			err = db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", Params{"b": "x", "a": 1}, resultMapper.Do)
+			So(err, ShouldBeNil)
+
+			Convey("then the database is only queried once and the mapper is called for both", func() {
+				So(conn.QueryNeoCalls(), ShouldHaveLength, 1)
+				So(resultMapper.Calls, ShouldHaveLength, 2)
+				So(resultMapper.Calls[1].Data, ShouldResemble, expectedData)
+				So(cache.Stats(), ShouldResemble, ResultCacheStats{Hits: 1, Misses: 1})
+			})
+		})
+
+		Convey("when the same query is made with an integer and a float that JSON writes the same way", func() {
+			err := db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", Params{"a": int64(1)}, resultMapper.Do)
+			So(err, ShouldBeNil)
+			err = db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", Params{"a": float64(1)}, resultMapper.Do)
+			So(err, ShouldBeNil)
+			err = db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", Params{"a": 1}, resultMapper.Do)
+			So(err, ShouldBeNil)
+
+			Convey("then they are cached separately but integers of different types share an entry", func() {
+				So(conn.QueryNeoCalls(), ShouldHaveLength, 2)
+				So(cache.Stats(), ShouldResemble, ResultCacheStats{Hits: 1, Misses: 2})
+			})
+		})
+
+		Convey("when a mapper changes a cached result before it is read again by column name", func() {
+			err := db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", nil, func(r *Result) error {
+				r.Data[0] = "changed"
+				r.Meta["key"] = "changed"
+				return nil
+			})
+			So(err, ShouldBeNil)
+			var n int64
+			var meta interface{}
+			err = db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", nil, func(r *Result) error {
+				meta = r.Meta["key"]
+				var err error
+				n, err = r.Int64ByName("n")
+				return err
+			})
+			key, _ := cacheKey("MATCH (n) RETURN n", nil)
+			entry, _ := cache.store.Get(key)
+
+			Convey("then the hit sees the values as they were returned by the database", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, 1)
+				So(meta, ShouldEqual, "value")
+				So(expectedMeta["key"], ShouldEqual, "value")
+			})
+
+			Convey("then the entry holds the column names instead of the rows they came from", func() {
+				So(entry.Columns, ShouldResemble, []string{"n"})
+				So(entry.Results[0].columns, ShouldBeNil)
+			})
+		})
+
+		Convey("when a statement tagged with the cached tag is executed between queries", func() {
+			db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", nil, resultMapper.Do)
+			_, _, err := db.Exec(Stmt{Query: "CREATE (n)", Tags: []string{"codelists"}})
+			So(err, ShouldBeNil)
+			db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", nil, resultMapper.Do)
+
+			Convey("then the cached result is invalidated and the database queried again", func() {
+				So(conn.QueryNeoCalls(), ShouldHaveLength, 2)
+				So(cache.Stats(), ShouldResemble, ResultCacheStats{Misses: 2})
+			})
+		})
+
+		Convey("when a statement with an unrelated tag is executed between queries", func() {
+			db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", nil, resultMapper.Do)
+			db.Exec(Stmt{Query: "CREATE (n)", Tags: []string{"datasets"}})
+			db.Cached("codelists").QueryForResult("MATCH (n) RETURN n", nil, resultMapper.Do)
+
+			Convey("then the cached result is used", func() {
+				So(conn.QueryNeoCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("given a DB without a result cache", t, func() {
+		pool, conn := newCacheTestPool()
+		db := New(pool)
+
+		Convey("when a cached query is made twice then the database is queried both times", func() {
+			db.Cached().QueryForResults("MATCH (n) RETURN n", nil, nil)
+			db.Cached().QueryForResults("MATCH (n) RETURN n", nil, nil)
+
+			So(conn.QueryNeoCalls(), ShouldHaveLength, 2)
+		})
+	})
+}
+
+func TestMemoryStore(t *testing.T) {
+	Convey("given a memory store bounded to 2 entries", t, func() {
+		store := NewMemoryStore(2, 0)
+
+		Convey("when a third entry is added then the least recently used entry is evicted", func() {
+			store.Set("a", &CacheEntry{})
+			store.Set("b", &CacheEntry{})
+			store.Get("a")
+			store.Set("c", &CacheEntry{})
+
+			_, okA := store.Get("a")
+			_, okB := store.Get("b")
+			So(okA, ShouldBeTrue)
+			So(okB, ShouldBeFalse)
+			So(store.Len(), ShouldEqual, 2)
+		})
+	})
+
+	Convey("given a memory store bounded to 10 bytes", t, func() {
+		store := NewMemoryStore(0, 10)
+
+		Convey("when entries exceed the byte bound then the oldest are evicted", func() {
+			store.Set("a", &CacheEntry{Size: 6})
+			store.Set("b", &CacheEntry{Size: 6})
+
+			_, okA := store.Get("a")
+			So(okA, ShouldBeFalse)
+			So(store.Len(), ShouldEqual, 1)
+		})
+
+		Convey("when an entry is larger than the byte bound then it is not stored", func() {
+			store.Set("a", &CacheEntry{Size: 11})
+			So(store.Len(), ShouldEqual, 0)
+		})
+	})
+
+	Convey("given an expired entry", t, func() {
+		store := NewMemoryStore(0, 0)
+		store.Set("a", &CacheEntry{Expires: time.Now().Add(-time.Second)})
+
+		Convey("when it is read then it is not returned and is removed", func() {
+			_, ok := store.Get("a")
+			So(ok, ShouldBeFalse)
+			So(store.Len(), ShouldEqual, 0)
+		})
+	})
+
+	Convey("given entries with tags", t, func() {
+		store := NewMemoryStore(0, 0)
+		store.Set("a", &CacheEntry{Tags: []string{"codelists"}})
+		store.Set("b", &CacheEntry{Tags: []string{"hierarchies", "codelists"}})
+		store.Set("c", &CacheEntry{Tags: []string{"datasets"}})
+
+		Convey("when a tag is invalidated then every entry carrying it is removed", func() {
+			store.InvalidateTags("codelists")
+
+			_, okC := store.Get("c")
+			So(okC, ShouldBeTrue)
+			So(store.Len(), ShouldEqual, 1)
+		})
+	})
+}
@@ -0,0 +1,35 @@
+package bolt
+
+import (
+	"reflect"
+
+	"github.com/ONSdigital/dp-bolt/bolt/internal/convert"
+)
+
+// Valuer is implemented by types that are sent to the database as another value, such as NullString. Value must
+// return a value Params accept, which is encoded in turn.
+type Valuer = convert.Valuer
+
+// Scanner is implemented by types that set themselves from a value received from the database, such as NullString.
+// src is nil if the value is null.
+type Scanner = convert.Scanner
+
+// RegisterCodec registers how values of type T are sent as Params values and scanned from a Result, replacing any
+// codec already registered for T. It is meant for types that can not implement Valuer and Scanner themselves, such as
+// those of other packages. encode must return a value Params accept other than a T, and decode is never given null.
+//
+//	bolt.RegisterCodec(func(s Status) (interface{}, error) {
+//		return s.String(), nil
+//	}, func(src interface{}) (Status, error) {
+//		name, _ := src.(string)
+//		return ParseStatus(name)
+//	})
+//
+// time.Time is registered to be sent as an RFC 3339 string and time.Duration as an integer of nanoseconds.
+func RegisterCodec[T any](encode func(v T) (interface{}, error), decode func(src interface{}) (T, error)) {
+	convert.Register(reflect.TypeOf((*T)(nil)).Elem(), func(v interface{}) (interface{}, error) {
+		return encode(v.(T))
+	}, func(src interface{}) (interface{}, error) {
+		return decode(src)
+	})
+}
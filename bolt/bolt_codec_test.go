@@ -0,0 +1,206 @@
+package bolt
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/boltmem"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type testStatus int
+
+const (
+	statusDraft testStatus = iota
+	statusPublished
+)
+
+func (s testStatus) String() string {
+	return [...]string{"draft", "published"}[s]
+}
+
+type testUUID [16]byte
+
+type testState string
+
+// testDecimal stands in for decimal types that marshal to and from text.
+type testDecimal struct {
+	units, cents int64
+}
+
+func (d testDecimal) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d.%02d", d.units, d.cents)), nil
+}
+
+func (d *testDecimal) UnmarshalText(b []byte) error {
+	_, err := fmt.Sscanf(string(b), "%d.%02d", &d.units, &d.cents)
+	return err
+}
+
+type failingValuer struct{}
+
+func (failingValuer) Value() (interface{}, error) {
+	return nil, errors.New("no value")
+}
+
+func init() {
+	RegisterCodec(func(s testStatus) (interface{}, error) {
+		return s.String(), nil
+	}, func(src interface{}) (testStatus, error) {
+		switch src {
+		case "draft":
+			return statusDraft, nil
+		case "published":
+			return statusPublished, nil
+		}
+		return 0, fmt.Errorf("unknown status %v", src)
+	})
+}
+
+func TestCodecs(t *testing.T) {
+	released := time.Date(2018, 5, 1, 9, 30, 0, 500, time.UTC)
+	id := testUUID{0x12, 0x3e, 0x45, 0x67, 0xe8, 0x9b, 0x12, 0xd3, 0xa4, 0x56, 0x42, 0x66, 0x14, 0x17, 0x40, 0x00}
+
+	Convey("given params of types the driver can not send", t, func() {
+		params := Params{
+			"released": released,
+			"interval": 90 * time.Minute,
+			"id":       id,
+			"status":   statusPublished,
+			"price":    testDecimal{units: 12, cents: 5},
+			"state":    testState("live"),
+			"times":    []time.Time{released},
+			"labels":   map[string]string{"en": "Prices"},
+			"nested":   []interface{}{map[string]interface{}{"id": &id}},
+			"missing":  (*time.Time)(nil),
+		}
+
+		Convey("when they are encoded", func() {
+			encoded, err := encodeParams(params)
+
+			Convey("then each is sent in a form the driver accepts", func() {
+				So(err, ShouldBeNil)
+				So(encoded, ShouldResemble, map[string]interface{}{
+					"released": "2018-05-01T09:30:00.0000005Z",
+					"interval": int64(90 * time.Minute),
+					"id":       "123e4567-e89b-12d3-a456-426614174000",
+					"status":   "published",
+					"price":    "12.05",
+					"state":    "live",
+					"times":    []interface{}{"2018-05-01T09:30:00.0000005Z"},
+					"labels":   map[string]interface{}{"en": "Prices"},
+					"nested":   []interface{}{map[string]interface{}{"id": "123e4567-e89b-12d3-a456-426614174000"}},
+					"missing":  nil,
+				})
+			})
+
+			Convey("then the params passed in are unchanged", func() {
+				So(params["released"], ShouldHaveSameTypeAs, time.Time{})
+			})
+		})
+
+		Convey("when they are stored and scanned back out of a result", func() {
+			db := New(boltmem.NewPool())
+			_, _, err := db.Exec(Stmt{Query: "CREATE (:Release {released: $released, interval: $interval, id: $id, " +
+				"status: $status, price: $price, state: $state, times: $times})", Params: params})
+			So(err, ShouldBeNil)
+
+			var (
+				gotReleased time.Time
+				gotInterval time.Duration
+				gotID       testUUID
+				gotStatus   testStatus
+				gotPrice    testDecimal
+				gotState    testState
+				gotTimes    []time.Time
+				gotOptional Null[time.Time]
+			)
+			err = db.QueryForResult("MATCH (r:Release) RETURN r.released, r.interval, r.id, r.status, r.price, r.state, "+
+				"r.times, r.missing", nil, func(r *Result) error {
+				return r.Scan(&gotReleased, &gotInterval, &gotID, &gotStatus, &gotPrice, &gotState, &gotTimes, &gotOptional)
+			})
+
+			Convey("then each is decoded to its Go type", func() {
+				So(err, ShouldBeNil)
+				So(gotReleased.Equal(released), ShouldBeTrue)
+				So(gotInterval, ShouldEqual, 90*time.Minute)
+				So(gotID, ShouldEqual, id)
+				So(gotStatus, ShouldEqual, statusPublished)
+				So(gotPrice, ShouldResemble, testDecimal{units: 12, cents: 5})
+				So(gotState, ShouldEqual, testState("live"))
+				So(gotTimes, ShouldHaveLength, 1)
+				So(gotTimes[0].Equal(released), ShouldBeTrue)
+				So(gotOptional.Valid, ShouldBeFalse)
+			})
+		})
+	})
+
+	Convey("given statements run in each way a DB runs them", t, func() {
+		db := New(boltmem.NewPool())
+		stmt, err := db.Prepare("CREATE (:Release {status: $status})")
+		So(err, ShouldBeNil)
+		_, _, err = stmt.Exec(Params{"status": statusDraft})
+		So(err, ShouldBeNil)
+		tx, err := db.Begin()
+		So(err, ShouldBeNil)
+		_, _, err = tx.Exec(Stmt{Query: "CREATE (:Release {status: $status})", Params: Params{"status": statusPublished}})
+		So(err, ShouldBeNil)
+		_, err = tx.ExecReturning(Stmt{Query: "CREATE (r:Release {status: $status}) RETURN r.status",
+			Params: Params{"status": statusPublished}}, nil)
+		So(err, ShouldBeNil)
+		So(tx.Commit(), ShouldBeNil)
+
+		Convey("when they are queried with params that need encoding", func() {
+			var published int64
+			err := db.QueryForResult("MATCH (r:Release) WHERE r.status = $status RETURN count(r)",
+				Params{"status": statusPublished}, func(r *Result) error {
+					return r.Scan(&published)
+				})
+			var drafts []testStatus
+			query, _ := db.Prepare("MATCH (r:Release) WHERE r.status = $status RETURN r.status")
+			queryErr := query.QueryForResults(Params{"status": statusDraft}, func(r *Result) error {
+				var s testStatus
+				err := r.Scan(&s)
+				drafts = append(drafts, s)
+				return err
+			})
+
+			Convey("then the params were encoded however the statement was run", func() {
+				So(err, ShouldBeNil)
+				So(published, ShouldEqual, 2)
+				So(queryErr, ShouldBeNil)
+				So(drafts, ShouldResemble, []testStatus{statusDraft})
+			})
+		})
+	})
+
+	Convey("given values that can not be encoded or decoded", t, func() {
+		Convey("then encoding errors name the parameter", func() {
+			_, err := encodeParams(Params{"list": []interface{}{failingValuer{}}})
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "error encoding: parameter list: list item 0: no value")
+
+			_, err = encodeParams(Params{"big": uint64(1 << 63)})
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("then decoding errors name the column and wrap the error of the codec", func() {
+			r := &Result{Data: []interface{}{"archived", "not-a-uuid", "yesterday"}}
+			var status testStatus
+			var id testUUID
+			var released time.Time
+			err := r.Scan(&status, new(string), new(string))
+			So(err.Error(), ShouldEqual, "result column 0 is string, not bolt.testStatus: unknown status archived")
+			var colErr *ColumnError
+			So(errors.As(err, &colErr), ShouldBeTrue)
+			So(colErr.Unwrap(), ShouldNotBeNil)
+			So(colErr.Unwrap().Error(), ShouldEqual, "unknown status archived")
+			So(r.Scan(new(string), &id, new(string)), ShouldNotBeNil)
+			err = r.Scan(new(string), new(string), &released)
+			So(strings.HasPrefix(err.Error(), "result column 2 is string, not time.Time: parsing time"), ShouldBeTrue)
+		})
+	})
+}
@@ -0,0 +1,373 @@
+package bolt
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"strconv"
+	"time"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+// DefaultPoolSize is the number of connections in the pool unless Config.PoolSize is set.
+const DefaultPoolSize = 30
+
+var ErrAcquireTimeout = errors.New("timed out waiting for a neo4j connection from the pool")
+
+// Config holds everything needed to build a DB. Credentials are kept out of URL so the URL can be logged; String
+// never includes the password.
+type Config struct {
+	// URL is the bolt URL of the database, such as bolt://localhost:7687. It must not contain credentials.
+	URL string
+	// Username is the user to authenticate as, if any.
+	Username string
+	// Password is the password of Username. It can not be set along with PasswordFile.
+	Password string
+	// PasswordFile is a file holding the password of Username, such as a mounted secret. It is read again when it
+	// changes, so rotated passwords are picked up without a restart.
+	PasswordFile string
+	// Credentials supplies the credentials instead of Username, Password and PasswordFile, such as from a secrets
+	// service.
+	Credentials CredentialsProvider
+	// PoolSize is the number of connections in the pool, DefaultPoolSize by default.
+	PoolSize int
+	// AcquireTimeout is how long to wait for a connection from the pool before failing with ErrAcquireTimeout. Calls
+	// wait indefinitely if it is zero.
+	AcquireTimeout time.Duration
+	// StatementTimeout is the read and write timeout of connections, 60s by default.
+	StatementTimeout time.Duration
+	// ChunkSize is the size of the chunks messages are split into, the driver default if zero.
+	ChunkSize uint16
+	// TLS configures encrypted connections.
+	TLS TLSConfig
+	// Retry configures how broken connections are handled.
+	Retry RetryPolicy
+}
+
+// RetryPolicy configures how statements failing on broken connections are handled.
+type RetryPolicy struct {
+	// MaxRetries is the number of times a statement is retried on a fresh connection, DefaultReconnectRetries if
+	// zero. Retries are disabled if it is negative.
+	MaxRetries int
+	// ValidationQuery, such as "RETURN 1", is run on each connection as it is checked out of the pool. Connections
+	// are not validated if it is empty.
+	ValidationQuery string
+}
+
+// Validate reports the first problem with the config, if any.
+func (cfg Config) Validate() error {
+	if cfg.URL == "" {
+		return errors.New("config: URL is required")
+	}
+	u, err := url.Parse(cfg.URL)
+	if err != nil {
+		return errors.New("config: URL is invalid")
+	}
+	if u.Scheme != "bolt" {
+		return errors.Errorf("config: URL scheme must be bolt, not %q", u.Scheme)
+	}
+	if u.User != nil {
+		return errors.New("config: URL must not contain credentials, use Username and Password instead")
+	}
+	if cfg.Password != "" && cfg.PasswordFile != "" {
+		return errors.New("config: only one of Password and PasswordFile can be set")
+	}
+	if cfg.Credentials != nil && (cfg.Username != "" || cfg.Password != "" || cfg.PasswordFile != "") {
+		return errors.New("config: Credentials can not be set along with Username, Password or PasswordFile")
+	}
+	if cfg.Username == "" && (cfg.Password != "" || cfg.PasswordFile != "") {
+		return errors.New("config: Username is required with a password")
+	}
+	if cfg.PoolSize < 0 {
+		return errors.New("config: PoolSize must not be negative")
+	}
+	if cfg.AcquireTimeout < 0 || cfg.StatementTimeout < 0 {
+		return errors.New("config: timeouts must not be negative")
+	}
+	return cfg.TLS.validate()
+}
+
+// String describes the config without its password.
+func (cfg Config) String() string {
+	password := ""
+	if cfg.Password != "" {
+		password = "[REDACTED]"
+	}
+	return fmt.Sprintf("{URL:%s Username:%s Password:%s PasswordFile:%s PoolSize:%d AcquireTimeout:%s StatementTimeout:%s ChunkSize:%d TLS:%+v Retry:%+v}",
+		cfg.URL, cfg.Username, password, cfg.PasswordFile, cfg.PoolSize, cfg.AcquireTimeout, cfg.StatementTimeout,
+		cfg.ChunkSize, cfg.TLS, cfg.Retry)
+}
+
+// GoString describes the config without its password for the %#v verb.
+func (cfg Config) GoString() string {
+	return "bolt.Config" + cfg.String()
+}
+
+// credentials returns the provider of the credentials in the config, or nil if it has none.
+func (cfg Config) credentials() CredentialsProvider {
+	switch {
+	case cfg.Credentials != nil:
+		return cfg.Credentials
+	case cfg.PasswordFile != "":
+		return FileCredentials(cfg.Username, cfg.PasswordFile)
+	case cfg.Username != "":
+		return StaticCredentials(cfg.Username, cfg.Password)
+	}
+	return nil
+}
+
+// connString builds the connection string passed to the driver, adding the TLS parameters to those of the URL.
+func (cfg Config) connString(creds Credentials, tls url.Values) string {
+	u, _ := url.Parse(cfg.URL)
+	if creds.Username != "" {
+		u.User = url.UserPassword(creds.Username, creds.Password)
+	}
+	if len(tls) > 0 {
+		q := u.Query()
+		for key, values := range tls {
+			q[key] = values
+		}
+		u.RawQuery = q.Encode()
+	}
+	return u.String()
+}
+
+func setIfNotEmpty(q url.Values, key, value string) {
+	if value != "" {
+		q.Set(key, value)
+	}
+}
+
+// NewFromConfig validates cfg then builds the connection pool and a DB using it. Options are applied after those
+// derived from the config.
+func NewFromConfig(cfg Config, opts ...Option) (*DB, error) {
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+
+	var tlsFiles *tlsFiles
+	if cfg.TLS.Enabled {
+		var err error
+		if tlsFiles, err = newTLSFiles(cfg.TLS); err != nil {
+			return nil, err
+		}
+	}
+
+	size := cfg.PoolSize
+	if size == 0 {
+		size = DefaultPoolSize
+	}
+	newPool := func(creds Credentials) (DBPool, error) {
+		var tls url.Values
+		if tlsFiles != nil {
+			tls = tlsFiles.params()
+		}
+		driverPool, err := neo4j.NewClosableDriverPool(cfg.connString(creds, tls), size)
+		if err != nil {
+			return nil, errors.New("error creating neo4j connection pool")
+		}
+		return driverPool, nil
+	}
+
+	var pool DBPool
+	if provider := cfg.credentials(); provider != nil {
+		if _, err := provider.Credentials(false); err != nil {
+			tlsFiles.Close()
+			return nil, errors.WithMessage(err, "config: error getting credentials")
+		}
+		pool = newRotatingPool(provider, newPool)
+	} else {
+		var err error
+		if pool, err = newPool(Credentials{}); err != nil {
+			tlsFiles.Close()
+			return nil, err
+		}
+	}
+	if tlsFiles != nil {
+		pool = &tlsPool{DBPool: pool, files: tlsFiles}
+	}
+	if cfg.AcquireTimeout > 0 || cfg.StatementTimeout > 0 || cfg.ChunkSize > 0 {
+		pool = &configPool{
+			DBPool:           pool,
+			acquireTimeout:   cfg.AcquireTimeout,
+			statementTimeout: cfg.StatementTimeout,
+			chunkSize:        cfg.ChunkSize,
+		}
+	}
+
+	cfgOpts := []Option{WithPoolSize(size)}
+	switch {
+	case cfg.Retry.MaxRetries < 0:
+		cfgOpts = append(cfgOpts, WithReconnectRetries(0))
+	case cfg.Retry.MaxRetries > 0:
+		cfgOpts = append(cfgOpts, WithReconnectRetries(cfg.Retry.MaxRetries))
+	}
+	if cfg.Retry.ValidationQuery != "" {
+		cfgOpts = append(cfgOpts, WithConnValidation(cfg.Retry.ValidationQuery))
+	}
+	d := New(pool, append(cfgOpts, opts...)...)
+	d.tlsFiles = tlsFiles
+	return d, nil
+}
+
+// ConfigFromEnv reads a Config from environment variables named after its fields with the given prefix, such as
+// NEO4J_URL, NEO4J_USERNAME, NEO4J_PASSWORD, NEO4J_PASSWORD_FILE, NEO4J_POOL_SIZE, NEO4J_ACQUIRE_TIMEOUT,
+// NEO4J_STATEMENT_TIMEOUT, NEO4J_CHUNK_SIZE, NEO4J_TLS, NEO4J_TLS_CERT_FILE, NEO4J_TLS_KEY_FILE,
+// NEO4J_TLS_CA_CERT_FILE, NEO4J_TLS_SERVER_NAME, NEO4J_TLS_MIN_VERSION, NEO4J_TLS_INSECURE_SKIP_VERIFY,
+// NEO4J_MAX_RETRIES and NEO4J_VALIDATION_QUERY for the prefix NEO4J. Timeouts are durations such as 5s and TLS
+// versions are written as 1.2. The config is validated before it is returned.
+func ConfigFromEnv(prefix string) (Config, error) {
+	env := envReader{prefix: prefix}
+	cfg := Config{
+		URL:              env.string("URL"),
+		Username:         env.string("USERNAME"),
+		Password:         env.string("PASSWORD"),
+		PasswordFile:     env.string("PASSWORD_FILE"),
+		PoolSize:         env.int("POOL_SIZE"),
+		AcquireTimeout:   env.duration("ACQUIRE_TIMEOUT"),
+		StatementTimeout: env.duration("STATEMENT_TIMEOUT"),
+		ChunkSize:        uint16(env.uint("CHUNK_SIZE", 16)),
+		TLS: TLSConfig{
+			Enabled:            env.bool("TLS"),
+			CertFile:           env.string("TLS_CERT_FILE"),
+			KeyFile:            env.string("TLS_KEY_FILE"),
+			CACertFile:         env.string("TLS_CA_CERT_FILE"),
+			ServerName:         env.string("TLS_SERVER_NAME"),
+			MinVersion:         env.tlsVersion("TLS_MIN_VERSION"),
+			InsecureSkipVerify: env.bool("TLS_INSECURE_SKIP_VERIFY"),
+		},
+		Retry: RetryPolicy{
+			MaxRetries:      env.int("MAX_RETRIES"),
+			ValidationQuery: env.string("VALIDATION_QUERY"),
+		},
+	}
+	if env.err != nil {
+		return Config{}, env.err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
+
+// envReader reads prefixed environment variables, keeping the first parse error.
+type envReader struct {
+	prefix string
+	err    error
+}
+
+func (e *envReader) name(key string) string {
+	if e.prefix == "" {
+		return key
+	}
+	return e.prefix + "_" + key
+}
+
+func (e *envReader) string(key string) string {
+	return os.Getenv(e.name(key))
+}
+
+func (e *envReader) parse(key string, parse func(s string) error) {
+	s := e.string(key)
+	if s == "" || e.err != nil {
+		return
+	}
+	if err := parse(s); err != nil {
+		e.err = errors.Errorf("config: %s is invalid: %q", e.name(key), s)
+	}
+}
+
+func (e *envReader) int(key string) (i int) {
+	e.parse(key, func(s string) (err error) {
+		i, err = strconv.Atoi(s)
+		return err
+	})
+	return i
+}
+
+func (e *envReader) uint(key string, bitSize int) (u uint64) {
+	e.parse(key, func(s string) (err error) {
+		u, err = strconv.ParseUint(s, 10, bitSize)
+		return err
+	})
+	return u
+}
+
+func (e *envReader) bool(key string) (b bool) {
+	e.parse(key, func(s string) (err error) {
+		b, err = strconv.ParseBool(s)
+		return err
+	})
+	return b
+}
+
+func (e *envReader) duration(key string) (d time.Duration) {
+	e.parse(key, func(s string) (err error) {
+		d, err = time.ParseDuration(s)
+		return err
+	})
+	return d
+}
+
+func (e *envReader) tlsVersion(key string) (v uint16) {
+	e.parse(key, func(s string) (err error) {
+		v, err = parseTLSVersion(s)
+		return err
+	})
+	return v
+}
+
+// configPool applies the acquire timeout, statement timeout and chunk size of a Config to the connections it opens.
+type configPool struct {
+	DBPool
+	acquireTimeout   time.Duration
+	statementTimeout time.Duration
+	chunkSize        uint16
+}
+
+func (p *configPool) OpenPool() (neo4j.Conn, error) {
+	conn, err := p.acquire()
+	if err != nil {
+		return nil, err
+	}
+	if p.statementTimeout > 0 {
+		conn.SetTimeout(p.statementTimeout)
+	}
+	if p.chunkSize > 0 {
+		conn.SetChunkSize(p.chunkSize)
+	}
+	return conn, nil
+}
+
+func (p *configPool) acquire() (neo4j.Conn, error) {
+	if p.acquireTimeout <= 0 {
+		return p.DBPool.OpenPool()
+	}
+
+	type opened struct {
+		conn neo4j.Conn
+		err  error
+	}
+	c := make(chan opened, 1)
+	go func() {
+		conn, err := p.DBPool.OpenPool()
+		c <- opened{conn, err}
+	}()
+
+	timer := time.NewTimer(p.acquireTimeout)
+	defer timer.Stop()
+	select {
+	case o := <-c:
+		return o.conn, o.err
+	case <-timer.C:
+		// Return the connection to the pool once it is eventually acquired.
+		go func() {
+			if o := <-c; o.err == nil {
+				o.conn.Close()
+			}
+		}()
+		return nil, ErrAcquireTimeout
+	}
+}
@@ -0,0 +1,26 @@
+package bolt
+
+//go:generate moq -out ../boltmock/moq.go -pkg boltmock . Querier Executor Conn
+
+// Querier runs read queries, passing each result to a ResultMapper.
+type Querier interface {
+	QueryForResult(query string, params map[string]interface{}, mapResult ResultMapper) error
+	QueryForResults(query string, params map[string]interface{}, mapResult ResultMapper) error
+}
+
+// Executor runs statements that modify the graph.
+type Executor interface {
+	Exec(s Stmt) (int64, map[string]interface{}, error)
+}
+
+// Conn is implemented by both DB and Tx, so repository code written against it runs the same inside or outside a
+// transaction.
+type Conn interface {
+	Querier
+	Executor
+}
+
+var (
+	_ Conn = (*DB)(nil)
+	_ Conn = (*Tx)(nil)
+)
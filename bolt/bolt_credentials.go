@@ -0,0 +1,224 @@
+package bolt
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+// Credentials are the username and password to authenticate with.
+type Credentials struct {
+	Username string
+	Password string
+}
+
+// String describes the credentials without the password.
+func (c Credentials) String() string {
+	return "{Username:" + c.Username + " Password:" + redact([]byte(c.Password)) + "}"
+}
+
+// GoString describes the credentials without the password for the %#v verb.
+func (c Credentials) GoString() string {
+	return "bolt.Credentials" + c.String()
+}
+
+// CredentialsProvider supplies the credentials new connections authenticate with. Credentials is called each time a
+// connection is checked out of the pool, so implementations should be cheap. refresh is true after Neo4j rejected the
+// credentials, in which case any cached credentials should be discarded.
+type CredentialsProvider interface {
+	Credentials(refresh bool) (Credentials, error)
+}
+
+// CredentialsFunc adapts a func to a CredentialsProvider, such as to fetch credentials from a secrets service.
+type CredentialsFunc func(refresh bool) (Credentials, error)
+
+// Credentials calls f.
+func (f CredentialsFunc) Credentials(refresh bool) (Credentials, error) {
+	return f(refresh)
+}
+
+// StaticCredentials returns a provider that always supplies the same credentials.
+func StaticCredentials(username, password string) CredentialsProvider {
+	return CredentialsFunc(func(refresh bool) (Credentials, error) {
+		return Credentials{Username: username, Password: password}, nil
+	})
+}
+
+// FileCredentials returns a provider reading the password from a file, such as one mounted by secrets tooling. The
+// file is read again whenever it changes. Trailing newlines are ignored.
+func FileCredentials(username, passwordFile string) CredentialsProvider {
+	return &fileCredentials{username: username, file: passwordFile}
+}
+
+type fileCredentials struct {
+	username string
+	file     string
+
+	mutex    sync.Mutex
+	modTime  time.Time
+	size     int64
+	password string
+}
+
+func (f *fileCredentials) Credentials(refresh bool) (Credentials, error) {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	info, err := os.Stat(f.file)
+	if err != nil {
+		return Credentials{}, errors.WithMessage(err, "error reading password file")
+	}
+	if refresh || !info.ModTime().Equal(f.modTime) || info.Size() != f.size {
+		b, err := ioutil.ReadFile(f.file)
+		if err != nil {
+			return Credentials{}, errors.WithMessage(err, "error reading password file")
+		}
+		f.password = strings.TrimRight(string(b), "\r\n")
+		f.modTime, f.size = info.ModTime(), info.Size()
+	}
+	return Credentials{Username: f.username, Password: f.password}, nil
+}
+
+func isAuthError(err error) bool {
+	return strings.Contains(err.Error(), "Neo.ClientError.Security.Unauthorized")
+}
+
+// rotatingPool checks connections out of a pool built for the current credentials. When the credentials change a
+// new pool is built, and the previous one is closed once the connections checked out of it have been returned, so
+// connections using old credentials are retired as they are released rather than all at once.
+type rotatingPool struct {
+	provider CredentialsProvider
+	newPool  func(creds Credentials) (DBPool, error)
+
+	mutex   sync.Mutex
+	current *poolGeneration
+	retired map[*poolGeneration]struct{}
+	closed  bool
+}
+
+// poolGeneration is a pool built for one set of credentials.
+type poolGeneration struct {
+	creds      Credentials
+	pool       DBPool
+	checkedOut int
+	retired    bool
+}
+
+func newRotatingPool(provider CredentialsProvider, newPool func(creds Credentials) (DBPool, error)) *rotatingPool {
+	return &rotatingPool{provider: provider, newPool: newPool, retired: make(map[*poolGeneration]struct{})}
+}
+
+// OpenPool checks out a connection using the current credentials. If Neo4j rejects them, the credentials are
+// refreshed and the checkout is retried once.
+func (p *rotatingPool) OpenPool() (neo4j.Conn, error) {
+	conn, err := p.open(false)
+	if err != nil && isAuthError(err) {
+		conn, err = p.open(true)
+	}
+	return conn, err
+}
+
+func (p *rotatingPool) open(refresh bool) (neo4j.Conn, error) {
+	creds, err := p.provider.Credentials(refresh)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error getting neo4j credentials")
+	}
+
+	gen, err := p.generation(creds)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := gen.pool.OpenPool()
+	if err != nil {
+		p.release(gen)
+		return nil, err
+	}
+	return &rotatingConn{Conn: conn, release: func() { p.release(gen) }}, nil
+}
+
+// generation returns the pool for creds, building it and retiring the current one if the credentials changed. The
+// connection the caller is about to check out is counted against the pool returned.
+func (p *rotatingPool) generation(creds Credentials) (*poolGeneration, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return nil, ErrDBClosed
+	}
+
+	if p.current == nil || p.current.creds != creds {
+		pool, err := p.newPool(creds)
+		if err != nil {
+			return nil, err
+		}
+		if old := p.current; old != nil {
+			old.retired = true
+			if old.checkedOut == 0 {
+				old.pool.Close()
+			} else {
+				p.retired[old] = struct{}{}
+			}
+		}
+		p.current = &poolGeneration{creds: creds, pool: pool}
+	}
+	p.current.checkedOut++
+	return p.current, nil
+}
+
+func (p *rotatingPool) release(gen *poolGeneration) {
+	p.mutex.Lock()
+	gen.checkedOut--
+	retire := gen.retired && gen.checkedOut == 0 && !p.closed
+	if retire {
+		delete(p.retired, gen)
+	}
+	p.mutex.Unlock()
+
+	if retire {
+		gen.pool.Close()
+	}
+}
+
+// Close closes the current pool and any retired pools still waiting for their connections.
+func (p *rotatingPool) Close() error {
+	p.mutex.Lock()
+	p.closed = true
+	var pools []DBPool
+	if p.current != nil {
+		pools = append(pools, p.current.pool)
+	}
+	for gen := range p.retired {
+		pools = append(pools, gen.pool)
+	}
+	p.retired = nil
+	p.mutex.Unlock()
+
+	var err error
+	for _, pool := range pools {
+		if closeErr := pool.Close(); closeErr != nil && err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
+
+// rotatingConn returns its connection to the pool generation it came from.
+type rotatingConn struct {
+	neo4j.Conn
+	release func()
+	once    sync.Once
+}
+
+func (c *rotatingConn) unwrap() neo4j.Conn {
+	return c.Conn
+}
+
+func (c *rotatingConn) Close() error {
+	err := c.Conn.Close()
+	c.once.Do(c.release)
+	return err
+}
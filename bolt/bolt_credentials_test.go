@@ -0,0 +1,141 @@
+package bolt
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var errUnauthorized = errors.New("Neo4J reported a failure for the query: Neo.ClientError.Security.Unauthorized")
+
+// newPoolFactory returns a func building pools that only accept password, along with the pools built so far.
+func newPoolFactory(password *string) (func(creds Credentials) (DBPool, error), *[]*mock.DBPoolMock) {
+	var pools []*mock.DBPoolMock
+	return func(creds Credentials) (DBPool, error) {
+		pool := &mock.DBPoolMock{
+			OpenPoolFunc: func() (neo4j.Conn, error) {
+				if creds.Password != *password {
+					return nil, errUnauthorized
+				}
+				return &mock.NeoConnMock{CloseFunc: closeNoErr}, nil
+			},
+			CloseFunc: closeNoErr,
+		}
+		pools = append(pools, pool)
+		return pool, nil
+	}, &pools
+}
+
+func TestRotatingPool(t *testing.T) {
+	Convey("given a pool using rotating credentials", t, func() {
+		password := "one"
+		current := "one"
+		newPool, pools := newPoolFactory(&password)
+		pool := newRotatingPool(CredentialsFunc(func(refresh bool) (Credentials, error) {
+			return Credentials{Username: "neo4j", Password: current}, nil
+		}), newPool)
+
+		conn, err := pool.OpenPool()
+		So(err, ShouldBeNil)
+
+		Convey("when the credentials are rotated while a connection is checked out", func() {
+			password, current = "two", "two"
+			newConn, err := pool.OpenPool()
+			So(err, ShouldBeNil)
+
+			Convey("then new connections use a pool with the new credentials", func() {
+				So(*pools, ShouldHaveLength, 2)
+				So((*pools)[1].OpenPoolCalls(), ShouldHaveLength, 1)
+			})
+
+			Convey("then the old pool is retired once its connection is returned", func() {
+				So((*pools)[0].CloseCalls(), ShouldHaveLength, 0)
+				conn.Close()
+				conn.Close()
+				So((*pools)[0].CloseCalls(), ShouldHaveLength, 1)
+
+				newConn.Close()
+				So((*pools)[1].CloseCalls(), ShouldHaveLength, 0)
+			})
+
+			Convey("then closing the pool closes every generation once", func() {
+				So(pool.Close(), ShouldBeNil)
+				conn.Close()
+				So((*pools)[0].CloseCalls(), ShouldHaveLength, 1)
+				So((*pools)[1].CloseCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("given Neo4j rejects the cached credentials", t, func() {
+		password := "two"
+		var refreshes []bool
+		newPool, pools := newPoolFactory(&password)
+		pool := newRotatingPool(CredentialsFunc(func(refresh bool) (Credentials, error) {
+			refreshes = append(refreshes, refresh)
+			if refresh {
+				return Credentials{Username: "neo4j", Password: "two"}, nil
+			}
+			return Credentials{Username: "neo4j", Password: "one"}, nil
+		}), newPool)
+
+		Convey("when a connection is checked out", func() {
+			_, err := pool.OpenPool()
+
+			Convey("then the credentials are refreshed and the checkout retried once", func() {
+				So(err, ShouldBeNil)
+				So(refreshes, ShouldResemble, []bool{false, true})
+				So(*pools, ShouldHaveLength, 2)
+				So((*pools)[0].CloseCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("when the refreshed credentials are rejected too", func() {
+			password = "three"
+			_, err := pool.OpenPool()
+
+			Convey("then the error is returned", func() {
+				So(err, ShouldEqual, errUnauthorized)
+				So(refreshes, ShouldHaveLength, 2)
+			})
+		})
+	})
+}
+
+func TestFileCredentials(t *testing.T) {
+	Convey("given a password file", t, func() {
+		f, err := ioutil.TempFile("", "neo4j-password")
+		So(err, ShouldBeNil)
+		defer os.Remove(f.Name())
+		f.WriteString("one\n")
+		f.Close()
+		provider := FileCredentials("neo4j", f.Name())
+
+		Convey("then the password is read without its trailing newline", func() {
+			creds, err := provider.Credentials(false)
+			So(err, ShouldBeNil)
+			So(creds, ShouldResemble, Credentials{Username: "neo4j", Password: "one"})
+			So(fmt.Sprintf("%v %#v", creds, creds), ShouldNotContainSubstring, "one")
+		})
+
+		Convey("when the file is rewritten", func() {
+			provider.Credentials(false)
+			ioutil.WriteFile(f.Name(), []byte("two\n"), 0600)
+			later := time.Now().Add(time.Minute)
+			os.Chtimes(f.Name(), later, later)
+
+			Convey("then the new password is used", func() {
+				creds, err := provider.Credentials(false)
+				So(err, ShouldBeNil)
+				So(creds.Password, ShouldEqual, "two")
+			})
+		})
+	})
+}
@@ -1,6 +1,9 @@
 package bolt
 
-import "github.com/pkg/errors"
+import (
+	"github.com/pkg/errors"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+)
 
 type Params map[string]interface{}
 
@@ -8,6 +11,13 @@ type Params map[string]interface{}
 type Stmt struct {
 	Query  string
 	Params Params
+	// Tags lists the result cache tags the statement touches, cached results carrying any of them are invalidated
+	// once the statement has been executed.
+	Tags []string
+	// Idempotent marks a write that can safely be applied twice, such as a MERGE, so it is retried on a fresh
+	// connection if its connection breaks. Other writes are not, as the connection may break after the statement was
+	// applied but before its response was received.
+	Idempotent bool
 }
 
 func (d *DB) Exec(s Stmt) (int64, map[string]interface{}, error) {
@@ -15,13 +25,31 @@ func (d *DB) Exec(s Stmt) (int64, map[string]interface{}, error) {
 		return 0, nil, nil
 	}
 
-	conn, err := d.pool.OpenPool()
+	execStmt := func(conn neo4j.Conn) (neo4j.Result, error) {
+		return conn.ExecNeo(s.Query, s.Params)
+	}
+	rowsAffected, meta, err := d.exec(describe("exec", s.Query), s.Idempotent, execStmt)
+	if d.resultCache != nil {
+		d.resultCache.InvalidateTags(s.Tags...)
+	}
+	return rowsAffected, meta, err
+}
+
+func (d *DB) exec(desc string, idempotent bool, execStmt func(conn neo4j.Conn) (neo4j.Result, error)) (int64, map[string]interface{}, error) {
+	op, err := d.open(desc)
 	if err != nil {
-		return 0, nil, errors.WithMessage(err, "error opening neo4j connection")
+		return 0, nil, err
 	}
-	defer conn.Close()
+	defer d.release(op)
+
+	if idempotent {
+		execStmt = d.retryExec(op, execStmt)
+	}
+	return execConn(op.conn, execStmt)
+}
 
-	res, err := conn.ExecNeo(s.Query, s.Params)
+func execConn(conn neo4j.Conn, execStmt func(conn neo4j.Conn) (neo4j.Result, error)) (int64, map[string]interface{}, error) {
+	res, err := execStmt(conn)
 	if err != nil {
 		return 0, nil, errors.WithMessage(err, "error executing statement")
 	}
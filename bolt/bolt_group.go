@@ -0,0 +1,195 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// DefaultFanOutShare is the share of the pool a Group uses at once unless WithFanOutLimit is used, so fan-out can not
+// starve other requests of connections.
+const DefaultFanOutShare = 0.25
+
+// DefaultFanOutLimit is the number of statements a Group runs at once if the size of the pool is not known, such as
+// for a DB created by New without WithPoolSize.
+const DefaultFanOutLimit = 4
+
+// WithFanOutLimit caps the number of statements a Group, or QueryAll, runs at once in place of DefaultFanOutShare of
+// the pool.
+func WithFanOutLimit(limit int) Option {
+	return func(d *DB) {
+		d.fanOutLimit = limit
+	}
+}
+
+// WithPoolSize tells the DB how many connections its pool holds, which Groups take DefaultFanOutShare of. DBs
+// created by NewFromConfig know the size of their pool.
+func WithPoolSize(size int) Option {
+	return func(d *DB) {
+		d.poolSize = size
+	}
+}
+
+// fanOut returns the number of statements a Group runs at once.
+func (d *DB) fanOut() int {
+	switch {
+	case d.fanOutLimit > 0:
+		return d.fanOutLimit
+	case d.poolSize > 0:
+		if limit := int(float64(d.poolSize) * DefaultFanOutShare); limit > 1 {
+			return limit
+		}
+		return 1
+	}
+	return DefaultFanOutLimit
+}
+
+// GroupOption configures a Group.
+type GroupOption func(g *Group)
+
+// CancelOnError makes a Group cancel its remaining statements once one of them fails.
+func CancelOnError() GroupOption {
+	return func(g *Group) {
+		g.cancelErr = true
+	}
+}
+
+// GroupError holds the error of every statement in a Group, in the order the statements were added. Statements that
+// succeeded have a nil error and those cancelled before or while running have the context's error.
+type GroupError struct {
+	Errors []error
+}
+
+func (e *GroupError) Error() string {
+	var msgs []string
+	for i, err := range e.Errors {
+		if err != nil {
+			msgs = append(msgs, fmt.Sprintf("statement %d: %s", i, err))
+		}
+	}
+	return fmt.Sprintf("%d of %d statements failed: %s", len(msgs), len(e.Errors), strings.Join(msgs, "; "))
+}
+
+// Group runs independent read statements concurrently, bounded by the fan-out limit of the DB. Each ResultMapper is
+// only called from the goroutine running its statement, but mappers of different statements run concurrently.
+//
+// Cancellation is checked before a statement starts and between the rows it returns. The driver can not interrupt a
+// statement, so one that is slow to return its first row runs until it does regardless of cancellation.
+type Group struct {
+	db        *DB
+	ctx       context.Context
+	cancel    context.CancelFunc
+	cancelErr bool
+	sem       chan struct{}
+	wg        sync.WaitGroup
+	mutex     sync.Mutex
+	errs      []error
+	failed    bool
+}
+
+// Group creates a Group whose statements are cancelled when ctx is done.
+func (d *DB) Group(ctx context.Context, opts ...GroupOption) *Group {
+	ctx, cancel := context.WithCancel(ctx)
+	g := &Group{db: d, ctx: ctx, cancel: cancel, sem: make(chan struct{}, d.fanOut())}
+	for _, opt := range opts {
+		opt(g)
+	}
+	return g
+}
+
+// QueryForResults adds a statement expected to return 1 or more results to the group.
+func (g *Group) QueryForResults(query string, params map[string]interface{}, mapResult ResultMapper) {
+	g.run(query, params, mapResult, false)
+}
+
+// QueryForResult adds a statement expected to return a single result to the group.
+func (g *Group) QueryForResult(query string, params map[string]interface{}, mapResult ResultMapper) {
+	g.run(query, params, mapResult, true)
+}
+
+// Wait waits for every statement to complete, returning a *GroupError if any of them failed.
+func (g *Group) Wait() error {
+	g.wg.Wait()
+	g.cancel()
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	if !g.failed {
+		return nil
+	}
+	return &GroupError{Errors: append([]error{}, g.errs...)}
+}
+
+func (g *Group) run(query string, params map[string]interface{}, mapResult ResultMapper, singleResult bool) {
+	g.mutex.Lock()
+	i := len(g.errs)
+	g.errs = append(g.errs, nil)
+	g.mutex.Unlock()
+
+	g.wg.Add(1)
+	go func() {
+		defer g.wg.Done()
+
+		select {
+		case g.sem <- struct{}{}:
+			defer func() { <-g.sem }()
+		case <-g.ctx.Done():
+			g.done(i, g.ctx.Err())
+			return
+		}
+		if err := g.ctx.Err(); err != nil {
+			g.done(i, err)
+			return
+		}
+
+		// The driver can not interrupt a running statement, so cancellation is checked between results.
+		err := g.db.query(query, params, func(r *Result) error {
+			if err := g.ctx.Err(); err != nil {
+				return err
+			}
+			if mapResult == nil {
+				return nil
+			}
+			return mapResult(r)
+		}, singleResult)
+		if err != nil && errors.Cause(err) == g.ctx.Err() {
+			err = g.ctx.Err()
+		}
+		g.done(i, err)
+	}()
+}
+
+func (g *Group) done(i int, err error) {
+	if err == nil {
+		return
+	}
+
+	g.mutex.Lock()
+	defer g.mutex.Unlock()
+	g.errs[i] = err
+	g.failed = true
+	if g.cancelErr {
+		g.cancel()
+	}
+}
+
+// QueryAll runs the statements concurrently in a Group with the default options. See Group.QueryAll.
+func (d *DB) QueryAll(ctx context.Context, stmts []Stmt, mappers ...ResultMapper) error {
+	return d.Group(ctx).QueryAll(stmts, mappers...)
+}
+
+// QueryAll adds the statements to the group, passing the results of stmts[i] to mappers[i], then waits for them.
+// Statements without a mapper have their results discarded. It returns a *GroupError if any statement failed.
+func (g *Group) QueryAll(stmts []Stmt, mappers ...ResultMapper) error {
+	for i, s := range stmts {
+		var mapResult ResultMapper
+		if i < len(mappers) {
+			mapResult = mappers[i]
+		}
+		g.QueryForResults(s.Query, s.Params, mapResult)
+	}
+	return g.Wait()
+}
@@ -0,0 +1,139 @@
+package bolt
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	"github.com/ONSdigital/dp-bolt/bolt/mock/mockrows"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newFanOutPool returns a pool whose statements take delay to run and fail if the query is "fail", along with a func
+// returning the most statements seen running at once.
+func newFanOutPool(delay time.Duration) (*mock.DBPoolMock, func() int) {
+	var mutex sync.Mutex
+	running, maxRunning := 0, 0
+
+	pool := &mock.DBPoolMock{
+		OpenPoolFunc: func() (neo4j.Conn, error) {
+			return &mock.NeoConnMock{
+				CloseFunc: closeNoErr,
+				QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+					mutex.Lock()
+					running++
+					if running > maxRunning {
+						maxRunning = running
+					}
+					mutex.Unlock()
+					defer func() {
+						mutex.Lock()
+						running--
+						mutex.Unlock()
+					}()
+
+					if query == "fail" {
+						return nil, Err
+					}
+					time.Sleep(delay)
+					return mockrows.New("query").Row(query), nil
+				},
+			}, nil
+		},
+	}
+	return pool, func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		return maxRunning
+	}
+}
+
+func TestDB_QueryAll(t *testing.T) {
+	Convey("given a DB limited to 2 concurrent statements", t, func() {
+		pool, maxRunning := newFanOutPool(10 * time.Millisecond)
+		db := New(pool, WithFanOutLimit(2))
+
+		Convey("when 6 statements are run", func() {
+			results := make([]string, 6)
+			var stmts []Stmt
+			var mappers []ResultMapper
+			for i, q := range []string{"a", "b", "c", "d", "e", "f"} {
+				i := i
+				stmts = append(stmts, Stmt{Query: q})
+				mappers = append(mappers, func(r *Result) error {
+					results[i] = r.Data[0].(string)
+					return nil
+				})
+			}
+			err := db.QueryAll(context.Background(), stmts, mappers...)
+
+			Convey("then every statement is mapped and no more than 2 run at once", func() {
+				So(err, ShouldBeNil)
+				So(results, ShouldResemble, []string{"a", "b", "c", "d", "e", "f"})
+				So(maxRunning(), ShouldEqual, 2)
+			})
+		})
+	})
+
+	Convey("given a DB whose pool holds 12 connections", t, func() {
+		pool, maxRunning := newFanOutPool(10 * time.Millisecond)
+		db := New(pool, WithPoolSize(12))
+
+		Convey("when 6 statements are run", func() {
+			stmts := make([]Stmt, 6)
+			err := db.QueryAll(context.Background(), stmts)
+
+			Convey("then no more than a quarter of the pool is used at once", func() {
+				So(err, ShouldBeNil)
+				So(maxRunning(), ShouldEqual, 3)
+			})
+		})
+	})
+
+	Convey("given a group cancelling on error", t, func() {
+		pool, _ := newFanOutPool(50 * time.Millisecond)
+		db := New(pool, WithFanOutLimit(3))
+
+		Convey("when one of the statements fails", func() {
+			g := db.Group(context.Background(), CancelOnError())
+			err := g.QueryAll([]Stmt{{Query: "a"}, {Query: "fail"}, {Query: "b"}})
+
+			Convey("then the failure is reported and the other statements are cancelled", func() {
+				So(err, ShouldNotBeNil)
+				groupErr, ok := err.(*GroupError)
+				So(ok, ShouldBeTrue)
+				So(groupErr.Errors, ShouldHaveLength, 3)
+				So(groupErr.Errors[0], ShouldEqual, context.Canceled)
+				So(groupErr.Errors[1].Error(), ShouldEqual, "error executing neo4j query: error")
+				So(groupErr.Errors[2], ShouldEqual, context.Canceled)
+			})
+		})
+	})
+
+	Convey("given a group not cancelling on error", t, func() {
+		pool, _ := newFanOutPool(time.Millisecond)
+		db := New(pool)
+
+		Convey("when one of the statements fails", func() {
+			g := db.Group(context.Background())
+			var mapped []interface{}
+			g.QueryForResult("a", nil, func(r *Result) error {
+				mapped = r.Data
+				return nil
+			})
+			g.QueryForResults("fail", nil, nil)
+			err := g.Wait()
+
+			Convey("then only the failed statement has an error", func() {
+				groupErr := err.(*GroupError)
+				So(groupErr.Errors[0], ShouldBeNil)
+				So(groupErr.Errors[1], ShouldNotBeNil)
+				So(mapped, ShouldResemble, []interface{}{"a"})
+				So(err.Error(), ShouldEqual, "1 of 2 statements failed: statement 1: error executing neo4j query: error")
+			})
+		})
+	})
+}
@@ -0,0 +1,346 @@
+package bolt
+
+import (
+	"bytes"
+	"encoding/json"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-bolt/bolt/graphval"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/pkg/errors"
+)
+
+// maxSafeInt is the largest integer a JavaScript number holds exactly.
+const maxSafeInt = 1<<53 - 1
+
+type jsonResult struct {
+	Index int                    `json:"index"`
+	Data  []interface{}          `json:"data"`
+	Meta  map[string]interface{} `json:"meta,omitempty"`
+}
+
+type jsonNode struct {
+	ID         int64                  `json:"id"`
+	Labels     []string               `json:"labels"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+type jsonRelationship struct {
+	ID         int64                  `json:"id"`
+	Type       string                 `json:"type"`
+	Start      *int64                 `json:"start,omitempty"`
+	End        *int64                 `json:"end,omitempty"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+// MarshalJSON writes the result as {"index", "data", "meta"}. Nodes are written as {"id", "labels", "properties"},
+// relationships as {"id", "type", "start", "end", "properties"}, leaving out start and end for unbound relationships,
+// and paths as arrays alternating nodes and the relationships between them. Floats are always written with a
+// decimal point so they are not read back as integers. Integers a JavaScript number can not hold exactly are written
+// as {"@int": "9007199254740993"} and floats JSON can not hold as {"@float": "NaN"}. Map keys are sorted, so the
+// same result always gives the same JSON.
+func (r Result) MarshalJSON() ([]byte, error) {
+	data := make([]interface{}, len(r.Data))
+	for i, v := range r.Data {
+		var err error
+		if data[i], err = toJSON(v); err != nil {
+			return nil, errors.WithMessage(err, "error encoding result column "+strconv.Itoa(i))
+		}
+	}
+	var meta map[string]interface{}
+	if r.Meta != nil {
+		var err error
+		if meta, err = toJSONMap(r.Meta); err != nil {
+			return nil, errors.WithMessage(err, "error encoding result metadata")
+		}
+	}
+	return json.Marshal(jsonResult{Index: r.Index, Data: data, Meta: meta})
+}
+
+// UnmarshalJSON reads a result written by MarshalJSON, such as from a test fixture. Objects shaped like nodes and
+// relationships are read as graph.Node, graph.Relationship and graph.UnboundRelationship values, and arrays
+// alternating nodes with the relationships between them as graph.Path values. A path of a single node can not be told
+// apart from a list and is read as a list.
+func (r *Result) UnmarshalJSON(b []byte) error {
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var raw jsonResult
+	if err := d.Decode(&raw); err != nil {
+		return err
+	}
+
+	res := Result{Index: raw.Index, Data: make([]interface{}, len(raw.Data))}
+	for i, v := range raw.Data {
+		val, err := fromJSON(v)
+		if err != nil {
+			return errors.WithMessage(err, "error decoding result column "+strconv.Itoa(i))
+		}
+		res.Data[i] = val
+	}
+	if raw.Meta != nil {
+		meta, err := fromJSONMap(raw.Meta)
+		if err != nil {
+			return errors.WithMessage(err, "error decoding result metadata")
+		}
+		res.Meta = meta
+	}
+	*r = res
+	return nil
+}
+
+// JSONValue returns the value Result.MarshalJSON writes for v, a value returned by the driver, so other encodings of
+// driver values, such as the fixtures of bolttest, write them in the same form.
+func JSONValue(v interface{}) (interface{}, error) {
+	return toJSON(v)
+}
+
+// ValueFromJSON reverses JSONValue on a value decoded with json.Decoder.UseNumber.
+func ValueFromJSON(v interface{}) (interface{}, error) {
+	return fromJSON(v)
+}
+
+func toJSON(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case int64:
+		return jsonInt(val), nil
+	case int:
+		return jsonInt(int64(val)), nil
+	case float64:
+		return jsonFloat(val), nil
+	case float32:
+		return jsonFloat(float64(val)), nil
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			var err error
+			if list[i], err = toJSON(item); err != nil {
+				return nil, err
+			}
+		}
+		return list, nil
+	case map[string]interface{}:
+		return toJSONMap(val)
+	case graph.Node:
+		return newJSONNode(val.NodeIdentity, val.Labels, val.Properties)
+	case graph.Relationship:
+		props, err := toJSONMap(val.Properties)
+		return jsonRelationship{ID: val.RelIdentity, Type: val.Type, Start: &val.StartNodeIdentity,
+			End: &val.EndNodeIdentity, Properties: props}, err
+	case graph.UnboundRelationship:
+		props, err := toJSONMap(val.Properties)
+		return jsonRelationship{ID: val.RelIdentity, Type: val.Type, Properties: props}, err
+	case graph.Path:
+		p, err := graphval.PathOf(val)
+		if err != nil {
+			return nil, err
+		}
+		node, err := newJSONNode(p.Nodes[0].ID, p.Nodes[0].Labels, p.Nodes[0].Props)
+		if err != nil {
+			return nil, err
+		}
+		steps := []interface{}{node}
+		for i, rel := range p.Rels {
+			props, err := toJSONMap(rel.Props)
+			if err != nil {
+				return nil, err
+			}
+			node, err := newJSONNode(p.Nodes[i+1].ID, p.Nodes[i+1].Labels, p.Nodes[i+1].Props)
+			if err != nil {
+				return nil, err
+			}
+			start, end := rel.StartID, rel.EndID
+			steps = append(steps, jsonRelationship{ID: rel.ID, Type: rel.Type, Start: &start, End: &end,
+				Properties: props}, node)
+		}
+		return steps, nil
+	}
+	return v, nil
+}
+
+func toJSONMap(m map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		var err error
+		if out[k], err = toJSON(v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+func newJSONNode(id int64, labels []string, props map[string]interface{}) (jsonNode, error) {
+	if labels == nil {
+		labels = []string{}
+	}
+	p, err := toJSONMap(props)
+	return jsonNode{ID: id, Labels: labels, Properties: p}, err
+}
+
+func jsonInt(i int64) interface{} {
+	if i > maxSafeInt || i < -maxSafeInt {
+		return map[string]interface{}{"@int": strconv.FormatInt(i, 10)}
+	}
+	return i
+}
+
+func jsonFloat(f float64) interface{} {
+	s := strconv.FormatFloat(f, 'g', -1, 64)
+	if math.IsInf(f, 0) || math.IsNaN(f) {
+		return map[string]interface{}{"@float": s}
+	}
+	if !strings.ContainsAny(s, ".eE") {
+		s += ".0"
+	}
+	return json.Number(s)
+}
+
+// fromJSON reverses toJSON on a value decoded with json.Decoder.UseNumber.
+func fromJSON(v interface{}) (interface{}, error) {
+	switch val := v.(type) {
+	case json.Number:
+		if strings.ContainsAny(string(val), ".eE") {
+			return val.Float64()
+		}
+		return val.Int64()
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			var err error
+			if list[i], err = fromJSON(item); err != nil {
+				return nil, err
+			}
+		}
+		if p, ok := pathFromJSON(list); ok {
+			return p, nil
+		}
+		return list, nil
+	case map[string]interface{}:
+		return objectFromJSON(val)
+	}
+	return v, nil
+}
+
+func fromJSONMap(m map[string]interface{}) (map[string]interface{}, error) {
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		var err error
+		if out[k], err = fromJSON(v); err != nil {
+			return nil, err
+		}
+	}
+	return out, nil
+}
+
+// objectFromJSON reads an object written for a special number, node or relationship, or otherwise a map.
+func objectFromJSON(m map[string]interface{}) (interface{}, error) {
+	if s, ok := m["@int"].(string); ok && len(m) == 1 {
+		return strconv.ParseInt(s, 10, 64)
+	}
+	if s, ok := m["@float"].(string); ok && len(m) == 1 {
+		return strconv.ParseFloat(s, 64)
+	}
+
+	props, hasProps := m["properties"].(map[string]interface{})
+	id, hasID := m["id"].(json.Number)
+	if hasProps && hasID {
+		id, err := id.Int64()
+		if err != nil {
+			return nil, err
+		}
+		props, err := fromJSONMap(props)
+		if err != nil {
+			return nil, err
+		}
+
+		labels, hasLabels := m["labels"].([]interface{})
+		relType, hasType := m["type"].(string)
+		start, hasStart := m["start"].(json.Number)
+		end, hasEnd := m["end"].(json.Number)
+		switch {
+		case hasLabels && len(m) == 3:
+			n := graph.Node{NodeIdentity: id, Labels: []string{}, Properties: props}
+			for _, l := range labels {
+				label, ok := l.(string)
+				if !ok {
+					return nil, errors.Errorf("node label %v is not a string", l)
+				}
+				n.Labels = append(n.Labels, label)
+			}
+			return n, nil
+		case hasType && hasStart && hasEnd && len(m) == 5:
+			s, err := start.Int64()
+			if err != nil {
+				return nil, err
+			}
+			e, err := end.Int64()
+			if err != nil {
+				return nil, err
+			}
+			return graph.Relationship{RelIdentity: id, StartNodeIdentity: s, EndNodeIdentity: e, Type: relType,
+				Properties: props}, nil
+		case hasType && len(m) == 3:
+			return graph.UnboundRelationship{RelIdentity: id, Type: relType, Properties: props}, nil
+		}
+	}
+	return fromJSONMap(m)
+}
+
+// pathFromJSON reads a list alternating nodes with relationships between them as a path, returning false if the list
+// is not one.
+func pathFromJSON(list []interface{}) (graph.Path, bool) {
+	if len(list) < 3 || len(list)%2 == 0 {
+		return graph.Path{}, false
+	}
+
+	var p graph.Path
+	nodeIndex := map[int64]int{}
+	relIndex := map[int64]int{}
+	addNode := func(n graph.Node) int {
+		i, ok := nodeIndex[n.NodeIdentity]
+		if !ok {
+			i = len(p.Nodes)
+			nodeIndex[n.NodeIdentity] = i
+			p.Nodes = append(p.Nodes, n)
+		}
+		return i
+	}
+
+	prev, ok := list[0].(graph.Node)
+	if !ok {
+		return graph.Path{}, false
+	}
+	addNode(prev)
+	for i := 1; i < len(list); i += 2 {
+		rel, ok := list[i].(graph.Relationship)
+		if !ok {
+			return graph.Path{}, false
+		}
+		next, ok := list[i+1].(graph.Node)
+		if !ok {
+			return graph.Path{}, false
+		}
+
+		sign := 1
+		switch {
+		case rel.StartNodeIdentity == prev.NodeIdentity && rel.EndNodeIdentity == next.NodeIdentity:
+		case rel.StartNodeIdentity == next.NodeIdentity && rel.EndNodeIdentity == prev.NodeIdentity:
+			sign = -1
+		default:
+			return graph.Path{}, false
+		}
+
+		ri, ok := relIndex[rel.RelIdentity]
+		if !ok {
+			ri = len(p.Relationships)
+			relIndex[rel.RelIdentity] = ri
+			p.Relationships = append(p.Relationships,
+				graph.UnboundRelationship{RelIdentity: rel.RelIdentity, Type: rel.Type, Properties: rel.Properties})
+		}
+		p.Sequence = append(p.Sequence, sign*(ri+1), addNode(next))
+		prev = next
+	}
+	return p, true
+}
@@ -0,0 +1,101 @@
+package bolt
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResultJSON(t *testing.T) {
+	Convey("given a result holding graph values", t, func() {
+		overall := graph.Node{NodeIdentity: 1, Labels: []string{"Code"}, Properties: map[string]interface{}{"code": "A0"}}
+		food := graph.Node{NodeIdentity: 2, Labels: []string{"Code"}, Properties: map[string]interface{}{"code": "G10"}}
+		r := Result{
+			Index: 3,
+			Data: []interface{}{
+				overall,
+				graph.Relationship{RelIdentity: 5, StartNodeIdentity: 1, EndNodeIdentity: 2, Type: "PARENT_OF",
+					Properties: map[string]interface{}{"order": int64(1)}},
+				// (food)<-[:PARENT_OF]-(overall)
+				graph.Path{
+					Nodes:         []graph.Node{food, overall},
+					Relationships: []graph.UnboundRelationship{{RelIdentity: 5, Type: "PARENT_OF", Properties: map[string]interface{}{}}},
+					Sequence:      []int{-1, 1},
+				},
+			},
+			Meta: map[string]interface{}{"fields": []interface{}{"n", "r", "p"}},
+		}
+
+		Convey("when it is marshalled", func() {
+			b, err := json.Marshal(r)
+
+			Convey("then nodes, relationships and paths are written in a stable, readable form", func() {
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, `{"index":3,"data":[`+
+					`{"id":1,"labels":["Code"],"properties":{"code":"A0"}},`+
+					`{"id":5,"type":"PARENT_OF","start":1,"end":2,"properties":{"order":1}},`+
+					`[{"id":2,"labels":["Code"],"properties":{"code":"G10"}},`+
+					`{"id":5,"type":"PARENT_OF","start":1,"end":2,"properties":{}},`+
+					`{"id":1,"labels":["Code"],"properties":{"code":"A0"}}]],`+
+					`"meta":{"fields":["n","r","p"]}}`)
+			})
+
+			Convey("then unmarshalling it gives back the same result", func() {
+				var decoded Result
+				So(json.Unmarshal(b, &decoded), ShouldBeNil)
+				So(decoded, ShouldResemble, r)
+			})
+		})
+	})
+
+	Convey("given a result holding numbers JSON can not hold as is", t, func() {
+		r := Result{Data: []interface{}{
+			int64(math.MaxInt64), int64(-1 << 60), int64(42), 2.0, 0.5, math.Inf(-1),
+			[]interface{}{nil, true, "x"},
+			map[string]interface{}{"nested": 1e21},
+			graph.UnboundRelationship{RelIdentity: 9, Type: "HAS", Properties: map[string]interface{}{}},
+		}}
+
+		Convey("when it is marshalled", func() {
+			b, err := json.Marshal(r)
+			So(err, ShouldBeNil)
+
+			Convey("then unsafe integers and non-finite floats are written as tagged strings", func() {
+				So(string(b), ShouldContainSubstring, `{"@int":"9223372036854775807"}`)
+				So(string(b), ShouldContainSubstring, `{"@int":"-1152921504606846976"}`)
+				So(string(b), ShouldContainSubstring, `42,2.0,0.5,{"@float":"-Inf"}`)
+			})
+
+			Convey("then unmarshalling it keeps every type", func() {
+				var decoded Result
+				So(json.Unmarshal(b, &decoded), ShouldBeNil)
+				So(decoded, ShouldResemble, r)
+			})
+		})
+	})
+
+	Convey("given a list of nodes that is not a path", t, func() {
+		b := []byte(`{"index":0,"data":[[{"id":1,"labels":[],"properties":{}},{"id":2,"labels":[],"properties":{}}]]}`)
+
+		Convey("then it is read back as a list", func() {
+			var decoded Result
+			So(json.Unmarshal(b, &decoded), ShouldBeNil)
+			So(decoded.Data[0], ShouldHaveLength, 2)
+			_, isList := decoded.Data[0].([]interface{})
+			So(isList, ShouldBeTrue)
+		})
+	})
+
+	Convey("given a result holding a path whose sequence is not valid", t, func() {
+		r := Result{Data: []interface{}{"a", graph.Path{Nodes: []graph.Node{{}}, Sequence: []int{3, 0}}}}
+
+		Convey("then marshalling it returns an error naming the column instead of writing null", func() {
+			_, err := json.Marshal(r)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "error encoding result column 1")
+		})
+	})
+}
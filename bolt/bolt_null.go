@@ -0,0 +1,265 @@
+package bolt
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/ONSdigital/dp-bolt/bolt/internal/convert"
+	"github.com/pkg/errors"
+)
+
+// NullString is a string that may be null, such as an optional property or an OPTIONAL MATCH column. It can be
+// scanned from a Result, decoded into by struct mapping and passed as a Params value, where it is sent as null if not
+// Valid.
+type NullString struct {
+	String string
+	Valid  bool
+}
+
+// Value returns the string, or nil if it is not valid.
+func (n NullString) Value() (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.String, nil
+}
+
+// Scan sets the string from a value received from the driver, marking it not valid if the value is null.
+func (n *NullString) Scan(src interface{}) error {
+	if src == nil {
+		*n = NullString{}
+		return nil
+	}
+	s, ok := src.(string)
+	if !ok {
+		return errors.Errorf("can not scan %s into NullString", convert.Describe(src))
+	}
+	*n = NullString{String: s, Valid: true}
+	return nil
+}
+
+// MarshalJSON writes the string, or null if it is not valid.
+func (n NullString) MarshalJSON() ([]byte, error) {
+	return marshalNull(n.String, n.Valid)
+}
+
+// UnmarshalJSON reads a string or null.
+func (n *NullString) UnmarshalJSON(b []byte) error {
+	n.Valid = false
+	if isJSONNull(b) {
+		n.String = ""
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.String); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// NullInt64 is an integer that may be null. See NullString.
+type NullInt64 struct {
+	Int64 int64
+	Valid bool
+}
+
+// Value returns the integer, or nil if it is not valid.
+func (n NullInt64) Value() (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Int64, nil
+}
+
+// Scan sets the integer from a value received from the driver, marking it not valid if the value is null. A float is
+// accepted if it is integral.
+func (n *NullInt64) Scan(src interface{}) error {
+	if src == nil {
+		*n = NullInt64{}
+		return nil
+	}
+	i, ok := convert.Int64(src)
+	if !ok {
+		return errors.Errorf("can not scan %s into NullInt64", convert.Describe(src))
+	}
+	*n = NullInt64{Int64: i, Valid: true}
+	return nil
+}
+
+// MarshalJSON writes the integer, or null if it is not valid.
+func (n NullInt64) MarshalJSON() ([]byte, error) {
+	return marshalNull(n.Int64, n.Valid)
+}
+
+// UnmarshalJSON reads an integer or null.
+func (n *NullInt64) UnmarshalJSON(b []byte) error {
+	n.Valid = false
+	if isJSONNull(b) {
+		n.Int64 = 0
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.Int64); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// NullFloat64 is a float that may be null. See NullString.
+type NullFloat64 struct {
+	Float64 float64
+	Valid   bool
+}
+
+// Value returns the float, or nil if it is not valid.
+func (n NullFloat64) Value() (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Float64, nil
+}
+
+// Scan sets the float from a value received from the driver, marking it not valid if the value is null. An integer is
+// accepted if a float holds it exactly.
+func (n *NullFloat64) Scan(src interface{}) error {
+	if src == nil {
+		*n = NullFloat64{}
+		return nil
+	}
+	f, ok := convert.Float64(src)
+	if !ok {
+		return errors.Errorf("can not scan %s into NullFloat64", convert.Describe(src))
+	}
+	*n = NullFloat64{Float64: f, Valid: true}
+	return nil
+}
+
+// MarshalJSON writes the float, or null if it is not valid.
+func (n NullFloat64) MarshalJSON() ([]byte, error) {
+	return marshalNull(n.Float64, n.Valid)
+}
+
+// UnmarshalJSON reads a number or null.
+func (n *NullFloat64) UnmarshalJSON(b []byte) error {
+	n.Valid = false
+	if isJSONNull(b) {
+		n.Float64 = 0
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.Float64); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// NullBool is a boolean that may be null. See NullString.
+type NullBool struct {
+	Bool  bool
+	Valid bool
+}
+
+// Value returns the boolean, or nil if it is not valid.
+func (n NullBool) Value() (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.Bool, nil
+}
+
+// Scan sets the boolean from a value received from the driver, marking it not valid if the value is null.
+func (n *NullBool) Scan(src interface{}) error {
+	if src == nil {
+		*n = NullBool{}
+		return nil
+	}
+	b, ok := src.(bool)
+	if !ok {
+		return errors.Errorf("can not scan %s into NullBool", convert.Describe(src))
+	}
+	*n = NullBool{Bool: b, Valid: true}
+	return nil
+}
+
+// MarshalJSON writes the boolean, or null if it is not valid.
+func (n NullBool) MarshalJSON() ([]byte, error) {
+	return marshalNull(n.Bool, n.Valid)
+}
+
+// UnmarshalJSON reads a boolean or null.
+func (n *NullBool) UnmarshalJSON(b []byte) error {
+	n.Valid = false
+	if isJSONNull(b) {
+		n.Bool = false
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.Bool); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+// Null is a value of any type that may be null, such as Null[time.Duration] or Null[[]string]. See NullString.
+type Null[T any] struct {
+	V     T
+	Valid bool
+}
+
+// NullOf returns a valid Null holding v.
+func NullOf[T any](v T) Null[T] {
+	return Null[T]{V: v, Valid: true}
+}
+
+// Value returns the value, or nil if it is not valid.
+func (n Null[T]) Value() (interface{}, error) {
+	if !n.Valid {
+		return nil, nil
+	}
+	return n.V, nil
+}
+
+// Scan sets the value from a value received from the driver, marking it not valid if the value is null. Numbers are
+// converted to the type of the value when no precision is lost, and lists to slices.
+func (n *Null[T]) Scan(src interface{}) error {
+	if src == nil {
+		*n = Null[T]{}
+		return nil
+	}
+	var v T
+	if err := convert.Assign(reflect.ValueOf(&v).Elem(), src); err != nil {
+		return errors.WithMessage(err, fmt.Sprintf("can not scan into %T", n))
+	}
+	*n = Null[T]{V: v, Valid: true}
+	return nil
+}
+
+// MarshalJSON writes the value, or null if it is not valid.
+func (n Null[T]) MarshalJSON() ([]byte, error) {
+	return marshalNull(n.V, n.Valid)
+}
+
+// UnmarshalJSON reads the value or null.
+func (n *Null[T]) UnmarshalJSON(b []byte) error {
+	*n = Null[T]{}
+	if isJSONNull(b) {
+		return nil
+	}
+	if err := json.Unmarshal(b, &n.V); err != nil {
+		return err
+	}
+	n.Valid = true
+	return nil
+}
+
+func marshalNull(v interface{}, valid bool) ([]byte, error) {
+	if !valid {
+		return []byte("null"), nil
+	}
+	return json.Marshal(v)
+}
+
+func isJSONNull(b []byte) bool {
+	return string(b) == "null"
+}
@@ -0,0 +1,121 @@
+package bolt
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt/graphval"
+	"github.com/ONSdigital/dp-bolt/boltmem"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResultScan(t *testing.T) {
+	Convey("given a row holding values and nulls", t, func() {
+		r := &Result{Data: []interface{}{
+			"cpih01", nil, 3.0, nil, []interface{}{"a", "b"},
+			graph.Node{NodeIdentity: 7, Labels: []string{"Dataset"}, Properties: map[string]interface{}{}},
+		}}
+
+		Convey("when it is scanned into null types", func() {
+			var id, title NullString
+			var editions NullInt64
+			var score NullFloat64
+			var keywords Null[[]string]
+			var node graphval.Node
+			err := r.Scan(&id, &title, &editions, &score, &keywords, &node)
+
+			Convey("then null columns are not valid and the others are set", func() {
+				So(err, ShouldBeNil)
+				So(id, ShouldResemble, NullString{String: "cpih01", Valid: true})
+				So(title.Valid, ShouldBeFalse)
+				So(editions, ShouldResemble, NullInt64{Int64: 3, Valid: true})
+				So(score.Valid, ShouldBeFalse)
+				So(keywords, ShouldResemble, NullOf([]string{"a", "b"}))
+				So(node.ID, ShouldEqual, 7)
+			})
+		})
+
+		Convey("when a null column is scanned into a type that can not hold null", func() {
+			var id, title string
+			var editions int32
+			var score *float64
+			var keywords []string
+			var node interface{}
+			err := r.Scan(&id, &title, &editions, &score, &keywords, &node)
+
+			Convey("then an error names the column instead of a zero value being set", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "result column 1 is null, not string")
+			})
+		})
+
+		Convey("when it is scanned into values that can not hold the columns", func() {
+			var n int64
+			var b NullBool
+			So(r.Scan(&n), ShouldNotBeNil)
+			err := r.Scan(&n, new(string), new(float64), new(*float64), new([]string), new(interface{}))
+			So(err.Error(), ShouldEqual, "result column 0 is string, not int64")
+			err = r.Scan(new(string), new(NullString), &b, new(*float64), new([]string), new(interface{}))
+			So(err.Error(), ShouldEqual,
+				"result column 2 is float64 3, not bolt.NullBool: can not scan float64 3 into NullBool")
+		})
+	})
+
+	Convey("given a graph with an optional property", t, func() {
+		db := New(boltmem.NewPool())
+		_, _, err := db.Exec(Stmt{Query: "CREATE (:Dataset {id: $id, title: $title, editions: $editions})",
+			Params: Params{"id": NullString{String: "cpih01", Valid: true}, "title": NullString{},
+				"editions": NullOf(int32(2))}})
+		So(err, ShouldBeNil)
+
+		Convey("when it is queried with null values within params", func() {
+			var title NullString
+			var editions Null[int32]
+			err := db.QueryForResult("MATCH (d:Dataset) WHERE d.id IN $ids RETURN d.title AS title, d.editions AS editions",
+				Params{"ids": []interface{}{NullString{String: "cpih01", Valid: true}}}, func(r *Result) error {
+					if err := r.ScanByName("title", &title); err != nil {
+						return err
+					}
+					return r.ScanByName("editions", &editions)
+				})
+
+			Convey("then invalid values were sent as null and valid ones as their value", func() {
+				So(err, ShouldBeNil)
+				So(title.Valid, ShouldBeFalse)
+				So(editions, ShouldResemble, NullOf(int32(2)))
+			})
+		})
+	})
+}
+
+func TestNullJSON(t *testing.T) {
+	type dimension struct {
+		Label  NullString       `json:"label"`
+		Order  NullInt64        `json:"order"`
+		Weight NullFloat64      `json:"weight"`
+		Leaf   NullBool         `json:"leaf"`
+		Codes  Null[[]string]   `json:"codes"`
+		Parent Null[*dimension] `json:"parent"`
+	}
+
+	Convey("given null and valid values", t, func() {
+		d := dimension{Label: NullString{String: "Food", Valid: true}, Weight: NullFloat64{Float64: 1.5, Valid: true},
+			Leaf: NullBool{Bool: false, Valid: true}, Codes: NullOf([]string{"G10"})}
+
+		Convey("when they are marshalled", func() {
+			b, err := json.Marshal(d)
+
+			Convey("then null values are written as null", func() {
+				So(err, ShouldBeNil)
+				So(string(b), ShouldEqual, `{"label":"Food","order":null,"weight":1.5,"leaf":false,"codes":["G10"],"parent":null}`)
+			})
+
+			Convey("then unmarshalling them gives back the same values", func() {
+				decoded := dimension{Order: NullInt64{Int64: 9, Valid: true}}
+				So(json.Unmarshal(b, &decoded), ShouldBeNil)
+				So(decoded, ShouldResemble, d)
+			})
+		})
+	})
+}
@@ -0,0 +1,79 @@
+package bolt
+
+import (
+	"github.com/ONSdigital/dp-bolt/bolt/internal/convert"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+// encodeParams replaces parameter values the driver can not send, including those within lists and maps, with the
+// values they are sent as. See convert.Encode. The params are returned as they are if nothing needs replacing.
+func encodeParams(params map[string]interface{}) (map[string]interface{}, error) {
+	encoded, _, err := convert.EncodeMap(params, "parameter ")
+	if err != nil {
+		return nil, errors.WithMessage(err, "error encoding")
+	}
+	return encoded, nil
+}
+
+// paramsConn encodes the params of every statement run on a connection, so however a statement is run its params are
+// encoded in one place before they reach the driver.
+type paramsConn struct {
+	neo4j.Conn
+}
+
+func (c paramsConn) unwrap() neo4j.Conn {
+	return c.Conn
+}
+
+func (c paramsConn) QueryNeo(query string, params map[string]interface{}) (neo4j.Rows, error) {
+	params, err := encodeParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return c.Conn.QueryNeo(query, params)
+}
+
+func (c paramsConn) QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	params, err := encodeParams(params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	return c.Conn.QueryNeoAll(query, params)
+}
+
+func (c paramsConn) ExecNeo(query string, params map[string]interface{}) (neo4j.Result, error) {
+	params, err := encodeParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return c.Conn.ExecNeo(query, params)
+}
+
+func (c paramsConn) PrepareNeo(query string) (neo4j.Stmt, error) {
+	stmt, err := c.Conn.PrepareNeo(query)
+	if err != nil {
+		return nil, err
+	}
+	return paramsStmt{Stmt: stmt}, nil
+}
+
+type paramsStmt struct {
+	neo4j.Stmt
+}
+
+func (s paramsStmt) QueryNeo(params map[string]interface{}) (neo4j.Rows, error) {
+	params, err := encodeParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.QueryNeo(params)
+}
+
+func (s paramsStmt) ExecNeo(params map[string]interface{}) (neo4j.Result, error) {
+	params, err := encodeParams(params)
+	if err != nil {
+		return nil, err
+	}
+	return s.Stmt.ExecNeo(params)
+}
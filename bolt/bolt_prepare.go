@@ -0,0 +1,179 @@
+package bolt
+
+import (
+	"container/list"
+	"sync"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+var ErrEmptyQuery = errors.New("query must not be empty")
+
+// PreparedStmt is a reusable handle for a single cypher query. Bolt v1 has no server-side prepare: the driver's
+// PrepareNeo only holds the query on the client, and the query text is sent with every RUN. Executing a PreparedStmt
+// therefore prepares it on the connection taken from the pool, runs it and closes the driver statement before the
+// connection is returned to the pool. What a PreparedStmt saves is the client-side work done once in Prepare, such as
+// describing the query for ShutdownError.
+type PreparedStmt struct {
+	db      *DB
+	query   string
+	summary string
+}
+
+// StmtCacheStats is a snapshot of the prepared statement cache counters.
+type StmtCacheStats struct {
+	Hits      int64
+	Misses    int64
+	Evictions int64
+	Size      int
+}
+
+// WithStmtCache enables an LRU cache of up to size prepared statements keyed on the query text, so repeated calls to
+// DB.Prepare with the same cypher reuse the existing handle. The cache is held by the DB rather than per connection:
+// the driver only permits one open statement per connection and pooled connections are copied each time they are
+// reclaimed, so a driver statement can not outlive a single checkout.
+func WithStmtCache(size int) Option {
+	return func(d *DB) {
+		if size > 0 {
+			d.stmtCache = newStmtCache(size)
+		}
+	}
+}
+
+// Prepare returns a PreparedStmt for the provided query. If the statement cache is enabled and the query has been
+// prepared before the cached handle is returned.
+func (d *DB) Prepare(query string) (*PreparedStmt, error) {
+	if query == "" {
+		return nil, ErrEmptyQuery
+	}
+
+	create := func() *PreparedStmt {
+		return &PreparedStmt{db: d, query: query, summary: summarise(query)}
+	}
+	if d.stmtCache == nil {
+		return create(), nil
+	}
+	return d.stmtCache.getOrAdd(query, create), nil
+}
+
+// StmtCacheStats returns the current hit, miss and eviction counts of the prepared statement cache. A zero value is
+// returned if the cache is not enabled.
+func (d *DB) StmtCacheStats() StmtCacheStats {
+	if d.stmtCache == nil {
+		return StmtCacheStats{}
+	}
+	return d.stmtCache.stats()
+}
+
+// Query returns the cypher query of the prepared statement.
+func (s *PreparedStmt) Query() string {
+	return s.query
+}
+
+// QueryForResults executes the prepared statement to return 1 or more results.
+func (s *PreparedStmt) QueryForResults(params map[string]interface{}, mapResult ResultMapper) error {
+	return s.db.queryRows("query "+s.summary, s.openRows(params), mapResult, false)
+}
+
+// QueryForResult executes the prepared statement to return a single result.
+func (s *PreparedStmt) QueryForResult(params map[string]interface{}, mapResult ResultMapper) error {
+	return s.db.queryRows("query "+s.summary, s.openRows(params), mapResult, true)
+}
+
+// Exec executes the prepared statement returning the number of rows affected and the result metadata. It is not
+// retried if its connection breaks, see Stmt.Idempotent.
+func (s *PreparedStmt) Exec(params Params) (int64, map[string]interface{}, error) {
+	return s.db.exec("exec "+s.summary, false, func(conn neo4j.Conn) (neo4j.Result, error) {
+		stmt, err := conn.PrepareNeo(s.query)
+		if err != nil {
+			return nil, errors.WithMessage(err, "error preparing statement")
+		}
+		defer stmt.Close()
+
+		return stmt.ExecNeo(params)
+	})
+}
+
+func (s *PreparedStmt) openRows(params map[string]interface{}) func(conn neo4j.Conn) (neo4j.Rows, error) {
+	return func(conn neo4j.Conn) (neo4j.Rows, error) {
+		stmt, err := conn.PrepareNeo(s.query)
+		if err != nil {
+			return nil, errors.WithMessage(err, "error preparing statement")
+		}
+
+		rows, err := stmt.QueryNeo(params)
+		if err != nil {
+			stmt.Close()
+			return nil, err
+		}
+		return &stmtRows{Rows: rows, stmt: stmt}, nil
+	}
+}
+
+// stmtRows closes the driver statement once the rows it produced are closed.
+type stmtRows struct {
+	neo4j.Rows
+	stmt neo4j.Stmt
+}
+
+func (r *stmtRows) Close() error {
+	rowsErr := r.Rows.Close()
+	if err := r.stmt.Close(); err != nil && rowsErr == nil {
+		return err
+	}
+	return rowsErr
+}
+
+type stmtCache struct {
+	mutex     sync.Mutex
+	size      int
+	entries   map[string]*list.Element
+	order     *list.List
+	hits      int64
+	misses    int64
+	evictions int64
+}
+
+func newStmtCache(size int) *stmtCache {
+	return &stmtCache{
+		size:    size,
+		entries: make(map[string]*list.Element),
+		order:   list.New(),
+	}
+}
+
+func (c *stmtCache) getOrAdd(query string, create func() *PreparedStmt) *PreparedStmt {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	if e, ok := c.entries[query]; ok {
+		c.hits++
+		c.order.MoveToFront(e)
+		return e.Value.(*PreparedStmt)
+	}
+
+	c.misses++
+	stmt := create()
+	c.entries[query] = c.order.PushFront(stmt)
+
+	if c.order.Len() > c.size {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*PreparedStmt).query)
+		c.evictions++
+	}
+	return stmt
+}
+
+func (c *stmtCache) stats() StmtCacheStats {
+	c.mutex.Lock()
+	defer c.mutex.Unlock()
+
+	return StmtCacheStats{
+		Hits:      c.hits,
+		Misses:    c.misses,
+		Evictions: c.evictions,
+		Size:      c.order.Len(),
+	}
+}
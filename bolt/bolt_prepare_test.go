@@ -0,0 +1,163 @@
+package bolt
+
+import (
+	"io"
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDB_Prepare(t *testing.T) {
+	Convey("given an empty query", t, func() {
+		db := New(&mock.DBPoolMock{})
+
+		Convey("when Prepare is called then ErrEmptyQuery is returned", func() {
+			stmt, err := db.Prepare("")
+			So(stmt, ShouldBeNil)
+			So(err, ShouldEqual, ErrEmptyQuery)
+		})
+	})
+
+	Convey("given the statement cache is disabled", t, func() {
+		db := New(&mock.DBPoolMock{})
+
+		Convey("when Prepare is called twice with the same query then a new handle is returned each time", func() {
+			first, err := db.Prepare("MATCH (n) RETURN n")
+			So(err, ShouldBeNil)
+			second, err := db.Prepare("MATCH (n) RETURN n")
+			So(err, ShouldBeNil)
+
+			So(first, ShouldNotPointTo, second)
+			So(first.Query(), ShouldEqual, "MATCH (n) RETURN n")
+			So(db.StmtCacheStats(), ShouldResemble, StmtCacheStats{})
+		})
+	})
+
+	Convey("given the statement cache is enabled", t, func() {
+		db := New(&mock.DBPoolMock{}, WithStmtCache(2))
+
+		Convey("when Prepare is called with a repeated query then the cached handle is reused", func() {
+			first, _ := db.Prepare("a")
+			second, _ := db.Prepare("a")
+
+			So(first, ShouldPointTo, second)
+			So(db.StmtCacheStats(), ShouldResemble, StmtCacheStats{Hits: 1, Misses: 1, Size: 1})
+		})
+
+		Convey("when more queries are prepared than the cache size then the least recently used is evicted", func() {
+			a, _ := db.Prepare("a")
+			db.Prepare("b")
+			db.Prepare("a")
+			db.Prepare("c")
+			aAgain, _ := db.Prepare("a")
+			b, _ := db.Prepare("b")
+
+			So(aAgain, ShouldPointTo, a)
+			So(b.Query(), ShouldEqual, "b")
+			So(db.StmtCacheStats(), ShouldResemble, StmtCacheStats{Hits: 2, Misses: 4, Evictions: 2, Size: 2})
+		})
+	})
+}
+
+func TestPreparedStmt_QueryForResult(t *testing.T) {
+	Convey("given a prepared statement returning a single row", t, func() {
+		rowsStub := &mock.RowsStub{
+			Rows: []mock.RowValues{
+				{Data: expectedData, Meta: expectedMeta},
+				{Err: io.EOF},
+			},
+		}
+		rows := &mock.NeoRowsMock{
+			NextNeoFunc: rowsStub.Next,
+			CloseFunc:   closeNoErr,
+		}
+		stmt := &mock.NeoStmtMock{
+			QueryNeoFunc: func(params map[string]interface{}) (neo4j.Rows, error) {
+				return rows, nil
+			},
+			CloseFunc: closeNoErr,
+		}
+		conn := &mock.NeoConnMock{
+			PrepareNeoFunc: func(query string) (neo4j.Stmt, error) {
+				return stmt, nil
+			},
+			CloseFunc: closeNoErr,
+		}
+		pool := &mock.DBPoolMock{
+			OpenPoolFunc: func() (neo4j.Conn, error) {
+				return conn, nil
+			},
+		}
+		db := New(pool, WithStmtCache(10))
+		prepared, err := db.Prepare("MATCH (n) RETURN count(*)")
+		So(err, ShouldBeNil)
+
+		Convey("when QueryForResult is called", func() {
+			resultMapper := ResultMapperMock{
+				MapResultFunc: func(r *Result) error {
+					return nil
+				},
+			}
+
+			err := prepared.QueryForResult(Params{"key": "value"}, resultMapper.Do)
+
+			Convey("then the query is prepared on the connection and the statement, rows and connection are closed", func() {
+				So(err, ShouldBeNil)
+				So(conn.PrepareNeoCalls(), ShouldHaveLength, 1)
+				So(conn.PrepareNeoCalls()[0].Query, ShouldEqual, "MATCH (n) RETURN count(*)")
+				So(stmt.QueryNeoCalls(), ShouldHaveLength, 1)
+				So(stmt.QueryNeoCalls()[0].Params, ShouldResemble, map[string]interface{}{"key": "value"})
+				So(rows.CloseCalls(), ShouldHaveLength, 1)
+				So(stmt.CloseCalls(), ShouldHaveLength, 1)
+				So(conn.CloseCalls(), ShouldHaveLength, 1)
+				So(resultMapper.Calls, ShouldHaveLength, 1)
+				So(resultMapper.Calls[0].Data, ShouldResemble, expectedData)
+			})
+		})
+	})
+}
+
+func TestPreparedStmt_Exec(t *testing.T) {
+	Convey("given a prepared statement", t, func() {
+		res := &mock.NeoResultMock{
+			RowsAffectedFunc: func() (int64, error) {
+				return int64(2), nil
+			},
+			MetadataFunc: func() map[string]interface{} {
+				return expectedMeta
+			},
+		}
+		stmt := &mock.NeoStmtMock{
+			ExecNeoFunc: func(params map[string]interface{}) (neo4j.Result, error) {
+				return res, nil
+			},
+			CloseFunc: closeNoErr,
+		}
+		conn := &mock.NeoConnMock{
+			PrepareNeoFunc: func(query string) (neo4j.Stmt, error) {
+				return stmt, nil
+			},
+			CloseFunc: closeNoErr,
+		}
+		pool := &mock.DBPoolMock{
+			OpenPoolFunc: func() (neo4j.Conn, error) {
+				return conn, nil
+			},
+		}
+		db := New(pool)
+		prepared, _ := db.Prepare("CREATE (n)")
+
+		Convey("when Exec is called then the rows affected and metadata are returned and the statement closed", func() {
+			rowsAffected, meta, err := prepared.Exec(Params{"key": "value"})
+
+			So(err, ShouldBeNil)
+			So(rowsAffected, ShouldEqual, 2)
+			So(meta, ShouldResemble, expectedMeta)
+			So(stmt.ExecNeoCalls(), ShouldHaveLength, 1)
+			So(stmt.CloseCalls(), ShouldHaveLength, 1)
+			So(conn.CloseCalls(), ShouldHaveLength, 1)
+		})
+	})
+}
@@ -6,7 +6,7 @@ import (
 	"io"
 )
 
-//go:generate moq -out mock/bolt.go -pkg mock . DBPool NeoConn NeoRows NeoResult
+//go:generate moq -out mock/bolt.go -pkg mock . DBPool NeoConn NeoRows NeoResult NeoStmt
 
 var NonUniqueResult = errors.New("unique result expected but was not")
 
@@ -16,6 +16,8 @@ type Result struct {
 	Data  []interface{}
 	Meta  map[string]interface{}
 	Index int
+
+	columns *resultColumns
 }
 
 type ResultMapper func(r *Result) error
@@ -23,6 +25,7 @@ type ResultMapper func(r *Result) error
 type NeoConn neo4j.Conn
 type NeoRows neo4j.Rows
 type NeoResult neo4j.Result
+type NeoStmt neo4j.Stmt
 
 // DBPool contains the methods to control access to the Neo4J
 // database pool
@@ -32,16 +35,45 @@ type DBPool interface {
 }
 
 type DB struct {
-	pool DBPool
+	pool             DBPool
+	stmtCache        *stmtCache
+	resultCache      *ResultCache
+	fanOutLimit      int
+	poolSize         int
+	breaker          *circuitBreaker
+	reconnectRetries int
+	tlsFiles         *tlsFiles
+	ops              operations
 }
 
+//Option configures optional behaviour of a bolt.DB.
+type Option func(d *DB)
+
 //New create a new bolt.DB struct.
-func New(pool DBPool) *DB {
-	return &DB{pool: pool}
+func New(pool DBPool, opts ...Option) *DB {
+	d := &DB{pool: pool, reconnectRetries: DefaultReconnectRetries}
+	for _, opt := range opts {
+		opt(d)
+	}
+	return d
 }
 
-//Close attempts to close the db connection pool.
+//Close attempts to close the db connection pool without waiting for in-flight operations, see Shutdown. If any are
+//in flight the pool is closed once they have returned their connections. Subsequent calls have no effect.
 func (d *DB) Close() error {
+	d.ops.mutex.Lock()
+	if d.ops.closing {
+		d.ops.mutex.Unlock()
+		return nil
+	}
+	d.ops.closing = true
+	if len(d.ops.active) > 0 {
+		d.ops.closePool = true
+		d.ops.mutex.Unlock()
+		return nil
+	}
+	d.ops.mutex.Unlock()
+
 	return d.pool.Close()
 }
 
@@ -56,45 +88,61 @@ func (d *DB) QueryForResult(query string, params map[string]interface{}, mapResu
 }
 
 func (d *DB) query(cypherQuery string, params map[string]interface{}, mapResult ResultMapper, singleResult bool) error {
-	conn, err := d.pool.OpenPool()
+	return d.queryRows(describe("query", cypherQuery), func(conn neo4j.Conn) (neo4j.Rows, error) {
+		return conn.QueryNeo(cypherQuery, params)
+	}, mapResult, singleResult)
+}
+
+func (d *DB) queryRows(desc string, openRows func(conn neo4j.Conn) (neo4j.Rows, error), mapResult ResultMapper, singleResult bool) error {
+	op, err := d.open(desc)
 	if err != nil {
-		return errors.WithMessage(err, "error opening neo4j connection")
+		return err
 	}
-	defer conn.Close()
+	defer d.release(op)
+
+	return queryConn(op.conn, d.retryRows(op, openRows), mapResult, singleResult)
+}
 
-	rows, err := conn.QueryNeo(cypherQuery, params)
+func queryConn(conn neo4j.Conn, openRows func(conn neo4j.Conn) (neo4j.Rows, error), mapResult ResultMapper, singleResult bool) error {
+	rows, err := openRows(conn)
 	if err != nil {
 		return errors.WithMessage(err, "error executing neo4j query")
 	}
 	defer rows.Close()
 
+	numOfResults, _, err := streamRows(rows, mapResult, singleResult)
+	if err != nil {
+		return err
+	}
+	if numOfResults == 0 {
+		return ErrNoResults
+	}
+	return nil
+}
+
+// streamRows passes each row to mapResult, returning the number of rows and the metadata sent once they are exhausted.
+func streamRows(rows neo4j.Rows, mapResult ResultMapper, singleResult bool) (int, map[string]interface{}, error) {
 	index := 0
 	numOfResults := 0
-results:
+	columns := &resultColumns{rows: rows}
 	for {
 		data, meta, nextNeoErr := rows.NextNeo()
 		if nextNeoErr != nil {
 			if nextNeoErr == io.EOF {
-				break results
-			} else {
-				return errors.WithMessage(nextNeoErr, "extractResults: rows.NextNeo() return unexpected error")
+				return numOfResults, meta, nil
 			}
+			return numOfResults, nil, errors.WithMessage(nextNeoErr, "extractResults: rows.NextNeo() return unexpected error")
 		}
 		numOfResults++
 		if singleResult && index > 0 {
-			return NonUniqueResult
+			return numOfResults, nil, NonUniqueResult
 		}
 
 		if mapResult != nil {
-			if err := mapResult(&Result{Data: data, Meta: meta, Index: index}); err != nil {
-				return errors.WithMessage(err, "mapResult returned an error")
+			if err := mapResult(&Result{Data: data, Meta: meta, Index: index, columns: columns}); err != nil {
+				return numOfResults, nil, errors.WithMessage(err, "mapResult returned an error")
 			}
 		}
 		index++
 	}
-
-	if numOfResults == 0 {
-		return ErrNoResults
-	}
-	return nil
 }
@@ -0,0 +1,135 @@
+package bolt
+
+import (
+	"database/sql/driver"
+	"io"
+	"strings"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	neoerrors "github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/pkg/errors"
+)
+
+// DefaultReconnectRetries is the number of times a query or idempotent statement failing on a broken connection is
+// retried unless WithReconnectRetries is used.
+const DefaultReconnectRetries = 1
+
+// maxCheckoutAttempts is the number of connections WithConnValidation checks out before giving up.
+const maxCheckoutAttempts = 3
+
+// WithReconnectRetries sets the number of times a query, transaction begin or write marked Stmt.Idempotent that failed
+// on a broken connection, before streaming any results, is retried on a fresh connection. Other writes are never
+// retried, as they may have been applied before the connection broke. Zero disables retries.
+func WithReconnectRetries(retries int) Option {
+	return func(d *DB) {
+		d.reconnectRetries = retries
+	}
+}
+
+// WithConnValidation runs query, such as "RETURN 1", on each connection as it is checked out of the pool.
+// Connections found broken, which happens to every idle connection when Neo4j restarts, are discarded and another one
+// is checked out in their place.
+func WithConnValidation(query string) Option {
+	return func(d *DB) {
+		d.pool = &validatingPool{DBPool: d.pool, query: query}
+	}
+}
+
+// isBrokenConn reports whether err was caused by a dead connection rather than by the statement.
+func isBrokenConn(err error) bool {
+	err = errors.Cause(err)
+	if neoErr, ok := err.(*neoerrors.Error); ok {
+		err = neoErr.InnerMost()
+	}
+
+	switch err {
+	case driver.ErrBadConn, io.EOF, io.ErrUnexpectedEOF:
+		return true
+	}
+	msg := err.Error()
+	return strings.Contains(msg, "broken pipe") ||
+		strings.Contains(msg, "connection reset by peer") ||
+		strings.Contains(msg, "use of closed network connection")
+}
+
+// reopen discards the broken connection of the operation and replaces it with a fresh one from the pool.
+func (d *DB) reopen(op *operation) error {
+	d.ops.mutex.Lock()
+	if op.interrupted {
+		d.ops.mutex.Unlock()
+		return ErrDBClosed
+	}
+	conn := op.conn
+	op.conn = nil
+	d.ops.mutex.Unlock()
+
+	conn.Close()
+	conn, err := d.pool.OpenPool()
+	if err != nil {
+		return errors.WithMessage(err, "error opening neo4j connection")
+	}
+	if !d.setConn(op, conn) {
+		return ErrDBClosed
+	}
+	return nil
+}
+
+// retryBroken runs f on fresh connections, up to the reconnect retries of the DB, for as long as err shows the
+// connection of the operation is broken.
+func (d *DB) retryBroken(op *operation, err error, f func(conn neo4j.Conn) error) error {
+	for i := 0; i < d.reconnectRetries && err != nil && isBrokenConn(err); i++ {
+		if d.reopen(op) != nil {
+			break
+		}
+		err = f(op.conn)
+	}
+	return err
+}
+
+// retryRows wraps openRows to retry it on a fresh connection if the connection it was given is broken. Rows that
+// have started streaming are never retried.
+func (d *DB) retryRows(op *operation, openRows func(conn neo4j.Conn) (neo4j.Rows, error)) func(conn neo4j.Conn) (neo4j.Rows, error) {
+	return func(conn neo4j.Conn) (neo4j.Rows, error) {
+		rows, err := openRows(conn)
+		err = d.retryBroken(op, err, func(conn neo4j.Conn) error {
+			rows, err = openRows(conn)
+			return err
+		})
+		return rows, err
+	}
+}
+
+// retryExec wraps execStmt to retry it on a fresh connection if the connection it was given is broken.
+func (d *DB) retryExec(op *operation, execStmt func(conn neo4j.Conn) (neo4j.Result, error)) func(conn neo4j.Conn) (neo4j.Result, error) {
+	return func(conn neo4j.Conn) (neo4j.Result, error) {
+		res, err := execStmt(conn)
+		err = d.retryBroken(op, err, func(conn neo4j.Conn) error {
+			res, err = execStmt(conn)
+			return err
+		})
+		return res, err
+	}
+}
+
+type validatingPool struct {
+	DBPool
+	query string
+}
+
+func (p *validatingPool) OpenPool() (neo4j.Conn, error) {
+	for attempt := 1; ; attempt++ {
+		conn, err := p.DBPool.OpenPool()
+		if err != nil {
+			return nil, err
+		}
+
+		_, _, _, err = conn.QueryNeoAll(p.query, nil)
+		if err == nil {
+			return conn, nil
+		}
+		conn.Close()
+		if !isBrokenConn(err) || attempt == maxCheckoutAttempts {
+			return nil, errors.WithMessage(err, "error validating neo4j connection")
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package bolt
+
+import (
+	"database/sql/driver"
+	"io"
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	"github.com/ONSdigital/dp-bolt/bolt/mock/mockrows"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	neoerrors "github.com/johnnadratowski/golang-neo4j-bolt-driver/errors"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newRestartedPool returns a pool handing out a stale connection failing every call with staleErr, followed by
+// healthy connections.
+func newRestartedPool(staleErr error) (*mock.DBPoolMock, *mock.NeoConnMock) {
+	stale := &mock.NeoConnMock{
+		CloseFunc: closeNoErr,
+		QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+			return nil, staleErr
+		},
+		ExecNeoFunc: func(query string, params map[string]interface{}) (neo4j.Result, error) {
+			return nil, staleErr
+		},
+		QueryNeoAllFunc: func(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+			return nil, nil, nil, staleErr
+		},
+	}
+	healthy := &mock.NeoConnMock{
+		CloseFunc: closeNoErr,
+		QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+			return mockrows.New("n").Row(int64(1)), nil
+		},
+		ExecNeoFunc: func(query string, params map[string]interface{}) (neo4j.Result, error) {
+			return &mock.NeoResultMock{
+				RowsAffectedFunc: func() (int64, error) { return 1, nil },
+				MetadataFunc:     func() map[string]interface{} { return nil },
+			}, nil
+		},
+		QueryNeoAllFunc: func(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+			return nil, nil, nil, nil
+		},
+		BeginFunc: func() (driver.Tx, error) {
+			return &txStub{}, nil
+		},
+	}
+
+	pool := &mock.DBPoolMock{}
+	pool.OpenPoolFunc = func() (neo4j.Conn, error) {
+		if len(pool.OpenPoolCalls()) == 1 {
+			return stale, nil
+		}
+		return healthy, nil
+	}
+	return pool, stale
+}
+
+func TestDB_Reconnect(t *testing.T) {
+	Convey("given the first connection in the pool went stale", t, func() {
+		pool, stale := newRestartedPool(driver.ErrBadConn)
+		db := New(pool)
+
+		Convey("when a query is run", func() {
+			var n interface{}
+			err := db.QueryForResult("RETURN 1", nil, func(r *Result) error {
+				n = r.Data[0]
+				return nil
+			})
+
+			Convey("then it is retried on a fresh connection", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, int64(1))
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 2)
+				So(stale.QueryNeoCalls(), ShouldHaveLength, 1)
+				So(stale.CloseCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("when a transaction is begun", func() {
+			stale.BeginFunc = func() (driver.Tx, error) {
+				return nil, driver.ErrBadConn
+			}
+			_, err := db.Begin()
+
+			Convey("then it is begun on a fresh connection", func() {
+				So(err, ShouldBeNil)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 2)
+				So(stale.BeginCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("given the stale connection reports an EOF wrapped by the driver", t, func() {
+		pool, _ := newRestartedPool(neoerrors.Wrap(io.EOF, "An error occurred reading from stream"))
+		db := New(pool)
+
+		Convey("when a write is executed", func() {
+			_, _, err := db.Exec(Stmt{Query: "CREATE (n)"})
+
+			Convey("then it is not retried as it may have been applied before the connection broke", func() {
+				So(err, ShouldNotBeNil)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("when a write returning rows is executed", func() {
+			_, err := db.ExecReturning(Stmt{Query: "CREATE (n) RETURN n"}, nil)
+
+			Convey("then it is not retried either", func() {
+				So(err, ShouldNotBeNil)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("when an idempotent write is executed", func() {
+			rowsAffected, _, err := db.Exec(Stmt{Query: "MERGE (n {id: 1})", Idempotent: true})
+
+			Convey("then it is retried on a fresh connection", func() {
+				So(err, ShouldBeNil)
+				So(rowsAffected, ShouldEqual, 1)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 2)
+			})
+		})
+	})
+
+	Convey("given a statement failing for a reason other than its connection", t, func() {
+		pool, _ := newRestartedPool(errors.New("Neo.ClientError.Statement.SyntaxError"))
+		db := New(pool)
+
+		Convey("when it is run", func() {
+			err := db.QueryForResult("RETRUN 1", nil, nil)
+
+			Convey("then it is not retried", func() {
+				So(err, ShouldNotBeNil)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("given a connection breaking after the results started streaming", t, func() {
+		rows := mockrows.New("n").Row(int64(1)).Err(driver.ErrBadConn)
+		pool := &mock.DBPoolMock{
+			OpenPoolFunc: func() (neo4j.Conn, error) {
+				return &mock.NeoConnMock{
+					CloseFunc: closeNoErr,
+					QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+						return rows, nil
+					},
+				}, nil
+			},
+		}
+		db := New(pool)
+
+		Convey("when the query is run", func() {
+			err := db.QueryForResults("MATCH (n) RETURN n", nil, nil)
+
+			Convey("then it is not retried", func() {
+				So(err, ShouldNotBeNil)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestWithConnValidation(t *testing.T) {
+	Convey("given a DB validating connections on checkout", t, func() {
+		pool, stale := newRestartedPool(driver.ErrBadConn)
+		db := New(pool, WithConnValidation("RETURN 1"))
+
+		Convey("when a query is run", func() {
+			err := db.QueryForResult("RETURN 1", nil, nil)
+
+			Convey("then the stale connection is dropped before the query is sent", func() {
+				So(err, ShouldBeNil)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 2)
+				So(stale.QueryNeoCalls(), ShouldHaveLength, 0)
+				So(stale.CloseCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
@@ -0,0 +1,386 @@
+package bolt
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"sync"
+
+	"github.com/ONSdigital/dp-bolt/bolt/graphval"
+	"github.com/ONSdigital/dp-bolt/bolt/internal/convert"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/pkg/errors"
+)
+
+// ColumnError reports a column of a Result that is missing or can not be read as the type asked for.
+type ColumnError struct {
+	Index    int
+	Name     string
+	Expected string
+	// Actual describes the value found, such as "string" or "float64 2.5".
+	Actual string
+	// Err is the error converting the value when it was scanned, such as one returned by a codec or Scan method. It
+	// is left out of the message when it only repeats that the types do not match.
+	Err error
+}
+
+func (e *ColumnError) Error() string {
+	column := "result column " + strconv.Itoa(e.Index)
+	if e.Name != "" {
+		column += fmt.Sprintf(" (%q)", e.Name)
+	}
+	msg := fmt.Sprintf("%s is %s, not %s", column, e.Actual, e.Expected)
+	if _, mismatch := errors.Cause(e.Err).(*convert.MismatchError); e.Err != nil && !mismatch {
+		msg += ": " + e.Err.Error()
+	}
+	return msg
+}
+
+// Unwrap returns the error converting the value, for use with errors.Is and errors.As.
+func (e *ColumnError) Unwrap() error {
+	return e.Err
+}
+
+// resultColumns looks up the column names of the rows a result came from. The names are only fetched from the rows
+// when a column is first read by name, unless they are already known.
+type resultColumns struct {
+	rows  neo4j.Rows
+	once  sync.Once
+	names []string
+}
+
+func (c *resultColumns) get() []string {
+	c.once.Do(func() {
+		if c.rows != nil {
+			c.names = c.rows.Columns()
+			c.rows = nil
+		}
+	})
+	return c.names
+}
+
+// NewResult returns the Result for row index of data from the named columns, for code that builds results without
+// running a query, such as mocks of a DB.
+func NewResult(columns []string, data []interface{}, index int) *Result {
+	r := &Result{Data: data, Index: index}
+	if columns != nil {
+		r.columns = &resultColumns{names: columns}
+	}
+	return r
+}
+
+// Columns returns the names of the columns, or nil if they are not known, such as for a result decoded from JSON.
+func (r *Result) Columns() []string {
+	if r.columns != nil {
+		return r.columns.get()
+	}
+	if fields, ok := r.Meta["fields"].([]interface{}); ok {
+		names := make([]string, len(fields))
+		for i, f := range fields {
+			names[i], _ = f.(string)
+		}
+		return names
+	}
+	return nil
+}
+
+// ColumnIndex returns the index of the column with the name.
+func (r *Result) ColumnIndex(name string) (int, error) {
+	columns := r.Columns()
+	for i, c := range columns {
+		if c == name {
+			return i, nil
+		}
+	}
+	if columns == nil {
+		return -1, errors.Errorf("result column %q can not be found as the column names are not known", name)
+	}
+	return -1, errors.Errorf("result has no column %q, only %v", name, columns)
+}
+
+// value returns the value of column i, or an error if there is no such column.
+func (r *Result) value(i int, expected string) (interface{}, error) {
+	if i < 0 || i >= len(r.Data) {
+		return nil, r.columnError(i, expected, fmt.Sprintf("missing from a row of %d columns", len(r.Data)))
+	}
+	if r.Data[i] == nil {
+		return nil, r.columnError(i, expected, "null")
+	}
+	return r.Data[i], nil
+}
+
+func (r *Result) columnError(i int, expected, actual string) *ColumnError {
+	e := &ColumnError{Index: i, Expected: expected, Actual: actual}
+	if columns := r.Columns(); i >= 0 && i < len(columns) {
+		e.Name = columns[i]
+	}
+	return e
+}
+
+// Int64 returns column i as an integer. A float is accepted if it is integral.
+func (r *Result) Int64(i int) (int64, error) {
+	v, err := r.value(i, "int64")
+	if err != nil {
+		return 0, err
+	}
+	n, ok := convert.Int64(v)
+	if !ok {
+		return 0, r.columnError(i, "int64", convert.Describe(v))
+	}
+	return n, nil
+}
+
+// Int returns column i as an int, provided it fits. A float is accepted if it is integral.
+func (r *Result) Int(i int) (int, error) {
+	n, err := r.Int64(i)
+	if err != nil {
+		if e, ok := err.(*ColumnError); ok {
+			e.Expected = "int"
+		}
+		return 0, err
+	}
+	if int64(int(n)) != n {
+		return 0, r.columnError(i, "int", fmt.Sprintf("int64 %d", n))
+	}
+	return int(n), nil
+}
+
+// Float64 returns column i as a float. An integer is accepted if a float holds it exactly.
+func (r *Result) Float64(i int) (float64, error) {
+	v, err := r.value(i, "float64")
+	if err != nil {
+		return 0, err
+	}
+	f, ok := convert.Float64(v)
+	if !ok {
+		return 0, r.columnError(i, "float64", convert.Describe(v))
+	}
+	return f, nil
+}
+
+// String returns column i as a string.
+func (r *Result) String(i int) (string, error) {
+	v, err := r.value(i, "string")
+	if err != nil {
+		return "", err
+	}
+	s, ok := v.(string)
+	if !ok {
+		return "", r.columnError(i, "string", convert.Describe(v))
+	}
+	return s, nil
+}
+
+// Bool returns column i as a boolean.
+func (r *Result) Bool(i int) (bool, error) {
+	v, err := r.value(i, "bool")
+	if err != nil {
+		return false, err
+	}
+	b, ok := v.(bool)
+	if !ok {
+		return false, r.columnError(i, "bool", convert.Describe(v))
+	}
+	return b, nil
+}
+
+// StringSlice returns column i as a list of strings.
+func (r *Result) StringSlice(i int) ([]string, error) {
+	list, err := r.list(i, "[]string")
+	if err != nil {
+		return nil, err
+	}
+	strs := make([]string, len(list))
+	for j, item := range list {
+		s, ok := item.(string)
+		if !ok {
+			return nil, r.columnError(i, "[]string", fmt.Sprintf("a list with %s at %d", convert.Describe(item), j))
+		}
+		strs[j] = s
+	}
+	return strs, nil
+}
+
+// Int64Slice returns column i as a list of integers. Floats are accepted if they are integral.
+func (r *Result) Int64Slice(i int) ([]int64, error) {
+	list, err := r.list(i, "[]int64")
+	if err != nil {
+		return nil, err
+	}
+	ints := make([]int64, len(list))
+	for j, item := range list {
+		n, ok := convert.Int64(item)
+		if !ok {
+			return nil, r.columnError(i, "[]int64", fmt.Sprintf("a list with %s at %d", convert.Describe(item), j))
+		}
+		ints[j] = n
+	}
+	return ints, nil
+}
+
+func (r *Result) list(i int, expected string) ([]interface{}, error) {
+	v, err := r.value(i, expected)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := v.([]interface{})
+	if !ok {
+		return nil, r.columnError(i, expected, convert.Describe(v))
+	}
+	return list, nil
+}
+
+// Map returns column i as a map.
+func (r *Result) Map(i int) (map[string]interface{}, error) {
+	v, err := r.value(i, "map")
+	if err != nil {
+		return nil, err
+	}
+	m, ok := v.(map[string]interface{})
+	if !ok {
+		return nil, r.columnError(i, "map", convert.Describe(v))
+	}
+	return m, nil
+}
+
+// Node returns column i as a node.
+func (r *Result) Node(i int) (graphval.Node, error) {
+	v, err := r.value(i, "node")
+	if err != nil {
+		return graphval.Node{}, err
+	}
+	n, ok := v.(graph.Node)
+	if !ok {
+		return graphval.Node{}, r.columnError(i, "node", convert.Describe(v))
+	}
+	return graphval.NodeOf(n)
+}
+
+// Scan sets the values dest points to from the columns of the row, in order. Numbers are converted when no precision
+// is lost and lists to slices, and nodes can be scanned into graphval.Node. Null columns are only accepted by types
+// that can hold them, such as NullString, pointers and slices, so a missing value is never read as a zero value.
+func (r *Result) Scan(dest ...interface{}) error {
+	if len(dest) != len(r.Data) {
+		return errors.Errorf("result has %d columns, not the %d scanned into", len(r.Data), len(dest))
+	}
+	for i, d := range dest {
+		if err := r.scan(i, d); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ScanByName sets the value dest points to from the named column. See Scan.
+func (r *Result) ScanByName(name string, dest interface{}) error {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return err
+	}
+	return r.scan(i, dest)
+}
+
+func (r *Result) scan(i int, dest interface{}) error {
+	if n, ok := dest.(*graphval.Node); ok {
+		var err error
+		*n, err = r.Node(i)
+		return err
+	}
+
+	d := reflect.ValueOf(dest)
+	if d.Kind() != reflect.Ptr || d.IsNil() {
+		return errors.Errorf("result column %d can not be scanned into %T, which is not a pointer", i, dest)
+	}
+	expected := d.Type().Elem().String()
+	if i < 0 || i >= len(r.Data) {
+		return r.columnError(i, expected, fmt.Sprintf("missing from a row of %d columns", len(r.Data)))
+	}
+	if err := convert.Assign(d.Elem(), r.Data[i]); err != nil {
+		e := r.columnError(i, expected, convert.Describe(r.Data[i]))
+		e.Err = err
+		return e
+	}
+	return nil
+}
+
+// Int64ByName returns the named column as an integer. See Int64.
+func (r *Result) Int64ByName(name string) (int64, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return 0, err
+	}
+	return r.Int64(i)
+}
+
+// IntByName returns the named column as an int. See Int.
+func (r *Result) IntByName(name string) (int, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return 0, err
+	}
+	return r.Int(i)
+}
+
+// Float64ByName returns the named column as a float. See Float64.
+func (r *Result) Float64ByName(name string) (float64, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return 0, err
+	}
+	return r.Float64(i)
+}
+
+// StringByName returns the named column as a string.
+func (r *Result) StringByName(name string) (string, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return "", err
+	}
+	return r.String(i)
+}
+
+// BoolByName returns the named column as a boolean.
+func (r *Result) BoolByName(name string) (bool, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return false, err
+	}
+	return r.Bool(i)
+}
+
+// StringSliceByName returns the named column as a list of strings.
+func (r *Result) StringSliceByName(name string) ([]string, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	return r.StringSlice(i)
+}
+
+// Int64SliceByName returns the named column as a list of integers. See Int64Slice.
+func (r *Result) Int64SliceByName(name string) ([]int64, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	return r.Int64Slice(i)
+}
+
+// MapByName returns the named column as a map.
+func (r *Result) MapByName(name string) (map[string]interface{}, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return nil, err
+	}
+	return r.Map(i)
+}
+
+// NodeByName returns the named column as a node.
+func (r *Result) NodeByName(name string) (graphval.Node, error) {
+	i, err := r.ColumnIndex(name)
+	if err != nil {
+		return graphval.Node{}, err
+	}
+	return r.Node(i)
+}
@@ -0,0 +1,162 @@
+package bolt
+
+import (
+	"math"
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/boltmem"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestResultAccessors(t *testing.T) {
+	Convey("given a row of typical values", t, func() {
+		r := &Result{Data: []interface{}{
+			int64(42), 3.0, 2.5, "cpih01", true,
+			[]interface{}{"a", "b"}, []interface{}{int64(1), 2.0},
+			map[string]interface{}{"k": "v"},
+			graph.Node{NodeIdentity: 7, Labels: []string{"Dataset"}, Properties: map[string]interface{}{"id": "cpih01"}},
+			nil, int64(1 << 60),
+		}}
+
+		Convey("then each column can be read as its type", func() {
+			n, err := r.Int64(0)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 42)
+			s, err := r.String(3)
+			So(err, ShouldBeNil)
+			So(s, ShouldEqual, "cpih01")
+			b, err := r.Bool(4)
+			So(err, ShouldBeNil)
+			So(b, ShouldBeTrue)
+			strs, err := r.StringSlice(5)
+			So(err, ShouldBeNil)
+			So(strs, ShouldResemble, []string{"a", "b"})
+			m, err := r.Map(7)
+			So(err, ShouldBeNil)
+			So(m, ShouldResemble, map[string]interface{}{"k": "v"})
+			node, err := r.Node(8)
+			So(err, ShouldBeNil)
+			So(node.ID, ShouldEqual, 7)
+			So(node.HasLabel("Dataset"), ShouldBeTrue)
+		})
+
+		Convey("then numbers are coerced when no precision is lost", func() {
+			n, err := r.Int64(1)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, 3)
+			i, err := r.Int(0)
+			So(err, ShouldBeNil)
+			So(i, ShouldEqual, 42)
+			f, err := r.Float64(0)
+			So(err, ShouldBeNil)
+			So(f, ShouldEqual, 42)
+			ints, err := r.Int64Slice(6)
+			So(err, ShouldBeNil)
+			So(ints, ShouldResemble, []int64{1, 2})
+		})
+
+		Convey("then coercions that would lose precision are refused", func() {
+			_, err := r.Int64(2)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldEqual, "result column 2 is float64 2.5, not int64")
+			_, err = r.Float64(10)
+			So(err, ShouldNotBeNil)
+		})
+
+		Convey("then reading a column as the wrong type describes the column and both types", func() {
+			_, err := r.Int64(3)
+			colErr, ok := err.(*ColumnError)
+			So(ok, ShouldBeTrue)
+			So(colErr.Index, ShouldEqual, 3)
+			So(colErr.Expected, ShouldEqual, "int64")
+			So(colErr.Actual, ShouldEqual, "string")
+
+			_, err = r.String(9)
+			So(err.Error(), ShouldEqual, "result column 9 is null, not string")
+			_, err = r.Bool(20)
+			So(err.Error(), ShouldEqual, "result column 20 is missing from a row of 11 columns, not bool")
+			_, err = r.StringSlice(6)
+			So(err.Error(), ShouldEqual, "result column 6 is a list with int64 1 at 0, not []string")
+		})
+
+		Convey("then columns can not be read by name when the names are not known", func() {
+			_, err := r.Int64ByName("count")
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "column names are not known")
+		})
+	})
+
+	Convey("given rows streamed from a query", t, func() {
+		db := New(boltmem.NewPool())
+		_, _, err := db.Exec(Stmt{Query: "CREATE (:Dataset {id: 'cpih01', editions: 3, score: 1.5, keywords: ['prices']})"})
+		So(err, ShouldBeNil)
+
+		Convey("when the columns are read by name", func() {
+			var id string
+			var editions int
+			var score float64
+			var keywords []string
+			var labels []string
+			err := db.QueryForResult("MATCH (d:Dataset) RETURN d.id AS id, d.editions AS editions, d.score AS score, "+
+				"d.keywords AS keywords, d", nil, func(r *Result) error {
+				var err error
+				if id, err = r.StringByName("id"); err != nil {
+					return err
+				}
+				if editions, err = r.IntByName("editions"); err != nil {
+					return err
+				}
+				if score, err = r.Float64ByName("score"); err != nil {
+					return err
+				}
+				if keywords, err = r.StringSliceByName("keywords"); err != nil {
+					return err
+				}
+				n, err := r.NodeByName("d")
+				labels = n.Labels
+				return err
+			})
+
+			Convey("then the values are returned", func() {
+				So(err, ShouldBeNil)
+				So(id, ShouldEqual, "cpih01")
+				So(editions, ShouldEqual, 3)
+				So(score, ShouldEqual, 1.5)
+				So(keywords, ShouldResemble, []string{"prices"})
+				So(labels, ShouldResemble, []string{"Dataset"})
+			})
+		})
+
+		Convey("when a column is read as the wrong type or does not exist", func() {
+			var typeErr, nameErr error
+			err := db.QueryForResult("MATCH (d:Dataset) RETURN d.id AS id", nil, func(r *Result) error {
+				_, typeErr = r.Int64ByName("id")
+				_, nameErr = r.Int64ByName("count")
+				return nil
+			})
+
+			Convey("then the errors name the column", func() {
+				So(err, ShouldBeNil)
+				So(typeErr.Error(), ShouldEqual, `result column 0 ("id") is string, not int64`)
+				So(nameErr.Error(), ShouldEqual, `result has no column "count", only [id]`)
+			})
+		})
+	})
+
+	Convey("given integers and floats at the edges of precision", t, func() {
+		r := &Result{Data: []interface{}{math.NaN(), math.Inf(1), float64(1 << 62), int64(1<<53 + 1)}}
+
+		Convey("then only lossless conversions succeed", func() {
+			_, err := r.Int64(0)
+			So(err, ShouldNotBeNil)
+			_, err = r.Int64(1)
+			So(err, ShouldNotBeNil)
+			n, err := r.Int64(2)
+			So(err, ShouldBeNil)
+			So(n, ShouldEqual, int64(1<<62))
+			_, err = r.Float64(3)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,210 @@
+package bolt
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+var ErrDBClosed = errors.New("bolt.DB is closed")
+
+// ShutdownError reports the operations that were interrupted because the shutdown context expired before they
+// finished.
+type ShutdownError struct {
+	Interrupted []string
+	Err         error
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown interrupted %d operation(s), %s: %s", len(e.Interrupted), e.Err, strings.Join(e.Interrupted, "; "))
+}
+
+// Cause returns the context error that ended the shutdown, for use with errors.Cause.
+func (e *ShutdownError) Cause() error {
+	return e.Err
+}
+
+// operation is a query, exec or transaction holding a connection from the pool.
+type operation struct {
+	desc        string
+	conn        neo4j.Conn
+	interrupted bool
+}
+
+// operations tracks in-flight operations so Shutdown can wait for them.
+type operations struct {
+	mutex   sync.Mutex
+	closing bool
+	active  map[*operation]struct{}
+	drained chan struct{}
+	// closePool is set when Shutdown gives up waiting, leaving the last operation to close the pool once it has
+	// returned its connection.
+	closePool bool
+}
+
+// describe summarises a statement for ShutdownError.
+func describe(kind, query string) string {
+	return kind + " " + summarise(query)
+}
+
+// summarise collapses the whitespace in query and shortens it to at most 80 characters.
+func summarise(query string) string {
+	query = strings.Join(strings.Fields(query), " ")
+	if len(query) > 80 {
+		query = query[:77] + "..."
+	}
+	return query
+}
+
+// open registers an operation and opens a connection for it, failing with ErrDBClosed once the DB is shutting down.
+func (d *DB) open(desc string) (*operation, error) {
+	d.ops.mutex.Lock()
+	if d.ops.closing {
+		d.ops.mutex.Unlock()
+		return nil, ErrDBClosed
+	}
+	op := &operation{desc: desc}
+	if d.ops.active == nil {
+		d.ops.active = make(map[*operation]struct{})
+	}
+	d.ops.active[op] = struct{}{}
+	d.ops.mutex.Unlock()
+
+	conn, err := d.pool.OpenPool()
+	if err != nil {
+		d.release(op)
+		return nil, errors.WithMessage(err, "error opening neo4j connection")
+	}
+
+	if !d.setConn(op, conn) {
+		d.release(op)
+		return nil, ErrDBClosed
+	}
+	return op, nil
+}
+
+// setConn gives the operation its connection, unless Shutdown has interrupted the operation while the connection
+// was being opened, in which case the connection is closed and false is returned.
+func (d *DB) setConn(op *operation, conn neo4j.Conn) bool {
+	d.ops.mutex.Lock()
+	if op.interrupted {
+		d.ops.mutex.Unlock()
+		conn.Close()
+		return false
+	}
+	op.conn = paramsConn{Conn: conn}
+	d.ops.mutex.Unlock()
+	return true
+}
+
+// release closes the connection of the operation and stops tracking it. It is only called by the goroutine running
+// the operation, as driver connections are not safe for concurrent use. If Shutdown gave up waiting, the last
+// operation to be released closes the pool.
+func (d *DB) release(op *operation) {
+	d.ops.mutex.Lock()
+	conn := op.conn
+	delete(d.ops.active, op)
+	closePool := false
+	if len(d.ops.active) == 0 {
+		if d.ops.drained != nil {
+			close(d.ops.drained)
+			d.ops.drained = nil
+		}
+		closePool, d.ops.closePool = d.ops.closePool, false
+	}
+	d.ops.mutex.Unlock()
+
+	if conn != nil {
+		conn.Close()
+	}
+	if closePool {
+		d.pool.Close()
+	}
+}
+
+// Shutdown stops the DB accepting new operations, which fail with ErrDBClosed, and waits for in-flight queries, execs
+// and transactions to finish before closing the pool. If ctx expires first, the network connections of the remaining
+// operations are closed so they fail, and a *ShutdownError listing them is returned. The pool is then closed once
+// they have returned their connections. Calling Shutdown or Close again has no effect.
+func (d *DB) Shutdown(ctx context.Context) error {
+	d.ops.mutex.Lock()
+	if d.ops.closing {
+		d.ops.mutex.Unlock()
+		return nil
+	}
+	d.ops.closing = true
+	if len(d.ops.active) == 0 {
+		d.ops.mutex.Unlock()
+		return d.pool.Close()
+	}
+	drained := make(chan struct{})
+	d.ops.drained = drained
+	d.ops.mutex.Unlock()
+
+	select {
+	case <-drained:
+		return d.pool.Close()
+	case <-ctx.Done():
+		return d.interrupt(ctx.Err())
+	}
+}
+
+// interrupt interrupts the operations still in flight, leaving the pool to be closed by the last of them to finish.
+func (d *DB) interrupt(cause error) error {
+	d.ops.mutex.Lock()
+	if len(d.ops.active) == 0 {
+		d.ops.mutex.Unlock()
+		return d.pool.Close()
+	}
+	d.ops.drained = nil
+	d.ops.closePool = true
+
+	var conns []neo4j.Conn
+	var interrupted []string
+	for op := range d.ops.active {
+		interrupted = append(interrupted, op.desc)
+		op.interrupted = true
+		if op.conn != nil {
+			conns = append(conns, op.conn)
+		}
+	}
+	d.ops.mutex.Unlock()
+
+	for _, conn := range conns {
+		interruptConn(conn)
+	}
+	sort.Strings(interrupted)
+	return &ShutdownError{Interrupted: interrupted, Err: cause}
+}
+
+// wrappedConn is implemented by the connections this package wraps driver connections in.
+type wrappedConn interface {
+	unwrap() neo4j.Conn
+}
+
+// interrupter is implemented by driver connections that can be interrupted from another goroutine.
+type interrupter interface {
+	Interrupt() error
+}
+
+// interruptConn interrupts the driver connection under conn, so an operation using it from another goroutine fails
+// on its next read or write. The connection itself is left to that goroutine, as it is not safe for concurrent use
+// and closing a pooled one would return it to the pool mid-statement. Connections that can not be interrupted are
+// left alone.
+func interruptConn(conn neo4j.Conn) {
+	for {
+		w, ok := conn.(wrappedConn)
+		if !ok {
+			break
+		}
+		conn = w.unwrap()
+	}
+	if i, ok := conn.(interrupter); ok {
+		i.Interrupt()
+	}
+}
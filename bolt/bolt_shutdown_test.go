@@ -0,0 +1,119 @@
+package bolt
+
+import (
+	"context"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	"github.com/ONSdigital/dp-bolt/bolt/mock/mockrows"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// interruptibleConn is a connection that records whether it has been interrupted.
+type interruptibleConn struct {
+	*mock.NeoConnMock
+	interrupted chan struct{}
+}
+
+func (c *interruptibleConn) Interrupt() error {
+	close(c.interrupted)
+	return nil
+}
+
+// newBlockingPool returns a pool whose queries block until unblock is closed or the connection is interrupted,
+// signalling started as each one begins.
+func newBlockingPool(started chan<- struct{}, unblock <-chan struct{}) (*mock.DBPoolMock, *interruptibleConn) {
+	interrupted := make(chan struct{})
+	conn := &interruptibleConn{interrupted: interrupted, NeoConnMock: &mock.NeoConnMock{
+		CloseFunc: closeNoErr,
+		QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+			started <- struct{}{}
+			select {
+			case <-unblock:
+				return mockrows.New("n").Row(int64(1)), nil
+			case <-interrupted:
+				return nil, io.ErrUnexpectedEOF
+			}
+		},
+	}}
+	pool := &mock.DBPoolMock{
+		OpenPoolFunc: func() (neo4j.Conn, error) { return conn, nil },
+		CloseFunc:    closeNoErr,
+	}
+	return pool, conn
+}
+
+func TestDB_Shutdown(t *testing.T) {
+	Convey("given a DB with a query in flight", t, func() {
+		started, unblock := make(chan struct{}, 1), make(chan struct{})
+		pool, conn := newBlockingPool(started, unblock)
+		db := New(pool)
+
+		queryErr := make(chan error, 1)
+		go func() {
+			queryErr <- db.QueryForResult("MATCH (n)\n  RETURN n", nil, nil)
+		}()
+		<-started
+
+		Convey("when the query finishes before the context expires", func() {
+			shutdownErr := make(chan error, 1)
+			go func() {
+				shutdownErr <- db.Shutdown(context.Background())
+			}()
+			time.Sleep(10 * time.Millisecond)
+			newErr := db.QueryForResult("RETURN 1", nil, nil)
+			close(unblock)
+
+			Convey("then new operations are refused and the shutdown waits for the query", func() {
+				So(newErr, ShouldEqual, ErrDBClosed)
+				So(<-queryErr, ShouldBeNil)
+				So(<-shutdownErr, ShouldBeNil)
+				So(conn.CloseCalls(), ShouldHaveLength, 1)
+				So(pool.CloseCalls(), ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("when the context expires first", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := db.Shutdown(ctx)
+
+			Convey("then the query is interrupted and reported", func() {
+				shutdownErr, ok := err.(*ShutdownError)
+				So(ok, ShouldBeTrue)
+				So(shutdownErr.Interrupted, ShouldResemble, []string{"query MATCH (n) RETURN n"})
+				So(errors.Cause(err) == context.DeadlineExceeded, ShouldBeTrue)
+
+				select {
+				case err := <-queryErr:
+					So(err, ShouldNotBeNil)
+				case <-time.After(5 * time.Second):
+					So("the query was not interrupted", ShouldBeEmpty)
+				}
+				So(conn.CloseCalls(), ShouldHaveLength, 1)
+				So(pool.CloseCalls(), ShouldHaveLength, 1)
+			})
+		})
+	})
+
+	Convey("given an idle DB", t, func() {
+		pool := &mock.DBPoolMock{CloseFunc: closeNoErr}
+		db := New(pool)
+
+		Convey("when it is closed repeatedly", func() {
+			So(db.Close(), ShouldBeNil)
+			So(db.Close(), ShouldBeNil)
+			So(db.Shutdown(context.Background()), ShouldBeNil)
+
+			Convey("then the pool is only closed once and transactions are refused", func() {
+				So(pool.CloseCalls(), ShouldHaveLength, 1)
+				_, err := db.Begin()
+				So(err, ShouldEqual, ErrDBClosed)
+			})
+		})
+	})
+}
@@ -0,0 +1,116 @@
+package bolt
+
+import (
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+// Summary holds the write statistics Neo4j reports once the rows of a statement have been consumed.
+type Summary struct {
+	NodesCreated         int64
+	NodesDeleted         int64
+	RelationshipsCreated int64
+	RelationshipsDeleted int64
+	PropertiesSet        int64
+	LabelsAdded          int64
+	LabelsRemoved        int64
+	IndexesAdded         int64
+	IndexesRemoved       int64
+	ConstraintsAdded     int64
+	ConstraintsRemoved   int64
+	// Metadata is the metadata the counters were read from.
+	Metadata map[string]interface{}
+}
+
+// newSummary reads the counters from the "stats" map of the metadata sent at the end of a statement.
+func newSummary(meta map[string]interface{}) Summary {
+	stats, _ := meta["stats"].(map[string]interface{})
+	counter := func(key string) int64 {
+		n, _ := stats[key].(int64)
+		return n
+	}
+	return Summary{
+		NodesCreated:         counter("nodes-created"),
+		NodesDeleted:         counter("nodes-deleted"),
+		RelationshipsCreated: counter("relationships-created"),
+		RelationshipsDeleted: counter("relationships-deleted"),
+		PropertiesSet:        counter("properties-set"),
+		LabelsAdded:          counter("labels-added"),
+		LabelsRemoved:        counter("labels-removed"),
+		IndexesAdded:         counter("indexes-added"),
+		IndexesRemoved:       counter("indexes-removed"),
+		ConstraintsAdded:     counter("constraints-added"),
+		ConstraintsRemoved:   counter("constraints-removed"),
+		Metadata:             meta,
+	}
+}
+
+// RowsAffected returns the number of nodes and relationships created or deleted, as Exec does.
+func (s Summary) RowsAffected() int64 {
+	return s.NodesCreated + s.NodesDeleted + s.RelationshipsCreated + s.RelationshipsDeleted
+}
+
+// ContainsUpdates reports whether the statement changed the graph or its schema.
+func (s Summary) ContainsUpdates() bool {
+	return s.RowsAffected() > 0 || s.PropertiesSet > 0 || s.LabelsAdded > 0 || s.LabelsRemoved > 0 ||
+		s.IndexesAdded > 0 || s.IndexesRemoved > 0 || s.ConstraintsAdded > 0 || s.ConstraintsRemoved > 0
+}
+
+// ExecReturning executes a statement that both writes and returns rows, such as CREATE ... RETURN. Each row is passed
+// to mapResult, which may be nil, then the write statistics are returned. Unlike QueryForResults, a statement
+// returning no rows is not an error.
+func (d *DB) ExecReturning(s Stmt, mapResult ResultMapper) (Summary, error) {
+	if s.Query == "" {
+		return Summary{}, nil
+	}
+
+	op, err := d.open(describe("exec", s.Query))
+	if err != nil {
+		return Summary{}, err
+	}
+	defer d.release(op)
+
+	openRows := openStmtRows(s)
+	if s.Idempotent {
+		openRows = d.retryRows(op, openRows)
+	}
+	summary, err := execRowsConn(op.conn, openRows, mapResult)
+	if d.resultCache != nil {
+		d.resultCache.InvalidateTags(s.Tags...)
+	}
+	return summary, err
+}
+
+// ExecReturning executes a statement that both writes and returns rows within the transaction. Result cache tags
+// declared by the statement are invalidated once the transaction is committed.
+func (t *Tx) ExecReturning(s Stmt, mapResult ResultMapper) (Summary, error) {
+	if t.closed {
+		return Summary{}, ErrTxClosed
+	}
+	if s.Query == "" {
+		return Summary{}, nil
+	}
+
+	t.tags = append(t.tags, s.Tags...)
+	return execRowsConn(t.conn, openStmtRows(s), mapResult)
+}
+
+func openStmtRows(s Stmt) func(conn neo4j.Conn) (neo4j.Rows, error) {
+	return func(conn neo4j.Conn) (neo4j.Rows, error) {
+		return conn.QueryNeo(s.Query, s.Params)
+	}
+}
+
+func execRowsConn(conn neo4j.Conn, openRows func(conn neo4j.Conn) (neo4j.Rows, error), mapResult ResultMapper) (Summary, error) {
+	rows, err := openRows(conn)
+	if err != nil {
+		return Summary{}, errors.WithMessage(err, "error executing statement")
+	}
+	defer rows.Close()
+
+	_, meta, err := streamRows(rows, mapResult, false)
+	if err != nil {
+		return Summary{}, err
+	}
+	return newSummary(meta), nil
+}
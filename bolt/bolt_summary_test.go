@@ -0,0 +1,108 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	"github.com/ONSdigital/dp-bolt/bolt/mock/mockrows"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var createSummary = map[string]interface{}{
+	"type": "rw",
+	"stats": map[string]interface{}{
+		"nodes-created":  int64(1),
+		"properties-set": int64(2),
+		"labels-added":   int64(1),
+	},
+}
+
+func TestDB_ExecReturning(t *testing.T) {
+	Convey("given a statement creating a node and returning its id", t, func() {
+		rows := mockrows.New("id").Row("123").WithSummary(createSummary)
+		conn := &mock.NeoConnMock{
+			CloseFunc: closeNoErr,
+			QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+				return rows, nil
+			},
+		}
+		db := New(&mock.DBPoolMock{OpenPoolFunc: func() (neo4j.Conn, error) { return conn, nil }})
+
+		Convey("when it is executed", func() {
+			var ids []interface{}
+			summary, err := db.ExecReturning(Stmt{Query: "CREATE (n:Dataset {id: $id, title: $title}) RETURN n.id"}, func(r *Result) error {
+				ids = append(ids, r.Data[0])
+				return nil
+			})
+
+			Convey("then the rows are mapped and the counters returned", func() {
+				So(err, ShouldBeNil)
+				So(ids, ShouldResemble, []interface{}{"123"})
+				So(summary.NodesCreated, ShouldEqual, 1)
+				So(summary.PropertiesSet, ShouldEqual, 2)
+				So(summary.LabelsAdded, ShouldEqual, 1)
+				So(summary.RowsAffected(), ShouldEqual, 1)
+				So(summary.ContainsUpdates(), ShouldBeTrue)
+				So(summary.Metadata, ShouldResemble, createSummary)
+				So(rows.Closed(), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("given a statement returning no rows", t, func() {
+		conn := &mock.NeoConnMock{
+			CloseFunc: closeNoErr,
+			QueryNeoFunc: func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+				return mockrows.New("id").WithSummary(map[string]interface{}{"type": "w"}), nil
+			},
+		}
+		db := New(&mock.DBPoolMock{OpenPoolFunc: func() (neo4j.Conn, error) { return conn, nil }})
+
+		Convey("when it is executed", func() {
+			summary, err := db.ExecReturning(Stmt{Query: "MATCH (n:Missing) SET n.seen = true RETURN n"}, nil)
+
+			Convey("then it is not an error", func() {
+				So(err, ShouldBeNil)
+				So(summary.ContainsUpdates(), ShouldBeFalse)
+			})
+		})
+	})
+}
+
+func TestTx_ExecReturning(t *testing.T) {
+	Convey("given a transaction", t, func() {
+		tx := &txStub{}
+		conn, pool := newTxMocks(tx)
+		conn.QueryNeoFunc = func(query string, params map[string]interface{}) (neo4j.Rows, error) {
+			return mockrows.New("id").Row("123").WithSummary(createSummary), nil
+		}
+		db := New(pool)
+		transaction, err := db.Begin()
+		So(err, ShouldBeNil)
+
+		Convey("when a statement is executed within it", func() {
+			var id interface{}
+			summary, err := transaction.ExecReturning(Stmt{Query: "CREATE (n:Dataset) RETURN n.id"}, func(r *Result) error {
+				id = r.Data[0]
+				return nil
+			})
+
+			Convey("then the rows and counters are returned without releasing the connection", func() {
+				So(err, ShouldBeNil)
+				So(id, ShouldEqual, "123")
+				So(summary.NodesCreated, ShouldEqual, 1)
+				So(conn.CloseCalls(), ShouldHaveLength, 0)
+			})
+		})
+
+		Convey("when it has been committed", func() {
+			So(transaction.Commit(), ShouldBeNil)
+			_, err := transaction.ExecReturning(Stmt{Query: "CREATE (n) RETURN n"}, nil)
+
+			Convey("then statements are refused", func() {
+				So(err, ShouldEqual, ErrTxClosed)
+			})
+		})
+	})
+}
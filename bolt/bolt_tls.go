@@ -0,0 +1,325 @@
+package bolt
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+var ErrTLSNotEnabled = errors.New("TLS is not enabled for this bolt.DB")
+
+// TLSConfig configures encrypted connections to the database. Certificates and keys can be given either as PEM bytes
+// or as files. Files are read again by each new connection, so rotated certificates are used without a restart.
+type TLSConfig struct {
+	// Enabled turns on TLS.
+	Enabled bool
+	// CACert is a PEM bundle of the CAs to verify the server with. It can not be set along with CACertFile. The system
+	// roots are used if neither is set.
+	CACert []byte
+	// CACertFile is a file holding a PEM bundle of the CAs to verify the server with.
+	CACertFile string
+	// Cert and Key are the PEM client certificate and key, if the server requires one. They can not be set along
+	// with CertFile and KeyFile.
+	Cert []byte
+	Key  []byte
+	// CertFile and KeyFile are files holding the PEM client certificate and key.
+	CertFile string
+	KeyFile  string
+	// ServerName is the name the server certificate is verified against, the host of the URL by default.
+	ServerName string
+	// MinVersion is the minimum TLS version, such as tls.VersionTLS12, which is the default.
+	MinVersion uint16
+	// InsecureSkipVerify disables verification of the server certificate. It should only be used in development.
+	InsecureSkipVerify bool
+}
+
+// String describes the config without the contents of its certificates and keys.
+func (c TLSConfig) String() string {
+	return fmt.Sprintf("{Enabled:%t CACert:%d bytes CACertFile:%s Cert:%d bytes Key:%s CertFile:%s KeyFile:%s ServerName:%s MinVersion:%s InsecureSkipVerify:%t}",
+		c.Enabled, len(c.CACert), c.CACertFile, len(c.Cert), redact(c.Key), c.CertFile, c.KeyFile, c.ServerName,
+		tlsVersionName(c.minVersion()), c.InsecureSkipVerify)
+}
+
+func redact(b []byte) string {
+	if len(b) == 0 {
+		return ""
+	}
+	return "[REDACTED]"
+}
+
+var tlsVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+func parseTLSVersion(s string) (uint16, error) {
+	v, ok := tlsVersions[s]
+	if !ok {
+		return 0, errors.Errorf("unknown TLS version %q", s)
+	}
+	return v, nil
+}
+
+func isTLSVersion(v uint16) bool {
+	for _, version := range tlsVersions {
+		if version == v {
+			return true
+		}
+	}
+	return false
+}
+
+func tlsVersionName(v uint16) string {
+	for name, version := range tlsVersions {
+		if version == v {
+			return name
+		}
+	}
+	return fmt.Sprintf("0x%04x", v)
+}
+
+func (c TLSConfig) minVersion() uint16 {
+	if c.MinVersion == 0 {
+		return tls.VersionTLS12
+	}
+	return c.MinVersion
+}
+
+// validate reports problems that can be found without reading any files.
+func (c TLSConfig) validate() error {
+	if !c.Enabled {
+		return nil
+	}
+	if len(c.CACert) > 0 && c.CACertFile != "" {
+		return errors.New("config: only one of TLS.CACert and TLS.CACertFile can be set")
+	}
+	if (len(c.Cert) > 0 || len(c.Key) > 0) && (c.CertFile != "" || c.KeyFile != "") {
+		return errors.New("config: TLS.Cert and TLS.Key can not be set along with TLS.CertFile and TLS.KeyFile")
+	}
+	if (len(c.Cert) > 0) != (len(c.Key) > 0) {
+		return errors.New("config: TLS.Cert and TLS.Key must be set together")
+	}
+	if (c.CertFile != "") != (c.KeyFile != "") {
+		return errors.New("config: TLS.CertFile and TLS.KeyFile must be set together")
+	}
+	if c.MinVersion != 0 && !isTLSVersion(c.MinVersion) {
+		return errors.Errorf("config: TLS.MinVersion 0x%04x is not a TLS version", c.MinVersion)
+	}
+	return nil
+}
+
+// load builds the tls.Config, reading and checking every certificate and key.
+func (c TLSConfig) load() (*tls.Config, error) {
+	conf := &tls.Config{
+		ServerName:         c.ServerName,
+		MinVersion:         c.minVersion(),
+		InsecureSkipVerify: c.InsecureSkipVerify,
+	}
+
+	ca := c.CACert
+	if c.CACertFile != "" {
+		var err error
+		if ca, err = ioutil.ReadFile(c.CACertFile); err != nil {
+			return nil, errors.WithMessage(err, "config: error reading TLS.CACertFile")
+		}
+	}
+	if len(ca) > 0 {
+		conf.RootCAs = x509.NewCertPool()
+		if !conf.RootCAs.AppendCertsFromPEM(ca) {
+			return nil, errors.New("config: TLS CA bundle holds no PEM certificates")
+		}
+	}
+
+	cert, key := c.Cert, c.Key
+	if c.CertFile != "" {
+		var err error
+		if cert, err = ioutil.ReadFile(c.CertFile); err != nil {
+			return nil, errors.WithMessage(err, "config: error reading TLS.CertFile")
+		}
+		if key, err = ioutil.ReadFile(c.KeyFile); err != nil {
+			return nil, errors.WithMessage(err, "config: error reading TLS.KeyFile")
+		}
+	}
+	if len(cert) > 0 {
+		pair, err := tls.X509KeyPair(cert, key)
+		if err != nil {
+			return nil, errors.WithMessage(err, "config: TLS client certificate is invalid")
+		}
+		conf.Certificates = []tls.Certificate{pair}
+	}
+	return conf, nil
+}
+
+// ReloadTLS replaces the certificates and keys given as bytes that new connections use, such as to rotate them
+// without a restart. They are checked before they are used. Only the contents of CACert, Cert and Key can change;
+// the other settings are fixed when the DB is built. Established connections are unaffected.
+func (d *DB) ReloadTLS(cfg TLSConfig) error {
+	if d.tlsFiles == nil {
+		return ErrTLSNotEnabled
+	}
+	cfg.Enabled = true
+	return d.tlsFiles.reload(cfg)
+}
+
+const (
+	tlsCAFile     = "ca.pem"
+	tlsClientFile = "client.pem"
+)
+
+// tlsFiles passes TLS settings to the driver, which only reads them from query parameters of the URL. Certificates
+// and keys given as bytes are written to files in a temporary directory only the current user can read. The driver
+// reads the files again for each new connection, so rewriting them rotates the certificates. The client certificate
+// and key are written to the same file so they are always replaced together.
+type tlsFiles struct {
+	mutex sync.Mutex
+	cfg   TLSConfig
+	dir   string
+}
+
+// newTLSFiles checks cfg then writes any certificates and keys given as bytes.
+func newTLSFiles(cfg TLSConfig) (*tlsFiles, error) {
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	if _, err := cfg.load(); err != nil {
+		return nil, err
+	}
+
+	f := &tlsFiles{cfg: cfg}
+	if len(cfg.CACert) > 0 || len(cfg.Cert) > 0 {
+		dir, err := ioutil.TempDir("", "bolt-tls")
+		if err != nil {
+			return nil, errors.WithMessage(err, "error creating TLS directory")
+		}
+		f.dir = dir
+		if err := f.write(cfg); err != nil {
+			f.Close()
+			return nil, err
+		}
+	}
+	return f, nil
+}
+
+// params returns the query parameters the driver reads the settings from.
+func (f *tlsFiles) params() url.Values {
+	f.mutex.Lock()
+	cfg := f.cfg
+	f.mutex.Unlock()
+
+	caFile, certFile, keyFile := cfg.CACertFile, cfg.CertFile, cfg.KeyFile
+	if len(cfg.CACert) > 0 {
+		caFile = filepath.Join(f.dir, tlsCAFile)
+	}
+	if len(cfg.Cert) > 0 {
+		certFile = filepath.Join(f.dir, tlsClientFile)
+		keyFile = certFile
+	}
+
+	q := url.Values{}
+	q.Set("tls", "true")
+	setIfNotEmpty(q, "tls_ca_cert_file", caFile)
+	setIfNotEmpty(q, "tls_cert_file", certFile)
+	setIfNotEmpty(q, "tls_key_file", keyFile)
+	setIfNotEmpty(q, "tls_server_name", cfg.ServerName)
+	q.Set("tls_min_version", tlsVersionName(cfg.minVersion()))
+	if cfg.InsecureSkipVerify {
+		q.Set("tls_no_verify", "true")
+	}
+	return q
+}
+
+func (f *tlsFiles) reload(cfg TLSConfig) error {
+	f.mutex.Lock()
+	defer f.mutex.Unlock()
+
+	if !reflect.DeepEqual(cfg.settings(), f.cfg.settings()) {
+		return errors.New("only the contents of TLS.CACert, TLS.Cert and TLS.Key can be reloaded")
+	}
+	if _, err := cfg.load(); err != nil {
+		return err
+	}
+	if err := f.write(cfg); err != nil {
+		return err
+	}
+	f.cfg = cfg
+	return nil
+}
+
+// settings returns the config with the certificates and keys given as bytes replaced by whether they are set.
+func (c TLSConfig) settings() TLSConfig {
+	for _, b := range []*[]byte{&c.CACert, &c.Cert, &c.Key} {
+		if len(*b) > 0 {
+			*b = []byte{}
+		} else {
+			*b = nil
+		}
+	}
+	return c
+}
+
+func (f *tlsFiles) write(cfg TLSConfig) error {
+	if len(cfg.CACert) > 0 {
+		if err := writeFileAtomic(filepath.Join(f.dir, tlsCAFile), cfg.CACert); err != nil {
+			return err
+		}
+	}
+	if len(cfg.Cert) > 0 {
+		client := append(append(append([]byte{}, cfg.Cert...), '\n'), cfg.Key...)
+		if err := writeFileAtomic(filepath.Join(f.dir, tlsClientFile), client); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeFileAtomic replaces the file at path with b by renaming a new file over it, so it is never read part written.
+func writeFileAtomic(path string, b []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path))
+	if err != nil {
+		return errors.WithMessage(err, "error writing TLS file")
+	}
+	_, err = tmp.Write(b)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil {
+		err = os.Rename(tmp.Name(), path)
+	}
+	if err != nil {
+		os.Remove(tmp.Name())
+		return errors.WithMessage(err, "error writing TLS file")
+	}
+	return nil
+}
+
+// Close removes the files written for the certificates and keys given as bytes.
+func (f *tlsFiles) Close() error {
+	if f == nil || f.dir == "" {
+		return nil
+	}
+	return os.RemoveAll(f.dir)
+}
+
+// tlsPool removes the TLS files along with the pool.
+type tlsPool struct {
+	DBPool
+	files *tlsFiles
+}
+
+func (p *tlsPool) Close() error {
+	err := p.DBPool.Close()
+	if filesErr := p.files.Close(); err == nil {
+		err = filesErr
+	}
+	return err
+}
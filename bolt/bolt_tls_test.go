@@ -0,0 +1,239 @@
+package bolt
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/binary"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// newCert returns a self-signed PEM certificate and key for the given DNS name.
+func newCert(name string) ([]byte, []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		panic(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name},
+		DNSNames:              []string{name},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		panic(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		panic(err)
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// newTLSServer starts a server that completes a TLS handshake then accepts the bolt v1 handshake and INIT message of
+// the driver, returning its address and the number of TLS handshakes completed.
+func newTLSServer(cert, key []byte) (string, *int32, func()) {
+	pair, err := tls.X509KeyPair(cert, key)
+	if err != nil {
+		panic(err)
+	}
+	listener, err := tls.Listen("tcp", "127.0.0.1:0", &tls.Config{Certificates: []tls.Certificate{pair}})
+	if err != nil {
+		panic(err)
+	}
+	var handshakes int32
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				if conn.(*tls.Conn).Handshake() != nil {
+					return
+				}
+				atomic.AddInt32(&handshakes, 1)
+				if _, err := io.ReadFull(conn, make([]byte, 20)); err != nil {
+					return
+				}
+				conn.Write([]byte{0x00, 0x00, 0x00, 0x01})
+				header := make([]byte, 2)
+				for {
+					if _, err := io.ReadFull(conn, header); err != nil {
+						return
+					}
+					size := int64(binary.BigEndian.Uint16(header))
+					if size == 0 {
+						break
+					}
+					io.CopyN(ioutil.Discard, conn, size)
+				}
+				conn.Write([]byte{0x00, 0x03, 0xB1, 0x70, 0xA0, 0x00, 0x00})
+				io.Copy(ioutil.Discard, conn)
+			}()
+		}
+	}()
+	return listener.Addr().String(), &handshakes, func() { listener.Close() }
+}
+
+func TestTLS(t *testing.T) {
+	Convey("given a TLS server with a self-signed certificate", t, func() {
+		cert, key := newCert("neo4j.test")
+		addr, handshakes, stop := newTLSServer(cert, key)
+		defer stop()
+		cfg := Config{URL: "bolt://" + addr + "?timeout=1", PoolSize: 2}
+
+		Convey("when the DB trusts it under its server name", func() {
+			cfg.TLS = TLSConfig{Enabled: true, CACert: cert, ServerName: "neo4j.test"}
+			db, err := NewFromConfig(cfg)
+			So(err, ShouldBeNil)
+			defer db.Close()
+			_, err = db.pool.OpenPool()
+
+			Convey("then the driver connects", func() {
+				So(err, ShouldBeNil)
+				So(atomic.LoadInt32(handshakes), ShouldEqual, 1)
+			})
+
+			Convey("then reloading an untrusted CA fails new connections", func() {
+				otherCert, _ := newCert("neo4j.test")
+				So(db.ReloadTLS(TLSConfig{CACert: otherCert, ServerName: "neo4j.test"}), ShouldBeNil)
+				_, err := db.pool.OpenPool()
+				So(err, ShouldNotBeNil)
+				So(atomic.LoadInt32(handshakes), ShouldEqual, 1)
+			})
+
+			Convey("then settings other than the certificates can not be reloaded", func() {
+				err := db.ReloadTLS(TLSConfig{CACert: cert, ServerName: "other.test"})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "only the contents of TLS.CACert, TLS.Cert and TLS.Key can be reloaded")
+			})
+
+			Convey("then closing the DB removes the certificate files", func() {
+				dir := db.tlsFiles.dir
+				So(dir, ShouldNotBeEmpty)
+				So(db.Close(), ShouldBeNil)
+				_, err := os.Stat(dir)
+				So(os.IsNotExist(err), ShouldBeTrue)
+			})
+		})
+
+		Convey("when the DB expects another server name", func() {
+			cfg.TLS = TLSConfig{Enabled: true, CACert: cert, ServerName: "other.test"}
+			db, err := NewFromConfig(cfg)
+			So(err, ShouldBeNil)
+			defer db.Close()
+			_, err = db.pool.OpenPool()
+
+			Convey("then connections fail", func() {
+				So(err, ShouldNotBeNil)
+				So(atomic.LoadInt32(handshakes), ShouldEqual, 0)
+			})
+		})
+
+		Convey("when the DB reads its CA from a file that changes", func() {
+			dir, err := ioutil.TempDir("", "bolt-tls")
+			So(err, ShouldBeNil)
+			defer os.RemoveAll(dir)
+			caFile := filepath.Join(dir, "ca.pem")
+			So(ioutil.WriteFile(caFile, cert, 0600), ShouldBeNil)
+
+			cfg.TLS = TLSConfig{Enabled: true, CACertFile: caFile, ServerName: "neo4j.test"}
+			db, err := NewFromConfig(cfg)
+			So(err, ShouldBeNil)
+			defer db.Close()
+			_, err = db.pool.OpenPool()
+			So(err, ShouldBeNil)
+
+			otherCert, _ := newCert("neo4j.test")
+			So(ioutil.WriteFile(caFile, otherCert, 0600), ShouldBeNil)
+			_, err = db.pool.OpenPool()
+
+			Convey("then the next connection reads it again", func() {
+				So(err, ShouldNotBeNil)
+				So(atomic.LoadInt32(handshakes), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestTLSConfig(t *testing.T) {
+	Convey("given invalid TLS settings", t, func() {
+		cert, key := newCert("neo4j.test")
+		cases := map[string]TLSConfig{
+			"config: only one of TLS.CACert and TLS.CACertFile can be set":                          {CACert: cert, CACertFile: "ca.pem"},
+			"config: TLS.Cert and TLS.Key must be set together":                                     {Cert: cert},
+			"config: TLS.Cert and TLS.Key can not be set along with TLS.CertFile and TLS.KeyFile":   {Cert: cert, Key: key, CertFile: "cert.pem", KeyFile: "key.pem"},
+			"config: TLS.MinVersion 0x0001 is not a TLS version":                                    {MinVersion: 1},
+			"config: TLS CA bundle holds no PEM certificates":                                       {CACert: []byte("not a certificate")},
+			"config: TLS client certificate is invalid: tls: private key does not match public key": {Cert: cert, Key: func() []byte { _, k := newCert("x"); return k }()},
+		}
+
+		Convey("then building a DB reports each problem up front", func() {
+			for msg, tlsCfg := range cases {
+				tlsCfg.Enabled = true
+				_, err := NewFromConfig(Config{URL: "bolt://localhost:7687", TLS: tlsCfg})
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, msg)
+			}
+		})
+	})
+
+	Convey("given TLS settings with a client key", t, func() {
+		cert, key := newCert("neo4j.test")
+		cfg := Config{URL: "bolt://localhost:7687", TLS: TLSConfig{Enabled: true, Cert: cert, Key: key, MinVersion: tls.VersionTLS13}}
+
+		Convey("then the key is never printed", func() {
+			So(cfg.String(), ShouldNotContainSubstring, string(key))
+			So(cfg.String(), ShouldContainSubstring, "Key:[REDACTED]")
+			So(cfg.String(), ShouldContainSubstring, "MinVersion:1.3")
+		})
+	})
+
+	Convey("given a client certificate and key given as bytes", t, func() {
+		cert, key := newCert("client.test")
+		files, err := newTLSFiles(TLSConfig{Enabled: true, Cert: cert, Key: key})
+		So(err, ShouldBeNil)
+		defer files.Close()
+
+		Convey("then the driver reads them from one file only the current user can read", func() {
+			params := files.params()
+			So(params.Get("tls_cert_file"), ShouldEqual, params.Get("tls_key_file"))
+			info, err := os.Stat(params.Get("tls_cert_file"))
+			So(err, ShouldBeNil)
+			So(info.Mode().Perm(), ShouldEqual, 0600)
+			_, err = tls.LoadX509KeyPair(params.Get("tls_cert_file"), params.Get("tls_key_file"))
+			So(err, ShouldBeNil)
+		})
+	})
+
+	Convey("given a DB without TLS", t, func() {
+		db, err := NewFromConfig(Config{URL: "bolt://localhost:7687"})
+		So(err, ShouldBeNil)
+
+		Convey("then its TLS settings can not be reloaded", func() {
+			So(db.ReloadTLS(TLSConfig{}), ShouldEqual, ErrTLSNotEnabled)
+		})
+	})
+}
@@ -0,0 +1,313 @@
+package bolt
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+
+	"github.com/ONSdigital/dp-bolt/bolt/graphval"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/pkg/errors"
+)
+
+// TreeNode is a node of a tree assembled by a TreeBuilder. ID is the node identity when the node came from a path,
+// or the id column of the row it came from.
+type TreeNode struct {
+	ID       interface{}
+	Labels   []string
+	Props    map[string]interface{}
+	Parent   *TreeNode
+	Children []*TreeNode
+}
+
+// Ancestors returns the parent of the node, its parent and so on up to the root.
+func (n *TreeNode) Ancestors() []*TreeNode {
+	var ancestors []*TreeNode
+	for p := n.Parent; p != nil; p = p.Parent {
+		ancestors = append(ancestors, p)
+	}
+	return ancestors
+}
+
+// Breadcrumb returns the path from the root down to and including the node.
+func (n *TreeNode) Breadcrumb() []*TreeNode {
+	ancestors := n.Ancestors()
+	crumbs := make([]*TreeNode, 0, len(ancestors)+1)
+	for i := len(ancestors) - 1; i >= 0; i-- {
+		crumbs = append(crumbs, ancestors[i])
+	}
+	return append(crumbs, n)
+}
+
+// Descendants returns the children of the node and all of theirs, depth first in child order.
+func (n *TreeNode) Descendants() []*TreeNode {
+	var descendants []*TreeNode
+	var walk func(n *TreeNode)
+	walk = func(n *TreeNode) {
+		for _, c := range n.Children {
+			descendants = append(descendants, c)
+			walk(c)
+		}
+	}
+	walk(n)
+	return descendants
+}
+
+// Tree is a forest of nodes assembled by a TreeBuilder.
+type Tree struct {
+	Roots []*TreeNode
+	nodes map[interface{}]*TreeNode
+}
+
+// Node returns the node with the id, or nil if it is not in the tree.
+func (t *Tree) Node(id interface{}) *TreeNode {
+	if !isTreeID(id) {
+		return nil
+	}
+	return t.nodes[id]
+}
+
+// TreeError reports the nodes a TreeBuilder could not place in the tree. Orphans are the nodes whose parent was never
+// added and Cycles the nodes that are their own ancestor, each in the order they were added. Descendants of either
+// are left out of the tree but not listed.
+type TreeError struct {
+	Orphans []interface{}
+	Cycles  []interface{}
+}
+
+func (e *TreeError) Error() string {
+	return fmt.Sprintf("tree has %d orphan(s) %v and %d node(s) in cycles %v", len(e.Orphans), e.Orphans, len(e.Cycles), e.Cycles)
+}
+
+// TreeBuilder assembles a tree from the rows of a hierarchy query as they are streamed, so rows are not buffered
+// before the tree is built. Rows may carry paths from a root down to a node, or a node with the id of its parent.
+// Children are kept in the order they were first added unless OrderChildren is used.
+type TreeBuilder struct {
+	nodes []*treeEntry
+	byID  map[interface{}]*treeEntry
+	less  func(a, b *TreeNode) bool
+}
+
+// treeEntry is a node added to a TreeBuilder along with the id of its parent, if known.
+type treeEntry struct {
+	node      *TreeNode
+	parentID  interface{}
+	hasParent bool
+}
+
+// NewTreeBuilder returns an empty TreeBuilder.
+func NewTreeBuilder() *TreeBuilder {
+	return &TreeBuilder{byID: make(map[interface{}]*treeEntry)}
+}
+
+// OrderChildren sorts the children of every node with less when the tree is built.
+func (b *TreeBuilder) OrderChildren(less func(a, b *TreeNode) bool) *TreeBuilder {
+	b.less = less
+	return b
+}
+
+// AddPath adds the nodes of a path such as one returned by MATCH p = (root)-[:HAS_CHILD*]->(n). The first node of
+// the path is taken as the root and each node after it as the child of the one before, whichever way the
+// relationships point.
+func (b *TreeBuilder) AddPath(p graph.Path) error {
+	path, err := graphval.PathOf(p)
+	if err != nil {
+		return err
+	}
+	for i, n := range path.Nodes {
+		if err := b.add(n.ID, n.Labels, n.Props); err != nil {
+			return err
+		}
+		if i > 0 {
+			if err := b.setParent(n.ID, path.Nodes[i-1].ID); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// AddNode adds the node with the id and properties as a child of the node with parentID, or as a root if parentID is
+// nil. The parent does not need to have been added yet.
+func (b *TreeBuilder) AddNode(id, parentID interface{}, props map[string]interface{}) error {
+	if err := b.add(id, nil, props); err != nil {
+		return err
+	}
+	if parentID == nil {
+		return nil
+	}
+	return b.setParent(id, parentID)
+}
+
+// PathMapper returns a ResultMapper adding the path in the column of each row.
+func (b *TreeBuilder) PathMapper(column int) ResultMapper {
+	return func(r *Result) error {
+		if column >= len(r.Data) {
+			return errors.Errorf("tree path column %d is out of range", column)
+		}
+		p, ok := r.Data[column].(graph.Path)
+		if !ok {
+			return errors.Errorf("tree path column %d is %T, not a path", column, r.Data[column])
+		}
+		return b.AddPath(p)
+	}
+}
+
+// RowMapper returns a ResultMapper adding a node from the id, parent id and properties columns of each row, such as
+// those returned by RETURN c.id, p.id, c. The properties column may hold a node or a map, or be -1 if there is none.
+func (b *TreeBuilder) RowMapper(idColumn, parentColumn, propsColumn int) ResultMapper {
+	return func(r *Result) error {
+		for _, column := range []int{idColumn, parentColumn, propsColumn} {
+			if column >= len(r.Data) {
+				return errors.Errorf("tree column %d is out of range", column)
+			}
+		}
+
+		var labels []string
+		var props map[string]interface{}
+		if propsColumn >= 0 {
+			switch v := r.Data[propsColumn].(type) {
+			case nil:
+			case graph.Node:
+				labels, props = v.Labels, v.Properties
+			case map[string]interface{}:
+				props = v
+			default:
+				return errors.Errorf("tree properties column %d is %T, not a node or map", propsColumn, v)
+			}
+		}
+
+		id := r.Data[idColumn]
+		if err := b.add(id, labels, props); err != nil {
+			return err
+		}
+		if parentID := r.Data[parentColumn]; parentID != nil {
+			return b.setParent(id, parentID)
+		}
+		return nil
+	}
+}
+
+// add adds a node, merging its labels and properties into those of a node with the same id added before.
+func (b *TreeBuilder) add(id interface{}, labels []string, props map[string]interface{}) error {
+	if !isTreeID(id) {
+		return errors.Errorf("tree node id %v of type %T can not be used as an id", id, id)
+	}
+
+	e, ok := b.byID[id]
+	if !ok {
+		e = &treeEntry{node: &TreeNode{ID: id, Props: make(map[string]interface{})}}
+		b.byID[id] = e
+		b.nodes = append(b.nodes, e)
+	}
+	if len(e.node.Labels) == 0 {
+		e.node.Labels = labels
+	}
+	for k, v := range props {
+		e.node.Props[k] = v
+	}
+	return nil
+}
+
+func (b *TreeBuilder) setParent(id, parentID interface{}) error {
+	if !isTreeID(parentID) {
+		return errors.Errorf("tree parent id %v of type %T can not be used as an id", parentID, parentID)
+	}
+	e := b.byID[id]
+	if e.hasParent && e.parentID != parentID {
+		return errors.Errorf("tree node %v has more than one parent: %v and %v", id, e.parentID, parentID)
+	}
+	e.parentID, e.hasParent = parentID, true
+	return nil
+}
+
+// Build links the nodes added into a tree. If any nodes could not be placed, the tree of those that could is
+// returned along with a *TreeError.
+func (b *TreeBuilder) Build() (*Tree, error) {
+	t := &Tree{nodes: make(map[interface{}]*TreeNode, len(b.nodes))}
+	for _, e := range b.nodes {
+		e.node.Parent, e.node.Children = nil, nil
+	}
+
+	var treeErr TreeError
+	for _, e := range b.nodes {
+		if !e.hasParent {
+			t.Roots = append(t.Roots, e.node)
+			continue
+		}
+		parent, ok := b.byID[e.parentID]
+		if !ok {
+			treeErr.Orphans = append(treeErr.Orphans, e.node.ID)
+			continue
+		}
+		e.node.Parent = parent.node
+		parent.node.Children = append(parent.node.Children, e.node)
+	}
+
+	var place func(n *TreeNode)
+	place = func(n *TreeNode) {
+		t.nodes[n.ID] = n
+		if b.less != nil {
+			sort.SliceStable(n.Children, func(i, j int) bool { return b.less(n.Children[i], n.Children[j]) })
+		}
+		for _, c := range n.Children {
+			place(c)
+		}
+	}
+	for _, root := range t.Roots {
+		place(root)
+	}
+
+	treeErr.Cycles = b.cycles(t)
+	if len(treeErr.Orphans) > 0 || len(treeErr.Cycles) > 0 {
+		return t, &treeErr
+	}
+	return t, nil
+}
+
+// cycles returns the ids of the nodes that are their own ancestor in the order they were added. Only nodes that could
+// not be reached from a root are checked, as every node reached is known not to be in a cycle.
+func (b *TreeBuilder) cycles(t *Tree) []interface{} {
+	inCycle := make(map[interface{}]bool)
+	checked := make(map[interface{}]bool)
+	for _, e := range b.nodes {
+		if _, placed := t.nodes[e.node.ID]; placed || checked[e.node.ID] {
+			continue
+		}
+
+		// follow the parents until reaching a node already checked, one without a known parent, or one seen on
+		// this walk, which is the start of a cycle
+		seen := make(map[interface{}]int)
+		var walk []*treeEntry
+		for cur := e; cur != nil && !checked[cur.node.ID]; {
+			if i, ok := seen[cur.node.ID]; ok {
+				for _, c := range walk[i:] {
+					inCycle[c.node.ID] = true
+				}
+				break
+			}
+			seen[cur.node.ID] = len(walk)
+			walk = append(walk, cur)
+			if !cur.hasParent {
+				break
+			}
+			cur = b.byID[cur.parentID]
+		}
+		for _, c := range walk {
+			checked[c.node.ID] = true
+		}
+	}
+
+	var ids []interface{}
+	for _, e := range b.nodes {
+		if inCycle[e.node.ID] {
+			ids = append(ids, e.node.ID)
+		}
+	}
+	return ids
+}
+
+// isTreeID reports whether id can be used as a map key.
+func isTreeID(id interface{}) bool {
+	return id != nil && reflect.TypeOf(id).Comparable()
+}
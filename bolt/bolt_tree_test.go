@@ -0,0 +1,160 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/boltmem"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func codeNode(id int64, code string) graph.Node {
+	return graph.Node{NodeIdentity: id, Labels: []string{"Code"}, Properties: map[string]interface{}{"code": code}}
+}
+
+// hierarchyPath returns a path through the nodes in order, as Neo4j returns for (a)-[:PARENT_OF]->(b)-...
+func hierarchyPath(nodes ...graph.Node) graph.Path {
+	p := graph.Path{Nodes: nodes}
+	for i := 1; i < len(nodes); i++ {
+		p.Relationships = append(p.Relationships, graph.UnboundRelationship{RelIdentity: int64(100 + i), Type: "PARENT_OF"})
+		p.Sequence = append(p.Sequence, i, i)
+	}
+	return p
+}
+
+func ids(nodes []*TreeNode) []interface{} {
+	var ids []interface{}
+	for _, n := range nodes {
+		ids = append(ids, n.ID)
+	}
+	return ids
+}
+
+func TestTreeBuilderPaths(t *testing.T) {
+	Convey("given rows of paths from the root of a hierarchy", t, func() {
+		root, food, bread, alcohol := codeNode(1, "A0"), codeNode(2, "G10"), codeNode(3, "G11"), codeNode(4, "G20")
+		rows := []graph.Path{
+			hierarchyPath(root, food),
+			hierarchyPath(root, food, bread),
+			hierarchyPath(root, alcohol),
+		}
+
+		Convey("when they are streamed through the path mapper", func() {
+			b := NewTreeBuilder()
+			mapper := b.PathMapper(0)
+			for i, p := range rows {
+				So(mapper(&Result{Data: []interface{}{p}, Index: i}), ShouldBeNil)
+			}
+			tree, err := b.Build()
+
+			Convey("then the tree is assembled with children in the order they were seen", func() {
+				So(err, ShouldBeNil)
+				So(ids(tree.Roots), ShouldResemble, []interface{}{int64(1)})
+				So(ids(tree.Roots[0].Children), ShouldResemble, []interface{}{int64(2), int64(4)})
+				So(tree.Node(int64(3)).Props["code"], ShouldEqual, "G11")
+				So(tree.Node(int64(3)).Labels, ShouldResemble, []string{"Code"})
+			})
+
+			Convey("then ancestors, descendants and breadcrumbs can be looked up", func() {
+				bread := tree.Node(int64(3))
+				So(ids(bread.Ancestors()), ShouldResemble, []interface{}{int64(2), int64(1)})
+				So(ids(bread.Breadcrumb()), ShouldResemble, []interface{}{int64(1), int64(2), int64(3)})
+				So(ids(tree.Roots[0].Descendants()), ShouldResemble, []interface{}{int64(2), int64(3), int64(4)})
+				So(tree.Node(int64(99)), ShouldBeNil)
+			})
+		})
+
+		Convey("when children are ordered by a property", func() {
+			b := NewTreeBuilder().OrderChildren(func(a, b *TreeNode) bool {
+				return a.Props["code"].(string) > b.Props["code"].(string)
+			})
+			for _, p := range rows {
+				So(b.AddPath(p), ShouldBeNil)
+			}
+			tree, err := b.Build()
+
+			Convey("then the children are sorted", func() {
+				So(err, ShouldBeNil)
+				So(ids(tree.Roots[0].Children), ShouldResemble, []interface{}{int64(4), int64(2)})
+			})
+		})
+
+		Convey("when a row is not a path", func() {
+			err := NewTreeBuilder().PathMapper(0)(&Result{Data: []interface{}{"A0"}})
+
+			Convey("then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestTreeBuilderRows(t *testing.T) {
+	Convey("given a hierarchy stored in the graph", t, func() {
+		db := New(boltmem.NewPool())
+		_, _, err := db.Exec(Stmt{Query: "CREATE (a:Code {id: 'A0'})-[:PARENT_OF]->(:Code {id: 'G10', label: 'Food'}), " +
+			"(a)-[:PARENT_OF]->(:Code {id: 'G20', label: 'Alcohol'})"})
+		So(err, ShouldBeNil)
+
+		Convey("when its rows of id, parent id and node are streamed into a builder", func() {
+			b := NewTreeBuilder()
+			err := db.QueryForResults("MATCH (c:Code) OPTIONAL MATCH (p:Code)-[:PARENT_OF]->(c) RETURN c.id, p.id, c ORDER BY c.id",
+				nil, b.RowMapper(0, 1, 2))
+			So(err, ShouldBeNil)
+			tree, err := b.Build()
+
+			Convey("then the tree is assembled", func() {
+				So(err, ShouldBeNil)
+				So(ids(tree.Roots), ShouldResemble, []interface{}{"A0"})
+				So(ids(tree.Roots[0].Children), ShouldResemble, []interface{}{"G10", "G20"})
+				So(tree.Node("G20").Props["label"], ShouldEqual, "Alcohol")
+				So(tree.Node("G20").Parent.ID, ShouldEqual, "A0")
+			})
+		})
+	})
+
+	Convey("given nodes added with their parent ids", t, func() {
+		b := NewTreeBuilder()
+
+		Convey("when a parent is added after its child", func() {
+			So(b.AddNode("G10", "A0", nil), ShouldBeNil)
+			So(b.AddNode("A0", nil, nil), ShouldBeNil)
+			tree, err := b.Build()
+
+			Convey("then the child is still placed", func() {
+				So(err, ShouldBeNil)
+				So(ids(tree.Roots[0].Children), ShouldResemble, []interface{}{"G10"})
+			})
+		})
+
+		Convey("when some parents are missing or form a cycle", func() {
+			So(b.AddNode("A0", nil, nil), ShouldBeNil)
+			So(b.AddNode("G10", "missing", nil), ShouldBeNil)
+			So(b.AddNode("G11", "G10", nil), ShouldBeNil)
+			So(b.AddNode("X", "Z", nil), ShouldBeNil)
+			So(b.AddNode("Y", "X", nil), ShouldBeNil)
+			So(b.AddNode("Z", "Y", nil), ShouldBeNil)
+			So(b.AddNode("W", "X", nil), ShouldBeNil)
+			tree, err := b.Build()
+
+			Convey("then the orphans and cycles are reported along with the tree that could be built", func() {
+				treeErr, ok := err.(*TreeError)
+				So(ok, ShouldBeTrue)
+				So(treeErr.Orphans, ShouldResemble, []interface{}{"G10"})
+				So(treeErr.Cycles, ShouldResemble, []interface{}{"X", "Y", "Z"})
+				So(ids(tree.Roots), ShouldResemble, []interface{}{"A0"})
+				So(tree.Node("G11"), ShouldBeNil)
+			})
+		})
+
+		Convey("when a node is given two parents", func() {
+			So(b.AddNode("G10", "A0", nil), ShouldBeNil)
+			err := b.AddNode("G10", "B0", nil)
+
+			Convey("then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "more than one parent")
+			})
+		})
+	})
+}
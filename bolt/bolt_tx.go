@@ -0,0 +1,108 @@
+package bolt
+
+import (
+	"database/sql/driver"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+var ErrTxClosed = errors.New("transaction has already been committed or rolled back")
+
+// Tx is a transaction holding a single connection from the pool until it is committed or rolled back.
+//
+// A Tx is not safe for concurrent use.
+type Tx struct {
+	db     *DB
+	op     *operation
+	conn   neo4j.Conn
+	tx     driver.Tx
+	tags   []string
+	closed bool
+}
+
+// Begin opens a connection from the pool and begins a transaction on it.
+func (d *DB) Begin() (*Tx, error) {
+	op, err := d.open("transaction")
+	if err != nil {
+		return nil, err
+	}
+
+	tx, err := op.conn.Begin()
+	err = d.retryBroken(op, err, func(conn neo4j.Conn) error {
+		tx, err = conn.Begin()
+		return err
+	})
+	if err != nil {
+		d.release(op)
+		return nil, errors.WithMessage(err, "error beginning transaction")
+	}
+	return &Tx{db: d, op: op, conn: op.conn, tx: tx}, nil
+}
+
+// QueryForResults executes the provided query within the transaction to return 1 or more results.
+func (t *Tx) QueryForResults(query string, params map[string]interface{}, mapResult ResultMapper) error {
+	return t.query(query, params, mapResult, false)
+}
+
+// QueryForResult executes the provided query within the transaction to return a single result.
+func (t *Tx) QueryForResult(query string, params map[string]interface{}, mapResult ResultMapper) error {
+	return t.query(query, params, mapResult, true)
+}
+
+func (t *Tx) query(query string, params map[string]interface{}, mapResult ResultMapper, singleResult bool) error {
+	if t.closed {
+		return ErrTxClosed
+	}
+	return queryConn(t.conn, func(conn neo4j.Conn) (neo4j.Rows, error) {
+		return conn.QueryNeo(query, params)
+	}, mapResult, singleResult)
+}
+
+// Exec executes the statement within the transaction. Result cache tags declared by the statement are invalidated
+// once the transaction is committed.
+func (t *Tx) Exec(s Stmt) (int64, map[string]interface{}, error) {
+	if t.closed {
+		return 0, nil, ErrTxClosed
+	}
+	if s.Query == "" {
+		return 0, nil, nil
+	}
+
+	t.tags = append(t.tags, s.Tags...)
+	return execConn(t.conn, func(conn neo4j.Conn) (neo4j.Result, error) {
+		return conn.ExecNeo(s.Query, s.Params)
+	})
+}
+
+// Commit commits the transaction and returns its connection to the pool.
+func (t *Tx) Commit() error {
+	if t.closed {
+		return ErrTxClosed
+	}
+	t.closed = true
+	defer t.db.release(t.op)
+
+	if err := t.tx.Commit(); err != nil {
+		return errors.WithMessage(err, "error committing transaction")
+	}
+
+	if t.db.resultCache != nil {
+		t.db.resultCache.InvalidateTags(t.tags...)
+	}
+	return nil
+}
+
+// Rollback rolls back the transaction and returns its connection to the pool.
+func (t *Tx) Rollback() error {
+	if t.closed {
+		return ErrTxClosed
+	}
+	t.closed = true
+	defer t.db.release(t.op)
+
+	if err := t.tx.Rollback(); err != nil {
+		return errors.WithMessage(err, "error rolling back transaction")
+	}
+	return nil
+}
@@ -0,0 +1,142 @@
+package bolt
+
+import (
+	"database/sql/driver"
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt/mock"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+type txStub struct {
+	commits   int
+	rollbacks int
+	err       error
+}
+
+func (t *txStub) Commit() error {
+	t.commits++
+	return t.err
+}
+
+func (t *txStub) Rollback() error {
+	t.rollbacks++
+	return t.err
+}
+
+func newTxMocks(tx *txStub) (*mock.NeoConnMock, *mock.DBPoolMock) {
+	conn := &mock.NeoConnMock{
+		CloseFunc: closeNoErr,
+		BeginFunc: func() (driver.Tx, error) {
+			return tx, nil
+		},
+		ExecNeoFunc: func(query string, params map[string]interface{}) (neo4j.Result, error) {
+			return &mock.NeoResultMock{
+				RowsAffectedFunc: func() (int64, error) {
+					return int64(1), nil
+				},
+				MetadataFunc: func() map[string]interface{} {
+					return nil
+				},
+			}, nil
+		},
+	}
+
+	pool := &mock.DBPoolMock{
+		CloseFunc: closeNoErr,
+		OpenPoolFunc: func() (neo4j.Conn, error) {
+			return conn, nil
+		},
+	}
+	return conn, pool
+}
+
+func TestTx_Commit(t *testing.T) {
+	Convey("given a transaction with an executed statement", t, func() {
+		tx := &txStub{}
+		conn, pool := newTxMocks(tx)
+		store := NewMemoryStore(10, 0)
+		store.Set("key", &CacheEntry{Tags: []string{"dataset"}})
+		db := New(pool, WithResultCache(NewResultCache(store, 0)))
+
+		t, err := db.Begin()
+		So(err, ShouldBeNil)
+
+		rowsAffected, _, err := t.Exec(Stmt{Query: "CREATE (n:_dataset)", Tags: []string{"dataset"}})
+		So(err, ShouldBeNil)
+		So(rowsAffected, ShouldEqual, 1)
+
+		Convey("when the transaction is committed", func() {
+			err := t.Commit()
+
+			Convey("then the statement runs on the connection held by the transaction", func() {
+				So(err, ShouldBeNil)
+				So(pool.OpenPoolCalls(), ShouldHaveLength, 1)
+				So(conn.ExecNeoCalls(), ShouldHaveLength, 1)
+				So(tx.commits, ShouldEqual, 1)
+				So(conn.CloseCalls(), ShouldHaveLength, 1)
+			})
+
+			Convey("then the tags of the statement are invalidated", func() {
+				So(store.Len(), ShouldEqual, 0)
+			})
+
+			Convey("then the transaction can not be used again", func() {
+				So(t.Commit(), ShouldEqual, ErrTxClosed)
+				So(t.Rollback(), ShouldEqual, ErrTxClosed)
+				_, _, err := t.Exec(stmt)
+				So(err, ShouldEqual, ErrTxClosed)
+			})
+		})
+	})
+}
+
+func TestTx_Rollback(t *testing.T) {
+	Convey("given a transaction with an executed statement", t, func() {
+		tx := &txStub{}
+		conn, pool := newTxMocks(tx)
+		store := NewMemoryStore(10, 0)
+		store.Set("key", &CacheEntry{Tags: []string{"dataset"}})
+		db := New(pool, WithResultCache(NewResultCache(store, 0)))
+
+		t, err := db.Begin()
+		So(err, ShouldBeNil)
+		_, _, err = t.Exec(Stmt{Query: "CREATE (n:_dataset)", Tags: []string{"dataset"}})
+		So(err, ShouldBeNil)
+
+		Convey("when the transaction is rolled back", func() {
+			err := t.Rollback()
+
+			Convey("then the connection is closed and the cache is untouched", func() {
+				So(err, ShouldBeNil)
+				So(tx.rollbacks, ShouldEqual, 1)
+				So(tx.commits, ShouldEqual, 0)
+				So(conn.CloseCalls(), ShouldHaveLength, 1)
+				So(store.Len(), ShouldEqual, 1)
+			})
+		})
+	})
+}
+
+func TestDB_BeginError(t *testing.T) {
+	Convey("should close the connection and return an error if begin fails", t, func() {
+		conn := &mock.NeoConnMock{
+			CloseFunc: closeNoErr,
+			BeginFunc: func() (driver.Tx, error) {
+				return nil, Err
+			},
+		}
+		pool := &mock.DBPoolMock{
+			OpenPoolFunc: func() (neo4j.Conn, error) {
+				return conn, nil
+			},
+		}
+
+		tx, err := New(pool).Begin()
+
+		So(tx, ShouldBeNil)
+		So(err.Error(), ShouldEqual, "error beginning transaction: error")
+		So(conn.CloseCalls(), ShouldHaveLength, 1)
+	})
+}
@@ -0,0 +1,132 @@
+package bolt
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrUpsertNoLabel = errors.New("upsert requires a label or relationship type")
+	ErrUpsertNoKey   = errors.New("upsert requires at least one key property")
+)
+
+// NodeMatch identifies an existing node by its label and key properties.
+type NodeMatch struct {
+	Label string
+	Props map[string]interface{}
+}
+
+// Upsert is a parameterised MERGE statement built by UpsertNode or UpsertRelationship. As running a MERGE again
+// leaves the graph the same, it is marked idempotent so it is retried on a broken connection.
+type Upsert struct {
+	stmt Stmt
+	err  error
+}
+
+// UpsertNode builds a statement merging the node with the label and key properties. onCreate properties are set if
+// the node is created and onMatch properties if it already existed; either may be nil.
+//
+//	MERGE (n:`Dataset` {`id`: {key_0}})
+//	ON CREATE SET n += {on_create}
+//	ON MATCH SET n += {on_match}
+func UpsertNode(label string, keyProps, onCreate, onMatch map[string]interface{}) *Upsert {
+	if label == "" {
+		return &Upsert{err: ErrUpsertNoLabel}
+	}
+	if len(keyProps) == 0 {
+		return &Upsert{err: ErrUpsertNoKey}
+	}
+
+	params := Params{}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "MERGE (n:%s %s)", EscapeIdentifier(label), propsPattern("key", keyProps, params))
+	if len(onCreate) > 0 {
+		b.WriteString("\nON CREATE SET n += {on_create}")
+		params["on_create"] = onCreate
+	}
+	if len(onMatch) > 0 {
+		b.WriteString("\nON MATCH SET n += {on_match}")
+		params["on_match"] = onMatch
+	}
+	return &Upsert{stmt: Stmt{Query: b.String(), Params: params, Idempotent: true}}
+}
+
+// UpsertRelationship builds a statement merging a relationship of the type between the nodes matched by from and to.
+// props are set on the relationship whether it is created or already existed, and may be nil.
+//
+//	MATCH (a:`Dataset` {`id`: {from_0}}), (b:`Edition` {`id`: {to_0}})
+//	MERGE (a)-[r:`HAS_EDITION`]->(b)
+//	SET r += {props}
+//
+// Nothing is written if either node does not exist, which is reported the same way as a matched relationship.
+func UpsertRelationship(from NodeMatch, relType string, to NodeMatch, props map[string]interface{}) *Upsert {
+	if from.Label == "" || to.Label == "" || relType == "" {
+		return &Upsert{err: ErrUpsertNoLabel}
+	}
+	if len(from.Props) == 0 || len(to.Props) == 0 {
+		return &Upsert{err: ErrUpsertNoKey}
+	}
+
+	params := Params{}
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "MATCH (a:%s %s), (b:%s %s)\nMERGE (a)-[r:%s]->(b)",
+		EscapeIdentifier(from.Label), propsPattern("from", from.Props, params),
+		EscapeIdentifier(to.Label), propsPattern("to", to.Props, params),
+		EscapeIdentifier(relType))
+	if len(props) > 0 {
+		b.WriteString("\nSET r += {props}")
+		params["props"] = props
+	}
+	return &Upsert{stmt: Stmt{Query: b.String(), Params: params, Idempotent: true}}
+}
+
+// Stmt returns the statement, or the error describing why it could not be built.
+func (u *Upsert) Stmt() (Stmt, error) {
+	return u.stmt, u.err
+}
+
+// WithTags sets the result cache tags invalidated once the upsert has been executed.
+func (u *Upsert) WithTags(tags ...string) *Upsert {
+	u.stmt.Tags = tags
+	return u
+}
+
+// Exec executes the upsert, reporting whether the node or relationship was created rather than matched.
+func (u *Upsert) Exec(e Executor) (bool, error) {
+	if u.err != nil {
+		return false, u.err
+	}
+	rowsAffected, _, err := e.Exec(u.stmt)
+	if err != nil {
+		return false, err
+	}
+	return rowsAffected > 0, nil
+}
+
+// EscapeIdentifier quotes a label, relationship type or property key with backticks so it can be used safely in a
+// statement.
+func EscapeIdentifier(name string) string {
+	return "`" + strings.Replace(name, "`", "``", -1) + "`"
+}
+
+// propsPattern returns a property map pattern such as {`id`: {key_0}}, adding the values to params. Keys are sorted so
+// the same properties always build the same statement.
+func propsPattern(prefix string, props map[string]interface{}, params Params) string {
+	keys := make([]string, 0, len(props))
+	for k := range props {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	pairs := make([]string, len(keys))
+	for i, k := range keys {
+		name := fmt.Sprintf("%s_%d", prefix, i)
+		pairs[i] = fmt.Sprintf("%s: {%s}", EscapeIdentifier(k), name)
+		params[name] = props[k]
+	}
+	return "{" + strings.Join(pairs, ", ") + "}"
+}
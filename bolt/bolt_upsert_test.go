@@ -0,0 +1,103 @@
+package bolt
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/boltmem"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestUpsertNode(t *testing.T) {
+	Convey("given a node upsert", t, func() {
+		u := UpsertNode("Data`set", map[string]interface{}{"id": "cpih01", "edition": "time-series"},
+			map[string]interface{}{"state": "created"}, map[string]interface{}{"state": "updated"})
+
+		Convey("then a parameterised MERGE statement with escaped identifiers is built", func() {
+			stmt, err := u.Stmt()
+			So(err, ShouldBeNil)
+			So(stmt.Query, ShouldEqual, "MERGE (n:`Data``set` {`edition`: {key_0}, `id`: {key_1}})\n"+
+				"ON CREATE SET n += {on_create}\n"+
+				"ON MATCH SET n += {on_match}")
+			So(stmt.Idempotent, ShouldBeTrue)
+			So(stmt.Params, ShouldResemble, Params{
+				"key_0":     "time-series",
+				"key_1":     "cpih01",
+				"on_create": map[string]interface{}{"state": "created"},
+				"on_match":  map[string]interface{}{"state": "updated"},
+			})
+		})
+
+		Convey("when it is executed twice", func() {
+			db := New(boltmem.NewPool())
+			created, err := u.Exec(db)
+			So(err, ShouldBeNil)
+			createdAgain, err := u.Exec(db)
+			So(err, ShouldBeNil)
+
+			Convey("then the node is created then matched", func() {
+				So(created, ShouldBeTrue)
+				So(createdAgain, ShouldBeFalse)
+
+				var state interface{}
+				err := db.QueryForResult("MATCH (n:`Data``set` {id: 'cpih01'}) RETURN n.state", nil, func(r *Result) error {
+					state = r.Data[0]
+					return nil
+				})
+				So(err, ShouldBeNil)
+				So(state, ShouldEqual, "updated")
+			})
+		})
+	})
+
+	Convey("given upserts that can not be built", t, func() {
+		Convey("then executing them reports why", func() {
+			_, err := UpsertNode("", map[string]interface{}{"id": 1}, nil, nil).Exec(New(boltmem.NewPool()))
+			So(err, ShouldEqual, ErrUpsertNoLabel)
+			_, err = UpsertNode("Dataset", nil, nil, nil).Stmt()
+			So(err, ShouldEqual, ErrUpsertNoKey)
+		})
+	})
+}
+
+func TestUpsertRelationship(t *testing.T) {
+	Convey("given two nodes", t, func() {
+		db := New(boltmem.NewPool())
+		UpsertNode("Dataset", map[string]interface{}{"id": "cpih01"}, nil, nil).Exec(db)
+		UpsertNode("Edition", map[string]interface{}{"id": "time-series"}, nil, nil).Exec(db)
+
+		u := UpsertRelationship(
+			NodeMatch{Label: "Dataset", Props: map[string]interface{}{"id": "cpih01"}},
+			"HAS_EDITION",
+			NodeMatch{Label: "Edition", Props: map[string]interface{}{"id": "time-series"}},
+			map[string]interface{}{"order": int64(1)},
+		)
+
+		Convey("then a parameterised statement is built", func() {
+			stmt, err := u.Stmt()
+			So(err, ShouldBeNil)
+			So(stmt.Query, ShouldEqual, "MATCH (a:`Dataset` {`id`: {from_0}}), (b:`Edition` {`id`: {to_0}})\n"+
+				"MERGE (a)-[r:`HAS_EDITION`]->(b)\n"+
+				"SET r += {props}")
+			So(stmt.Idempotent, ShouldBeTrue)
+		})
+
+		Convey("when the relationship is upserted twice", func() {
+			created, err := u.Exec(db)
+			So(err, ShouldBeNil)
+			createdAgain, err := u.Exec(db)
+			So(err, ShouldBeNil)
+
+			Convey("then it is created once", func() {
+				So(created, ShouldBeTrue)
+				So(createdAgain, ShouldBeFalse)
+
+				var count interface{}
+				db.QueryForResult("MATCH (:Dataset)-[r:HAS_EDITION]->(:Edition) RETURN count(r)", nil, func(r *Result) error {
+					count = r.Data[0]
+					return nil
+				})
+				So(count, ShouldEqual, int64(1))
+			})
+		})
+	})
+}
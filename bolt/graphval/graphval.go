@@ -0,0 +1,175 @@
+// Package graphval converts the node, relationship and path values returned by the driver into types that are easier
+// to work with in a ResultMapper:
+//
+//	err := db.QueryForResults("MATCH p = (:Code)-[:PARENT_OF*]->(:Code) RETURN p", nil, func(r *bolt.Result) error {
+//		p, err := graphval.PathOf(r.Data[0])
+//		if err != nil {
+//			return err
+//		}
+//		for _, s := range p.Segments() {
+//			code, _ := s.End.Props.String("code")
+//			...
+//		}
+//		return nil
+//	})
+package graphval
+
+import (
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/pkg/errors"
+)
+
+// UnknownID is the start and end id of a relationship that was returned on its own without them.
+const UnknownID int64 = -1
+
+// Node is a node with its identity, labels and properties.
+type Node struct {
+	ID     int64
+	Labels []string
+	Props  Props
+}
+
+// HasLabel reports whether the node has the label.
+func (n Node) HasLabel(label string) bool {
+	for _, l := range n.Labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+// HasLabels reports whether the node has all of the labels.
+func (n Node) HasLabels(labels ...string) bool {
+	for _, l := range labels {
+		if !n.HasLabel(l) {
+			return false
+		}
+	}
+	return true
+}
+
+// Decode sets the fields of dst, which must be a pointer to a struct, from the properties of the node. See
+// Props.Decode.
+func (n Node) Decode(dst interface{}) error {
+	return n.Props.Decode(dst)
+}
+
+// Relationship is a relationship with its identity, type, properties and the identities of the nodes it starts and
+// ends at.
+type Relationship struct {
+	ID      int64
+	StartID int64
+	EndID   int64
+	Type    string
+	Props   Props
+}
+
+// Segment is a step along a path from Start to End through Rel. Start and End are in the order of the path, so Rel
+// points from End to Start if the path follows it backwards.
+type Segment struct {
+	Start Node
+	Rel   Relationship
+	End   Node
+}
+
+// Reversed reports whether the path follows the relationship against its direction.
+func (s Segment) Reversed() bool {
+	return s.Rel.StartID != s.Start.ID
+}
+
+// Path is a path through alternating nodes and relationships. It holds one more node than relationships.
+type Path struct {
+	Nodes []Node
+	Rels  []Relationship
+}
+
+// Start returns the first node of the path.
+func (p Path) Start() Node {
+	return p.Nodes[0]
+}
+
+// End returns the last node of the path.
+func (p Path) End() Node {
+	return p.Nodes[len(p.Nodes)-1]
+}
+
+// Len returns the number of relationships in the path.
+func (p Path) Len() int {
+	return len(p.Rels)
+}
+
+// Segments returns the steps along the path in order.
+func (p Path) Segments() []Segment {
+	segments := make([]Segment, len(p.Rels))
+	for i, rel := range p.Rels {
+		segments[i] = Segment{Start: p.Nodes[i], Rel: rel, End: p.Nodes[i+1]}
+	}
+	return segments
+}
+
+// NodeOf converts a graph.Node value.
+func NodeOf(v interface{}) (Node, error) {
+	n, ok := v.(graph.Node)
+	if !ok {
+		return Node{}, errors.Errorf("graphval: expected a node, not %T", v)
+	}
+	return newNode(n), nil
+}
+
+// RelationshipOf converts a graph.Relationship value, or a graph.UnboundRelationship whose start and end ids are
+// UnknownID.
+func RelationshipOf(v interface{}) (Relationship, error) {
+	switch r := v.(type) {
+	case graph.Relationship:
+		return Relationship{ID: r.RelIdentity, StartID: r.StartNodeIdentity, EndID: r.EndNodeIdentity, Type: r.Type,
+			Props: r.Properties}, nil
+	case graph.UnboundRelationship:
+		return Relationship{ID: r.RelIdentity, StartID: UnknownID, EndID: UnknownID, Type: r.Type, Props: r.Properties}, nil
+	}
+	return Relationship{}, errors.Errorf("graphval: expected a relationship, not %T", v)
+}
+
+// PathOf converts a graph.Path value, following its sequence to put the nodes and relationships in order and set the
+// start and end ids of each relationship.
+func PathOf(v interface{}) (Path, error) {
+	gp, ok := v.(graph.Path)
+	if !ok {
+		return Path{}, errors.Errorf("graphval: expected a path, not %T", v)
+	}
+	if len(gp.Nodes) == 0 {
+		return Path{}, errors.New("graphval: path has no nodes")
+	}
+	if len(gp.Sequence)%2 != 0 {
+		return Path{}, errors.New("graphval: path has an odd sequence length")
+	}
+
+	p := Path{Nodes: []Node{newNode(gp.Nodes[0])}}
+	for i := 0; i < len(gp.Sequence); i += 2 {
+		relIndex, nodeIndex := gp.Sequence[i], gp.Sequence[i+1]
+		reversed := relIndex < 0
+		if reversed {
+			relIndex = -relIndex
+		}
+		if relIndex < 1 || relIndex > len(gp.Relationships) {
+			return Path{}, errors.Errorf("graphval: path relationship index %d is out of range", gp.Sequence[i])
+		}
+		if nodeIndex < 0 || nodeIndex >= len(gp.Nodes) {
+			return Path{}, errors.Errorf("graphval: path node index %d is out of range", nodeIndex)
+		}
+
+		prev, next := p.Nodes[len(p.Nodes)-1], newNode(gp.Nodes[nodeIndex])
+		ur := gp.Relationships[relIndex-1]
+		rel := Relationship{ID: ur.RelIdentity, StartID: prev.ID, EndID: next.ID, Type: ur.Type, Props: ur.Properties}
+		if reversed {
+			rel.StartID, rel.EndID = next.ID, prev.ID
+		}
+		p.Nodes = append(p.Nodes, next)
+		p.Rels = append(p.Rels, rel)
+	}
+	return p, nil
+}
+
+func newNode(n graph.Node) Node {
+	return Node{ID: n.NodeIdentity, Labels: n.Labels, Props: n.Properties}
+}
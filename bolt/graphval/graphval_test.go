@@ -0,0 +1,205 @@
+package graphval
+
+import (
+	"testing"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPathOf(t *testing.T) {
+	Convey("given a path that follows one relationship forwards and one backwards", t, func() {
+		// (list)-[:HAS_CODE]->(food)<-[:PARENT_OF]-(overall), sent with the nodes out of path order
+		gp := graph.Path{
+			Nodes: []graph.Node{
+				{NodeIdentity: 1, Labels: []string{"CodeList"}},
+				{NodeIdentity: 3, Labels: []string{"Code"}, Properties: map[string]interface{}{"label": "Overall"}},
+				{NodeIdentity: 2, Labels: []string{"Code"}, Properties: map[string]interface{}{"label": "Food"}},
+			},
+			Relationships: []graph.UnboundRelationship{
+				{RelIdentity: 10, Type: "HAS_CODE"},
+				{RelIdentity: 11, Type: "PARENT_OF"},
+			},
+			Sequence: []int{1, 2, -2, 1},
+		}
+
+		Convey("when it is converted", func() {
+			p, err := PathOf(gp)
+
+			Convey("then the nodes are in path order", func() {
+				So(err, ShouldBeNil)
+				So(p.Len(), ShouldEqual, 2)
+				So(p.Start().ID, ShouldEqual, 1)
+				So(p.End().ID, ShouldEqual, 3)
+			})
+
+			Convey("then each segment has its relationship with the start and end ids it points between", func() {
+				s := p.Segments()
+				So(s, ShouldHaveLength, 2)
+				So(s[0].Start.ID, ShouldEqual, 1)
+				So(s[0].End.ID, ShouldEqual, 2)
+				So(s[0].Rel.Type, ShouldEqual, "HAS_CODE")
+				So(s[0].Rel.StartID, ShouldEqual, 1)
+				So(s[0].Reversed(), ShouldBeFalse)
+
+				So(s[1].Start.ID, ShouldEqual, 2)
+				So(s[1].End.ID, ShouldEqual, 3)
+				So(s[1].Rel.StartID, ShouldEqual, 3)
+				So(s[1].Rel.EndID, ShouldEqual, 2)
+				So(s[1].Reversed(), ShouldBeTrue)
+			})
+		})
+	})
+
+	Convey("given values that are not valid paths", t, func() {
+		Convey("then converting them returns an error", func() {
+			_, err := PathOf(graph.Node{})
+			So(err, ShouldNotBeNil)
+			_, err = PathOf(graph.Path{Nodes: []graph.Node{{}}, Sequence: []int{3, 0}})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestNodeAndRelationshipOf(t *testing.T) {
+	Convey("given a node value", t, func() {
+		n, err := NodeOf(graph.Node{NodeIdentity: 7, Labels: []string{"Code", "Leaf"},
+			Properties: map[string]interface{}{"code": "G10", "order": int64(2), "weight": 1.5, "live": true,
+				"tags": []interface{}{"food"}, "updated": "2018-05-01T09:30:00Z"}})
+		So(err, ShouldBeNil)
+
+		Convey("then its labels can be checked", func() {
+			So(n.HasLabel("Code"), ShouldBeTrue)
+			So(n.HasLabels("Code", "Leaf"), ShouldBeTrue)
+			So(n.HasLabels("Code", "Root"), ShouldBeFalse)
+		})
+
+		Convey("then its properties can be read by type", func() {
+			code, ok := n.Props.String("code")
+			So(ok, ShouldBeTrue)
+			So(code, ShouldEqual, "G10")
+			order, _ := n.Props.Int("order")
+			So(order, ShouldEqual, 2)
+			weight, _ := n.Props.Float("weight")
+			So(weight, ShouldEqual, 1.5)
+			asFloat, ok := n.Props.Float("order")
+			So(ok, ShouldBeTrue)
+			So(asFloat, ShouldEqual, 2)
+			live, _ := n.Props.Bool("live")
+			So(live, ShouldBeTrue)
+			tags, _ := n.Props.Strings("tags")
+			So(tags, ShouldResemble, []string{"food"})
+			updated, ok := n.Props.Time("updated")
+			So(ok, ShouldBeTrue)
+			So(updated.Equal(time.Date(2018, 5, 1, 9, 30, 0, 0, time.UTC)), ShouldBeTrue)
+		})
+
+		Convey("then missing properties and those of another type are reported", func() {
+			_, ok := n.Props.String("missing")
+			So(ok, ShouldBeFalse)
+			_, ok = n.Props.Int("code")
+			So(ok, ShouldBeFalse)
+			So(n.Props.Has("code"), ShouldBeTrue)
+		})
+
+		Convey("then numbers that would lose precision are reported", func() {
+			_, ok := n.Props.Int("weight")
+			So(ok, ShouldBeFalse)
+			_, ok = Props{"big": int64(1<<53 + 1)}.Float("big")
+			So(ok, ShouldBeFalse)
+		})
+	})
+
+	Convey("given relationship values", t, func() {
+		Convey("then bound relationships keep their ends and unbound ones have unknown ends", func() {
+			r, err := RelationshipOf(graph.Relationship{RelIdentity: 1, StartNodeIdentity: 2, EndNodeIdentity: 3, Type: "PARENT_OF"})
+			So(err, ShouldBeNil)
+			So(r.StartID, ShouldEqual, 2)
+			So(r.EndID, ShouldEqual, 3)
+
+			r, err = RelationshipOf(graph.UnboundRelationship{RelIdentity: 1, Type: "PARENT_OF"})
+			So(err, ShouldBeNil)
+			So(r.StartID, ShouldEqual, UnknownID)
+
+			_, err = RelationshipOf(graph.Node{})
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestDecode(t *testing.T) {
+	type code struct {
+		Code     string     `bolt:"code,key"`
+		Order    int        `bolt:"order"`
+		Weight   *float32   `bolt:"weight"`
+		Tags     []string   `bolt:"tags"`
+		Updated  time.Time  `bolt:"updated"`
+		Children []struct{} `bolt:"rel=PARENT_OF"`
+		Note     string     `bolt:"-"`
+		Kept     string     `bolt:"kept"`
+		Ignored  string
+	}
+
+	Convey("given a node with properties", t, func() {
+		n := Node{Props: Props{"code": "G10", "order": int64(2), "weight": 1.5, "tags": []interface{}{"food"},
+			"updated": "2018-05-01T09:30:00Z", "Ignored": "x", "Note": "y"}}
+
+		Convey("when it is decoded into a struct", func() {
+			c := code{Kept: "unchanged"}
+			err := n.Decode(&c)
+
+			Convey("then the tagged fields are set and converted", func() {
+				So(err, ShouldBeNil)
+				So(c.Code, ShouldEqual, "G10")
+				So(c.Order, ShouldEqual, 2)
+				So(*c.Weight, ShouldEqual, 1.5)
+				So(c.Tags, ShouldResemble, []string{"food"})
+				So(c.Updated.Equal(time.Date(2018, 5, 1, 9, 30, 0, 0, time.UTC)), ShouldBeTrue)
+			})
+
+			Convey("then untagged, skipped and missing fields are left unchanged", func() {
+				So(c.Kept, ShouldEqual, "unchanged")
+				So(c.Ignored, ShouldBeEmpty)
+				So(c.Note, ShouldBeEmpty)
+				So(c.Children, ShouldBeNil)
+			})
+		})
+
+		Convey("when a property can not be converted", func() {
+			err := Node{Props: Props{"order": "first"}}.Decode(&code{})
+
+			Convey("then the property is named in the error", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "property order")
+			})
+		})
+
+		Convey("when a number would lose precision in the field", func() {
+			fractional := Node{Props: Props{"order": 2.7}}.Decode(&code{})
+			inexact := Node{Props: Props{"weight": int64(1<<53 + 1)}}.Decode(&code{})
+
+			Convey("then it is an error instead of being truncated or rounded", func() {
+				So(fractional, ShouldNotBeNil)
+				So(fractional.Error(), ShouldEqual, "graphval: property order: can not convert float64 2.7 to int")
+				So(inexact, ShouldNotBeNil)
+			})
+		})
+
+		Convey("when a property is null", func() {
+			c := code{Weight: new(float32)}
+			So(Node{Props: Props{"weight": nil}}.Decode(&c), ShouldBeNil)
+			err := Node{Props: Props{"order": nil}}.Decode(&c)
+
+			Convey("then a pointer field is set to nil and other fields are an error", func() {
+				So(c.Weight, ShouldBeNil)
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldEqual, "graphval: property order: can not convert null to int")
+			})
+		})
+
+		Convey("when the destination is not a pointer to a struct", func() {
+			So(n.Decode(code{}), ShouldNotBeNil)
+		})
+	})
+}
@@ -0,0 +1,102 @@
+package graphval
+
+import (
+	"reflect"
+	"strings"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/bolt/internal/convert"
+	"github.com/pkg/errors"
+)
+
+// Props are the properties of a node or relationship. The getters report false if the property is missing, null or
+// of another type.
+type Props map[string]interface{}
+
+// Has reports whether the property is set.
+func (p Props) Has(key string) bool {
+	return p[key] != nil
+}
+
+// String returns a string property.
+func (p Props) String(key string) (string, bool) {
+	s, ok := p[key].(string)
+	return s, ok
+}
+
+// Int returns an integer property. A float is accepted if it is integral.
+func (p Props) Int(key string) (int64, bool) {
+	return convert.Int64(p[key])
+}
+
+// Float returns a float property. An integer is accepted if a float holds it exactly.
+func (p Props) Float(key string) (float64, bool) {
+	return convert.Float64(p[key])
+}
+
+// Bool returns a boolean property.
+func (p Props) Bool(key string) (bool, bool) {
+	b, ok := p[key].(bool)
+	return b, ok
+}
+
+// Strings returns a property holding a list of strings.
+func (p Props) Strings(key string) ([]string, bool) {
+	list, ok := p[key].([]interface{})
+	if !ok {
+		return nil, false
+	}
+	strs := make([]string, len(list))
+	for i, item := range list {
+		if strs[i], ok = item.(string); !ok {
+			return nil, false
+		}
+	}
+	return strs, true
+}
+
+// Time returns a property holding an RFC 3339 timestamp.
+func (p Props) Time(key string) (time.Time, bool) {
+	s, ok := p.String(key)
+	if !ok {
+		return time.Time{}, false
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	return t, err == nil
+}
+
+// Decode sets the fields of dst, which must be a pointer to a struct, from the properties. Fields are matched by their
+// bolt tag, as in `bolt:"title"`, and fields without a tag or tagged "-" are skipped. Anything after a comma in the
+// tag is ignored, so structs mapped by the ogm package can be decoded too. Fields of missing properties are left
+// unchanged. Values are converted as bolt.Result.Scan converts them: numbers only when no precision is lost, lists to
+// slices, RFC 3339 strings to time.Time and values of types with a codec registered by bolt.RegisterCodec using it.
+// Null properties set pointer, slice and map fields to nil and are an error for other fields, unless the field has a
+// Scan(interface{}) error method, such as bolt.NullString, to set itself from the property value.
+func (p Props) Decode(dst interface{}) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return errors.Errorf("graphval: Decode requires a pointer to a struct, not %T", dst)
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, ok := sf.Tag.Lookup("bolt")
+		if !ok || sf.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if name == "" || name == "-" || strings.Contains(name, "=") {
+			continue
+		}
+		val, ok := p[name]
+		if !ok {
+			continue
+		}
+		if err := convert.Assign(v.Field(i), val); err != nil {
+			return errors.WithMessage(err, "graphval: property "+name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,190 @@
+package convert
+
+import (
+	"encoding"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// codec is a registered conversion of a Go type to and from the values the driver sends and receives.
+type codec struct {
+	encode func(v interface{}) (interface{}, error)
+	decode func(src interface{}) (interface{}, error)
+}
+
+var codecs = struct {
+	sync.RWMutex
+	byType map[reflect.Type]codec
+}{byType: map[reflect.Type]codec{}}
+
+// Register registers how values of type t are encoded and decoded, replacing any codec already registered for t.
+// decode must return a t and is never given null.
+func Register(t reflect.Type, encode func(v interface{}) (interface{}, error),
+	decode func(src interface{}) (interface{}, error)) {
+	codecs.Lock()
+	defer codecs.Unlock()
+	codecs.byType[t] = codec{encode: encode, decode: decode}
+}
+
+func lookupCodec(t reflect.Type) (codec, bool) {
+	codecs.RLock()
+	defer codecs.RUnlock()
+	c, ok := codecs.byType[t]
+	return c, ok
+}
+
+func init() {
+	Register(reflect.TypeOf(time.Time{}), func(v interface{}) (interface{}, error) {
+		return v.(time.Time).Format(time.RFC3339Nano), nil
+	}, func(src interface{}) (interface{}, error) {
+		s, ok := src.(string)
+		if !ok {
+			return time.Time{}, &MismatchError{Value: Describe(src), Type: reflect.TypeOf(time.Time{})}
+		}
+		return time.Parse(time.RFC3339Nano, s)
+	})
+
+	Register(reflect.TypeOf(time.Duration(0)), func(v interface{}) (interface{}, error) {
+		return int64(v.(time.Duration)), nil
+	}, func(src interface{}) (interface{}, error) {
+		if s, ok := src.(string); ok {
+			return time.ParseDuration(s)
+		}
+		n, ok := Int64(src)
+		if !ok {
+			return time.Duration(0), &MismatchError{Value: Describe(src), Type: reflect.TypeOf(time.Duration(0))}
+		}
+		return time.Duration(n), nil
+	})
+}
+
+var (
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// encodeCustom returns the value v is sent as if it is not a type the driver sends as it is, in order of preference
+// using Valuer, a registered codec or encoding.TextMarshaler. Otherwise named types are sent as their underlying
+// type, arrays of 16 bytes such as UUIDs as their canonical string and other slices, arrays and maps with string keys
+// as lists and maps. It returns false if v is left as it is.
+func encodeCustom(v interface{}) (interface{}, bool, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.IsNil() {
+		return nil, true, nil
+	}
+	if vr, ok := v.(Valuer); ok {
+		e, err := vr.Value()
+		return e, true, err
+	}
+	if c, ok := lookupCodec(rv.Type()); ok {
+		e, err := c.encode(v)
+		if err == nil && reflect.TypeOf(e) == rv.Type() {
+			err = errors.Errorf("codec for %s returned a value of the same type", rv.Type())
+		}
+		return e, true, err
+	}
+	if rv.Type().Implements(textMarshalerType) {
+		text, err := v.(encoding.TextMarshaler).MarshalText()
+		return string(text), true, err
+	}
+
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), true, nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		if n := rv.Uint(); n <= 1<<63-1 {
+			return int64(n), true, nil
+		}
+		return nil, false, errors.Errorf("%s %d is too large to send as an integer", rv.Type(), rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), true, nil
+	case reflect.String:
+		return rv.String(), true, nil
+	case reflect.Bool:
+		return rv.Bool(), true, nil
+	case reflect.Array, reflect.Slice:
+		if isUUIDType(rv.Type()) {
+			return formatUUID(rv), true, nil
+		}
+		if rv.Kind() == reflect.Slice && rv.IsNil() {
+			return nil, true, nil
+		}
+		list := make([]interface{}, rv.Len())
+		for i := range list {
+			list[i] = rv.Index(i).Interface()
+		}
+		return list, true, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		if rv.IsNil() {
+			return nil, true, nil
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			m[k.String()] = rv.MapIndex(k).Interface()
+		}
+		return m, true, nil
+	case reflect.Ptr:
+		return rv.Elem().Interface(), true, nil
+	}
+	return v, false, nil
+}
+
+// decodeCustom sets dst from src using a registered codec for its type, encoding.TextUnmarshaler for strings or
+// parsing the canonical string of a UUID, returning false if none apply.
+func decodeCustom(dst reflect.Value, src interface{}) (bool, error) {
+	if c, ok := lookupCodec(dst.Type()); ok {
+		v, err := c.decode(src)
+		if err != nil {
+			return true, err
+		}
+		dst.Set(reflect.ValueOf(v))
+		return true, nil
+	}
+
+	s, isString := src.(string)
+	if !isString {
+		return false, nil
+	}
+	if dst.CanAddr() && reflect.PointerTo(dst.Type()).Implements(textUnmarshalerType) {
+		return true, dst.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if isUUIDType(dst.Type()) && dst.Kind() == reflect.Array {
+		return true, parseUUID(dst, s)
+	}
+	return false, nil
+}
+
+func isUUIDType(t reflect.Type) bool {
+	return t.Kind() == reflect.Array && t.Len() == 16 && t.Elem().Kind() == reflect.Uint8
+}
+
+// formatUUID formats an array of 16 bytes as xxxxxxxx-xxxx-xxxx-xxxx-xxxxxxxxxxxx.
+func formatUUID(v reflect.Value) string {
+	b := make([]byte, 16)
+	for i := range b {
+		b[i] = byte(v.Index(i).Uint())
+	}
+	h := hex.EncodeToString(b)
+	return fmt.Sprintf("%s-%s-%s-%s-%s", h[0:8], h[8:12], h[12:16], h[16:20], h[20:])
+}
+
+// parseUUID sets an array of 16 bytes from a UUID string, with or without hyphens.
+func parseUUID(dst reflect.Value, s string) error {
+	b, err := hex.DecodeString(strings.Replace(s, "-", "", -1))
+	if err != nil || len(b) != 16 {
+		return errors.Errorf("%q is not a UUID", s)
+	}
+	for i, c := range b {
+		dst.Index(i).SetUint(uint64(c))
+	}
+	return nil
+}
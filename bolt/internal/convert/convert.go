@@ -0,0 +1,154 @@
+// Package convert converts values received from the driver to Go types and Go values to ones the driver can send. It
+// is shared by bolt, graphval and ogm so results, properties and mapped structs convert values the same way: numbers
+// only when no precision is lost and null only into types that can hold it.
+package convert
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/pkg/errors"
+)
+
+// Valuer is implemented by types that are sent to the database as another value.
+type Valuer interface {
+	Value() (interface{}, error)
+}
+
+// Scanner is implemented by types that set themselves from a value received from the database, including null.
+type Scanner interface {
+	Scan(src interface{}) error
+}
+
+// MismatchError reports a value that can not be converted to the type asked for.
+type MismatchError struct {
+	// Value describes the value as Describe does.
+	Value string
+	Type  reflect.Type
+}
+
+func (e *MismatchError) Error() string {
+	return fmt.Sprintf("can not convert %s to %s", e.Value, e.Type)
+}
+
+// maxExactFloatInt is the largest integer every smaller integer of which a float64 holds exactly.
+const maxExactFloatInt = 1 << 53
+
+// Int64 converts an integer, or a float that is integral, reporting false if it can not be converted without loss.
+func Int64(v interface{}) (int64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) && n >= math.MinInt64 && n < math.MaxInt64 {
+			return int64(n), true
+		}
+	}
+	return 0, false
+}
+
+// Float64 converts a float, or an integer a float holds exactly.
+func Float64(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case float64:
+		return n, true
+	case int64:
+		if n <= maxExactFloatInt && n >= -maxExactFloatInt {
+			return float64(n), true
+		}
+	}
+	return 0, false
+}
+
+// Describe describes a value for an error, including numbers as their value may be why they were rejected.
+func Describe(v interface{}) string {
+	switch n := v.(type) {
+	case nil:
+		return "null"
+	case int64, float64:
+		return fmt.Sprintf("%T %v", n, n)
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "a map"
+	case graph.Node:
+		return "a node"
+	case graph.Relationship, graph.UnboundRelationship:
+		return "a relationship"
+	case graph.Path:
+		return "a path"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// Assign sets dst to a value received from the driver using Scanner or a codec, converting numbers when no precision
+// is lost and lists to slices. Null is only accepted by a Scanner or a type that can be nil.
+func Assign(dst reflect.Value, src interface{}) error {
+	if dst.CanAddr() {
+		if s, ok := dst.Addr().Interface().(Scanner); ok {
+			return s.Scan(src)
+		}
+	}
+	if src == nil {
+		switch dst.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map:
+			dst.Set(reflect.Zero(dst.Type()))
+			return nil
+		}
+		return &MismatchError{Value: Describe(nil), Type: dst.Type()}
+	}
+	if ok, err := decodeCustom(dst, src); ok {
+		return err
+	}
+
+	v := reflect.ValueOf(src)
+	switch {
+	case v.Type().AssignableTo(dst.Type()):
+		dst.Set(v)
+		return nil
+
+	case dst.Kind() == reflect.Ptr:
+		elem := reflect.New(dst.Type().Elem())
+		if err := Assign(elem.Elem(), src); err != nil {
+			return err
+		}
+		dst.Set(elem)
+		return nil
+
+	case dst.Kind() == reflect.Slice && v.Kind() == reflect.Slice:
+		list := reflect.MakeSlice(dst.Type(), v.Len(), v.Len())
+		for i := 0; i < v.Len(); i++ {
+			if err := Assign(list.Index(i), v.Index(i).Interface()); err != nil {
+				return errors.WithMessage(err, "list item "+strconv.Itoa(i))
+			}
+		}
+		dst.Set(list)
+		return nil
+
+	case dst.Kind() >= reflect.Int && dst.Kind() <= reflect.Int64:
+		if i, ok := Int64(src); ok && !dst.OverflowInt(i) {
+			dst.SetInt(i)
+			return nil
+		}
+
+	case dst.Kind() >= reflect.Uint && dst.Kind() <= reflect.Uint64:
+		if i, ok := Int64(src); ok && i >= 0 && !dst.OverflowUint(uint64(i)) {
+			dst.SetUint(uint64(i))
+			return nil
+		}
+
+	case dst.Kind() == reflect.Float32 || dst.Kind() == reflect.Float64:
+		if f, ok := Float64(src); ok && !dst.OverflowFloat(f) {
+			dst.SetFloat(f)
+			return nil
+		}
+
+	case dst.Kind() == v.Kind() && dst.Kind() == reflect.String:
+		dst.SetString(v.String())
+		return nil
+	}
+	return &MismatchError{Value: Describe(src), Type: dst.Type()}
+}
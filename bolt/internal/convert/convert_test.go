@@ -0,0 +1,47 @@
+package convert
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestAssign(t *testing.T) {
+	Convey("given values received from the driver", t, func() {
+		cases := []struct {
+			src  interface{}
+			dst  interface{}
+			want interface{}
+			err  string
+		}{
+			{src: int64(300), dst: new(int16), want: int16(300)},
+			{src: int64(300), dst: new(int8), err: "can not convert int64 300 to int8"},
+			{src: int64(-1), dst: new(uint), err: "can not convert int64 -1 to uint"},
+			{src: 2.0, dst: new(int), want: 2},
+			{src: 2.7, dst: new(int), err: "can not convert float64 2.7 to int"},
+			{src: math.Inf(1), dst: new(int64), err: "can not convert float64 +Inf to int64"},
+			{src: int64(1 << 53), dst: new(float64), want: float64(1 << 53)},
+			{src: int64(1<<53 + 1), dst: new(float64), err: "can not convert int64 9007199254740993 to float64"},
+			{src: 1e300, dst: new(float32), err: "can not convert float64 1e+300 to float32"},
+			{src: nil, dst: new(int), err: "can not convert null to int"},
+			{src: nil, dst: new(*int), want: (*int)(nil)},
+			{src: []interface{}{int64(1), 2.5}, dst: new([]int), err: "list item 1: can not convert float64 2.5 to int"},
+		}
+
+		Convey("then each is converted only when no precision is lost", func() {
+			for _, c := range cases {
+				dst := reflect.ValueOf(c.dst).Elem()
+				err := Assign(dst, c.src)
+				if c.err != "" {
+					So(err, ShouldNotBeNil)
+					So(err.Error(), ShouldEqual, c.err)
+					continue
+				}
+				So(err, ShouldBeNil)
+				So(dst.Interface(), ShouldEqual, c.want)
+			}
+		})
+	})
+}
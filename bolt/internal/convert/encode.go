@@ -0,0 +1,70 @@
+package convert
+
+import (
+	"strconv"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
+	"github.com/pkg/errors"
+)
+
+// Encode returns the value v is sent as, and whether that differs from v. Values the driver can not send, including
+// those within lists and maps, are replaced using Valuer, a registered codec or encoding.TextMarshaler, and otherwise
+// by their underlying type, as lists and maps or as the canonical string of a UUID.
+func Encode(v interface{}) (interface{}, bool, error) {
+	switch val := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint8, uint16, uint32, float32, float64, string, structures.Structure:
+		return v, false, nil
+	case []interface{}:
+		var list []interface{}
+		for i, item := range val {
+			e, changed, err := Encode(item)
+			if err != nil {
+				return nil, false, errors.WithMessage(err, "list item "+strconv.Itoa(i))
+			}
+			if changed && list == nil {
+				list = append([]interface{}{}, val...)
+			}
+			if list != nil {
+				list[i] = e
+			}
+		}
+		if list == nil {
+			return v, false, nil
+		}
+		return list, true, nil
+	case map[string]interface{}:
+		return EncodeMap(val, "key ")
+	}
+
+	e, changed, err := encodeCustom(v)
+	if err != nil || !changed {
+		return e, changed, err
+	}
+	e, _, err = Encode(e)
+	return e, true, err
+}
+
+// EncodeMap encodes the values of m, copying m only if one of them changes. Errors name the key after prefix.
+func EncodeMap(m map[string]interface{}, prefix string) (map[string]interface{}, bool, error) {
+	var encoded map[string]interface{}
+	for k, v := range m {
+		e, changed, err := Encode(v)
+		if err != nil {
+			return nil, false, errors.WithMessage(err, prefix+k)
+		}
+		if !changed {
+			continue
+		}
+		if encoded == nil {
+			encoded = make(map[string]interface{}, len(m))
+			for k, v := range m {
+				encoded[k] = v
+			}
+		}
+		encoded[k] = e
+	}
+	if encoded == nil {
+		return m, false, nil
+	}
+	return encoded, true, nil
+}
@@ -942,3 +942,145 @@ func (mock *NeoResultMock) RowsAffectedCalls() []struct {
 	lockNeoResultMockRowsAffected.RUnlock()
 	return calls
 }
+
+var (
+	lockNeoStmtMockClose    sync.RWMutex
+	lockNeoStmtMockExecNeo  sync.RWMutex
+	lockNeoStmtMockQueryNeo sync.RWMutex
+)
+
+// NeoStmtMock is a mock implementation of NeoStmt.
+//
+//     func TestSomethingThatUsesNeoStmt(t *testing.T) {
+//
+//         // make and configure a mocked NeoStmt
+//         mockedNeoStmt := &NeoStmtMock{
+//             CloseFunc: func() error {
+// 	               panic("TODO: mock out the Close method")
+//             },
+//             ExecNeoFunc: func(params map[string]interface{}) (golangNeo4jBoltDriver.Result, error) {
+// 	               panic("TODO: mock out the ExecNeo method")
+//             },
+//             QueryNeoFunc: func(params map[string]interface{}) (golangNeo4jBoltDriver.Rows, error) {
+// 	               panic("TODO: mock out the QueryNeo method")
+//             },
+//         }
+//
+//         // TODO: use mockedNeoStmt in code that requires NeoStmt
+//         //       and then make assertions.
+//
+//     }
+type NeoStmtMock struct {
+	// CloseFunc mocks the Close method.
+	CloseFunc func() error
+
+	// ExecNeoFunc mocks the ExecNeo method.
+	ExecNeoFunc func(params map[string]interface{}) (golangNeo4jBoltDriver.Result, error)
+
+	// QueryNeoFunc mocks the QueryNeo method.
+	QueryNeoFunc func(params map[string]interface{}) (golangNeo4jBoltDriver.Rows, error)
+
+	// calls tracks calls to the methods.
+	calls struct {
+		// Close holds details about calls to the Close method.
+		Close []struct {
+		}
+		// ExecNeo holds details about calls to the ExecNeo method.
+		ExecNeo []struct {
+			Params map[string]interface{}
+		}
+		// QueryNeo holds details about calls to the QueryNeo method.
+		QueryNeo []struct {
+			Params map[string]interface{}
+		}
+	}
+}
+
+// Close calls CloseFunc.
+func (mock *NeoStmtMock) Close() error {
+	if mock.CloseFunc == nil {
+		panic("moq: NeoStmtMock.CloseFunc is nil but NeoStmt.Close was just called")
+	}
+	callInfo := struct {
+	}{}
+	lockNeoStmtMockClose.Lock()
+	mock.calls.Close = append(mock.calls.Close, callInfo)
+	lockNeoStmtMockClose.Unlock()
+	return mock.CloseFunc()
+}
+
+// CloseCalls gets all the calls that were made to Close.
+// Check the length with:
+//     len(mockedNeoStmt.CloseCalls())
+func (mock *NeoStmtMock) CloseCalls() []struct {
+} {
+	var calls []struct {
+	}
+	lockNeoStmtMockClose.RLock()
+	calls = mock.calls.Close
+	lockNeoStmtMockClose.RUnlock()
+	return calls
+}
+
+// ExecNeo calls ExecNeoFunc.
+func (mock *NeoStmtMock) ExecNeo(params map[string]interface{}) (golangNeo4jBoltDriver.Result, error) {
+	if mock.ExecNeoFunc == nil {
+		panic("moq: NeoStmtMock.ExecNeoFunc is nil but NeoStmt.ExecNeo was just called")
+	}
+	callInfo := struct {
+		Params map[string]interface{}
+	}{
+		Params: params,
+	}
+	lockNeoStmtMockExecNeo.Lock()
+	mock.calls.ExecNeo = append(mock.calls.ExecNeo, callInfo)
+	lockNeoStmtMockExecNeo.Unlock()
+	return mock.ExecNeoFunc(params)
+}
+
+// ExecNeoCalls gets all the calls that were made to ExecNeo.
+// Check the length with:
+//     len(mockedNeoStmt.ExecNeoCalls())
+func (mock *NeoStmtMock) ExecNeoCalls() []struct {
+	Params map[string]interface{}
+} {
+	var calls []struct {
+		Params map[string]interface{}
+	}
+	lockNeoStmtMockExecNeo.RLock()
+	calls = mock.calls.ExecNeo
+	lockNeoStmtMockExecNeo.RUnlock()
+	return calls
+}
+
+// QueryNeo calls QueryNeoFunc.
+func (mock *NeoStmtMock) QueryNeo(params map[string]interface{}) (golangNeo4jBoltDriver.Rows, error) {
+	if mock.QueryNeoFunc == nil {
+		panic("moq: NeoStmtMock.QueryNeoFunc is nil but NeoStmt.QueryNeo was just called")
+	}
+	callInfo := struct {
+		Params map[string]interface{}
+	}{
+		Params: params,
+	}
+	lockNeoStmtMockQueryNeo.Lock()
+	mock.calls.QueryNeo = append(mock.calls.QueryNeo, callInfo)
+	lockNeoStmtMockQueryNeo.Unlock()
+	return mock.QueryNeoFunc(params)
+}
+
+// QueryNeoCalls gets all the calls that were made to QueryNeo.
+// Check the length with:
+//     len(mockedNeoStmt.QueryNeoCalls())
+func (mock *NeoStmtMock) QueryNeoCalls() []struct {
+	Params map[string]interface{}
+} {
+	var calls []struct {
+		Params map[string]interface{}
+	}
+	lockNeoStmtMockQueryNeo.RLock()
+	calls = mock.calls.QueryNeo
+	lockNeoStmtMockQueryNeo.RUnlock()
+	return calls
+}
+
@@ -0,0 +1,160 @@
+package mockrows
+
+import (
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+)
+
+// NodeBuilder builds a graph.Node.
+type NodeBuilder struct {
+	node graph.Node
+}
+
+// Node starts building a node with the provided id and labels.
+func Node(id int64, labels ...string) *NodeBuilder {
+	return &NodeBuilder{node: graph.Node{NodeIdentity: id, Labels: labels, Properties: map[string]interface{}{}}}
+}
+
+// Prop sets a property of the node.
+func (b *NodeBuilder) Prop(key string, value interface{}) *NodeBuilder {
+	b.node.Properties[key] = value
+	return b
+}
+
+// Props sets several properties of the node.
+func (b *NodeBuilder) Props(props map[string]interface{}) *NodeBuilder {
+	for k, v := range props {
+		b.node.Properties[k] = v
+	}
+	return b
+}
+
+// Build returns the node.
+func (b *NodeBuilder) Build() graph.Node {
+	n := b.node
+	n.Labels = append([]string{}, b.node.Labels...)
+	n.Properties = copyProps(b.node.Properties)
+	return n
+}
+
+// RelationshipBuilder builds a graph.Relationship, or the graph.UnboundRelationship used within paths.
+type RelationshipBuilder struct {
+	rel graph.Relationship
+}
+
+// Relationship starts building a relationship of type typ from the start node to the end node.
+func Relationship(id int64, typ string, start, end int64) *RelationshipBuilder {
+	return &RelationshipBuilder{rel: graph.Relationship{
+		RelIdentity:       id,
+		Type:              typ,
+		StartNodeIdentity: start,
+		EndNodeIdentity:   end,
+		Properties:        map[string]interface{}{},
+	}}
+}
+
+// Prop sets a property of the relationship.
+func (b *RelationshipBuilder) Prop(key string, value interface{}) *RelationshipBuilder {
+	b.rel.Properties[key] = value
+	return b
+}
+
+// Build returns the relationship.
+func (b *RelationshipBuilder) Build() graph.Relationship {
+	r := b.rel
+	r.Properties = copyProps(b.rel.Properties)
+	return r
+}
+
+// Unbound returns the relationship without its start and end nodes, as it appears in a path.
+func (b *RelationshipBuilder) Unbound() graph.UnboundRelationship {
+	return graph.UnboundRelationship{RelIdentity: b.rel.RelIdentity, Type: b.rel.Type, Properties: copyProps(b.rel.Properties)}
+}
+
+// PathBuilder builds a graph.Path one hop at a time.
+type PathBuilder struct {
+	path graph.Path
+	last int64
+}
+
+// Path starts building a path at the start node.
+func Path(start *NodeBuilder) *PathBuilder {
+	return &PathBuilder{path: graph.Path{Nodes: []graph.Node{start.Build()}}, last: start.node.NodeIdentity}
+}
+
+// Hop extends the path along rel to node. The relationship is traversed backwards if it ends at the last node of the
+// path.
+func (b *PathBuilder) Hop(rel *RelationshipBuilder, node *NodeBuilder) *PathBuilder {
+	relIndex := -1
+	for i, r := range b.path.Relationships {
+		if r.RelIdentity == rel.rel.RelIdentity {
+			relIndex = i
+		}
+	}
+	if relIndex < 0 {
+		b.path.Relationships = append(b.path.Relationships, rel.Unbound())
+		relIndex = len(b.path.Relationships) - 1
+	}
+
+	nodeIndex := -1
+	for i, n := range b.path.Nodes {
+		if n.NodeIdentity == node.node.NodeIdentity {
+			nodeIndex = i
+		}
+	}
+	if nodeIndex < 0 {
+		b.path.Nodes = append(b.path.Nodes, node.Build())
+		nodeIndex = len(b.path.Nodes) - 1
+	}
+
+	// Relationships are indexed from 1 in the sequence, negated when traversed against their direction.
+	step := relIndex + 1
+	if rel.rel.StartNodeIdentity != b.last {
+		step = -step
+	}
+	b.path.Sequence = append(b.path.Sequence, step, nodeIndex)
+	b.last = node.node.NodeIdentity
+	return b
+}
+
+// Build returns the path.
+func (b *PathBuilder) Build() graph.Path {
+	p := b.path
+	p.Nodes = append([]graph.Node{}, b.path.Nodes...)
+	p.Relationships = append([]graph.UnboundRelationship{}, b.path.Relationships...)
+	p.Sequence = append([]int{}, b.path.Sequence...)
+	return p
+}
+
+// Value converts Node, Relationship and Path builders, and lists and maps of them, to graph values. Other values are
+// returned unchanged.
+func Value(v interface{}) interface{} {
+	switch t := v.(type) {
+	case *NodeBuilder:
+		return t.Build()
+	case *RelationshipBuilder:
+		return t.Build()
+	case *PathBuilder:
+		return t.Build()
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, item := range t {
+			l[i] = Value(item)
+		}
+		return l
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			m[k] = Value(item)
+		}
+		return m
+	}
+	return v
+}
+
+func copyProps(props map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(props))
+	for k, v := range props {
+		c[k] = v
+	}
+	return c
+}
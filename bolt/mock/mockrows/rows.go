@@ -0,0 +1,145 @@
+// Package mockrows builds stub driver rows and graph values so mapper tests can be written in a few lines.
+//
+//	rows := mockrows.New("name", "count").
+//	    Row("a", int64(1)).
+//	    Row(mockrows.Node(1, "_code").Prop("value", "K02000001"), int64(2)).
+//	    Err(io.ErrUnexpectedEOF)
+package mockrows
+
+import (
+	"fmt"
+	"io"
+)
+
+// Rows is a complete stub of the driver's Rows, returning the rows added to it in order followed by io.EOF, or the
+// error set by Err.
+type Rows struct {
+	columns   []string
+	metadata  map[string]interface{}
+	summary   map[string]interface{}
+	rows      []row
+	err       error
+	closeErr  error
+	index     int
+	nextCalls int
+	closed    bool
+}
+
+type row struct {
+	data []interface{}
+	meta map[string]interface{}
+}
+
+// New creates Rows with the provided columns.
+func New(columns ...string) *Rows {
+	return &Rows{columns: columns, err: io.EOF}
+}
+
+// Row adds a row. Node, Relationship and Path builders are converted to the driver's graph values. It panics if the
+// number of values does not match the number of columns.
+func (r *Rows) Row(values ...interface{}) *Rows {
+	if len(r.columns) > 0 && len(values) != len(r.columns) {
+		panic(fmt.Sprintf("mockrows: row has %d values but there are %d columns", len(values), len(r.columns)))
+	}
+	data := make([]interface{}, len(values))
+	for i, v := range values {
+		data[i] = Value(v)
+	}
+	r.rows = append(r.rows, row{data: data})
+	return r
+}
+
+// RowMeta sets the metadata returned with the last row added.
+func (r *Rows) RowMeta(meta map[string]interface{}) *Rows {
+	if len(r.rows) == 0 {
+		panic("mockrows: RowMeta called before Row")
+	}
+	r.rows[len(r.rows)-1].meta = meta
+	return r
+}
+
+// Err sets the error returned once every row has been read, io.EOF by default.
+func (r *Rows) Err(err error) *Rows {
+	r.err = err
+	return r
+}
+
+// WithMetadata sets the metadata returned by Metadata.
+func (r *Rows) WithMetadata(metadata map[string]interface{}) *Rows {
+	r.metadata = metadata
+	return r
+}
+
+// WithSummary sets the metadata returned with io.EOF.
+func (r *Rows) WithSummary(summary map[string]interface{}) *Rows {
+	r.summary = summary
+	return r
+}
+
+// CloseErr sets the error returned by Close.
+func (r *Rows) CloseErr(err error) *Rows {
+	r.closeErr = err
+	return r
+}
+
+// Columns returns the columns the Rows were created with.
+func (r *Rows) Columns() []string {
+	return r.columns
+}
+
+// Metadata returns the metadata set by WithMetadata, or the fields of the rows.
+func (r *Rows) Metadata() map[string]interface{} {
+	if r.metadata != nil {
+		return r.metadata
+	}
+	fields := make([]interface{}, len(r.columns))
+	for i, c := range r.columns {
+		fields[i] = c
+	}
+	return map[string]interface{}{"fields": fields}
+}
+
+// Close marks the Rows closed and returns the error set by CloseErr.
+func (r *Rows) Close() error {
+	r.closed = true
+	return r.closeErr
+}
+
+// NextNeo returns the next row, then the error set by Err once the rows are exhausted.
+func (r *Rows) NextNeo() ([]interface{}, map[string]interface{}, error) {
+	r.nextCalls++
+	if r.index >= len(r.rows) {
+		if r.err == io.EOF {
+			return nil, r.summary, io.EOF
+		}
+		return nil, nil, r.err
+	}
+	next := r.rows[r.index]
+	r.index++
+	return next.data, next.meta, nil
+}
+
+// All returns the remaining rows.
+func (r *Rows) All() ([][]interface{}, map[string]interface{}, error) {
+	output := [][]interface{}{}
+	for {
+		data, meta, err := r.NextNeo()
+		if err == io.EOF {
+			return output, meta, nil
+		}
+		if err != nil {
+			return output, meta, err
+		}
+		output = append(output, data)
+	}
+}
+
+// Closed reports whether Close has been called.
+func (r *Rows) Closed() bool {
+	return r.closed
+}
+
+// NextCalls returns the number of times NextNeo has been called.
+func (r *Rows) NextCalls() int {
+	return r.nextCalls
+}
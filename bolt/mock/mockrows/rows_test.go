@@ -0,0 +1,61 @@
+package mockrows
+
+import (
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRows(t *testing.T) {
+	Convey("given rows with a node and a terminal error", t, func() {
+		errStream := errors.New("stream broken")
+		rows := New("c", "count").
+			Row(Node(1, "_code").Prop("value", "K02000001"), int64(2)).
+			RowMeta(map[string]interface{}{"key": "value"}).
+			Row("b", int64(3)).
+			Err(errStream)
+
+		Convey("when all the rows are read", func() {
+			data, _, err := rows.All()
+
+			Convey("then the builders are converted and the error returned last", func() {
+				So(err, ShouldEqual, errStream)
+				So(data, ShouldResemble, [][]interface{}{
+					{graph.Node{NodeIdentity: 1, Labels: []string{"_code"}, Properties: map[string]interface{}{"value": "K02000001"}}, int64(2)},
+					{"b", int64(3)},
+				})
+				So(rows.NextCalls(), ShouldEqual, 3)
+				So(rows.Metadata(), ShouldResemble, map[string]interface{}{"fields": []interface{}{"c", "count"}})
+			})
+		})
+	})
+
+	Convey("should return io.EOF with the summary by default", t, func() {
+		rows := New("a").WithSummary(map[string]interface{}{"type": "r"})
+		_, summary, err := rows.NextNeo()
+		So(err, ShouldEqual, io.EOF)
+		So(summary, ShouldResemble, map[string]interface{}{"type": "r"})
+		So(rows.Close(), ShouldBeNil)
+		So(rows.Closed(), ShouldBeTrue)
+	})
+}
+
+func TestPath(t *testing.T) {
+	Convey("should build the sequence of a path traversing relationships in both directions", t, func() {
+		a, b, c := Node(1, "_code"), Node(2, "_code_list"), Node(3, "_code")
+		p := Path(a).
+			Hop(Relationship(10, "usedBy", 1, 2), b).
+			Hop(Relationship(11, "usedBy", 3, 2), c).
+			Build()
+
+		So(p.Nodes, ShouldHaveLength, 3)
+		So(p.Relationships, ShouldResemble, []graph.UnboundRelationship{
+			{RelIdentity: 10, Type: "usedBy", Properties: map[string]interface{}{}},
+			{RelIdentity: 11, Type: "usedBy", Properties: map[string]interface{}{}},
+		})
+		So(p.Sequence, ShouldResemble, []int{1, 1, -2, 2})
+	})
+}
@@ -0,0 +1,29 @@
+package ogm
+
+import (
+	"reflect"
+
+	"github.com/ONSdigital/dp-bolt/bolt/internal/convert"
+	"github.com/pkg/errors"
+)
+
+// toNeo converts a field value to the value it is saved as, as bolt encodes Params values: in order of preference
+// using a Valuer such as bolt.NullString, a codec registered by bolt.RegisterCodec or encoding.TextMarshaler, and
+// otherwise as its underlying type, with times as RFC 3339 strings and slices as lists.
+func toNeo(v reflect.Value) (interface{}, error) {
+	if !v.IsValid() {
+		return nil, nil
+	}
+	e, _, err := convert.Encode(v.Interface())
+	return e, err
+}
+
+// fromNeo sets dst to a value received from the driver, converting it back to the type of the field as
+// bolt.Result.Scan does. Numbers are only converted when no precision is lost, and a null is an error for fields that
+// can not hold null unless they have a Scan method, such as bolt.NullString.
+func fromNeo(dst reflect.Value, val interface{}) error {
+	if err := convert.Assign(dst, val); err != nil {
+		return errors.WithMessage(err, "ogm")
+	}
+	return nil
+}
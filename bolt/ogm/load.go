@@ -0,0 +1,202 @@
+package ogm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	"github.com/ONSdigital/dp-bolt/bolt/graphval"
+	"github.com/pkg/errors"
+)
+
+// relTypes returns the relationship types that can be followed within depth steps of nodes mapped by e, and how many
+// steps can be taken before none of the entities reached have relations.
+func relTypes(e *entity, depth int) ([]string, int, error) {
+	seenTypes := map[string]bool{}
+	var types []string
+	current := []*entity{e}
+	steps := 0
+	for ; steps < depth; steps++ {
+		seenEntities := map[*entity]bool{}
+		var next []*entity
+		for _, ce := range current {
+			for _, r := range ce.rels {
+				te, err := r.entity()
+				if err != nil {
+					return nil, 0, err
+				}
+				if !seenTypes[r.relType] {
+					seenTypes[r.relType] = true
+					types = append(types, r.relType)
+				}
+				if !seenEntities[te] {
+					seenEntities[te] = true
+					next = append(next, te)
+				}
+			}
+		}
+		if len(next) == 0 {
+			break
+		}
+		current = next
+	}
+	sort.Strings(types)
+	return types, steps, nil
+}
+
+// treeQuery extends match, which binds the root nodes to n0, with a variable length match of up to depth
+// relationships of the types, returning one row per root with the paths from it collected. The server does not
+// follow a relationship twice on a path, so a relation mapped in both directions does not lead straight back to the
+// node it came from.
+//
+//	MATCH (n0:`CodeList` {`id`: $key})
+//	OPTIONAL MATCH p = (n0)-[:`HAS_CODE`|`PARENT_OF`*..2]-()
+//	RETURN n0, collect(p) AS paths
+func treeQuery(match string, types []string, depth int) string {
+	if depth == 0 {
+		return match + "\nRETURN n0"
+	}
+	escaped := make([]string, len(types))
+	for i, t := range types {
+		escaped[i] = bolt.EscapeIdentifier(t)
+	}
+	return fmt.Sprintf("%s\nOPTIONAL MATCH p = (n0)-[:%s*..%d]-()\nRETURN n0, collect(p) AS paths",
+		match, strings.Join(escaped, "|"), depth)
+}
+
+// subgraph collects the nodes and relationships returned by a tree query.
+type subgraph struct {
+	ctx      context.Context
+	roots    []int64
+	nodes    map[int64]graphval.Node
+	rels     map[int64]graphval.Relationship
+	adjacent map[int64][]int64
+}
+
+func newSubgraph(ctx context.Context) *subgraph {
+	return &subgraph{
+		ctx:      ctx,
+		nodes:    map[int64]graphval.Node{},
+		rels:     map[int64]graphval.Relationship{},
+		adjacent: map[int64][]int64{},
+	}
+}
+
+// add adds a root and the nodes and relationships of the paths from it returned by a tree query. Roots are kept in
+// the order they were returned. Reading stops with the error of the context once it is done.
+func (g *subgraph) add(r *bolt.Result) error {
+	if err := g.ctx.Err(); err != nil {
+		return err
+	}
+	root, err := graphval.NodeOf(r.Data[0])
+	if err != nil {
+		return errors.WithMessage(err, "ogm: error reading root")
+	}
+	g.nodes[root.ID] = root
+	g.roots = append(g.roots, root.ID)
+	if len(r.Data) < 2 {
+		return nil
+	}
+
+	paths, ok := r.Data[1].([]interface{})
+	if !ok {
+		return errors.Errorf("ogm: expected a list of paths, not %T", r.Data[1])
+	}
+	for _, v := range paths {
+		p, err := graphval.PathOf(v)
+		if err != nil {
+			return errors.WithMessage(err, "ogm: error reading relations of node "+strconv.FormatInt(root.ID, 10))
+		}
+		for _, n := range p.Nodes {
+			g.nodes[n.ID] = n
+		}
+		for _, rel := range p.Rels {
+			if _, ok := g.rels[rel.ID]; ok {
+				continue
+			}
+			g.rels[rel.ID] = rel
+			g.adjacent[rel.StartID] = append(g.adjacent[rel.StartID], rel.ID)
+			if rel.EndID != rel.StartID {
+				g.adjacent[rel.EndID] = append(g.adjacent[rel.EndID], rel.ID)
+			}
+		}
+	}
+	return nil
+}
+
+// walk is the path from a root to the node being built.
+type walk struct {
+	nodes map[int64]bool
+	rels  map[int64]bool
+}
+
+// build sets v from the node with the id, then follows its relations depth more steps. Fields of missing properties
+// are left unchanged, as graphval.Decode does. Related nodes are assigned in the order their relationships were
+// created. ErrCycle is returned if a node is reached again from itself.
+func (g *subgraph) build(v reflect.Value, e *entity, id int64, depth int, w walk) error {
+	n := g.nodes[id]
+	for _, f := range e.props {
+		val, ok := n.Props[f.prop]
+		if !ok {
+			continue
+		}
+		if err := fromNeo(f.value(v), val); err != nil {
+			return errors.WithMessage(err, "property "+f.prop)
+		}
+	}
+	if depth == 0 {
+		return nil
+	}
+
+	relIDs := append([]int64{}, g.adjacent[id]...)
+	sort.Slice(relIDs, func(i, j int) bool { return relIDs[i] < relIDs[j] })
+
+	w.nodes[id] = true
+	defer delete(w.nodes, id)
+	for _, rel := range e.rels {
+		te, err := rel.entity()
+		if err != nil {
+			return err
+		}
+
+		var targets []reflect.Value
+		for _, relID := range relIDs {
+			r := g.rels[relID]
+			if w.rels[relID] || r.Type != rel.relType {
+				continue
+			}
+			other := r.EndID
+			if !rel.outgoing {
+				if r.EndID != id {
+					continue
+				}
+				other = r.StartID
+			} else if r.StartID != id {
+				continue
+			}
+			if !g.nodes[other].HasLabel(te.label) {
+				continue
+			}
+			if w.nodes[other] {
+				return errors.WithMessage(ErrCycle, fmt.Sprintf("%s reaches node %d again", rel.name, other))
+			}
+
+			t := reflect.New(rel.target)
+			w.rels[relID] = true
+			err := g.build(t.Elem(), te, other, depth-1, w)
+			delete(w.rels, relID)
+			if err != nil {
+				return err
+			}
+			targets = append(targets, t)
+		}
+		if err := rel.set(v, targets); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,241 @@
+package ogm
+
+import (
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// Node declares a struct as a node. Embed it with the label as its tag:
+//
+//	type Dataset struct {
+//		ogm.Node `bolt:"Dataset"`
+//		ID       string `bolt:"id,key"`
+//		Title    string `bolt:"title"`
+//	}
+type Node struct{}
+
+var nodeType = reflect.TypeOf(Node{})
+
+// entity describes how a struct type maps to a node.
+type entity struct {
+	label string
+	key   *field
+	props []*field
+	rels  []*relation
+}
+
+// field maps a struct field to a node property.
+type field struct {
+	prop  string
+	index []int
+}
+
+func (f *field) value(v reflect.Value) reflect.Value {
+	return v.FieldByIndex(f.index)
+}
+
+// relation maps a pointer or slice field to the relationships of a node and the nodes at their other end.
+type relation struct {
+	name     string
+	relType  string
+	outgoing bool
+	index    []int
+	many     bool
+	ptrElem  bool
+	target   reflect.Type
+}
+
+// entity returns how the nodes at the other end of the relationships are mapped. It is looked up when first needed
+// rather than when the relation is parsed, so a struct may have relations to its own type.
+func (r *relation) entity() (*entity, error) {
+	return entityOf(r.target)
+}
+
+// targets returns the structs the field of v refers to, skipping nil pointers.
+func (r *relation) targets(v reflect.Value) []reflect.Value {
+	f := v.FieldByIndex(r.index)
+	if !r.many {
+		if f.IsNil() {
+			return nil
+		}
+		return []reflect.Value{f.Elem()}
+	}
+
+	var targets []reflect.Value
+	for i := 0; i < f.Len(); i++ {
+		t := f.Index(i)
+		if r.ptrElem {
+			if t.IsNil() {
+				continue
+			}
+			t = t.Elem()
+		}
+		targets = append(targets, t)
+	}
+	return targets
+}
+
+// set sets the field of v to the targets, each a pointer to a struct of the target type.
+func (r *relation) set(v reflect.Value, targets []reflect.Value) error {
+	f := v.FieldByIndex(r.index)
+	if !r.many {
+		switch len(targets) {
+		case 0:
+			f.Set(reflect.Zero(f.Type()))
+		case 1:
+			f.Set(targets[0])
+		default:
+			return errors.Errorf("ogm: %s expects one %s relationship but found %d", r.name, r.relType, len(targets))
+		}
+		return nil
+	}
+
+	if len(targets) == 0 {
+		f.Set(reflect.Zero(f.Type()))
+		return nil
+	}
+	list := reflect.MakeSlice(f.Type(), 0, len(targets))
+	for _, t := range targets {
+		if !r.ptrElem {
+			t = t.Elem()
+		}
+		list = reflect.Append(list, t)
+	}
+	f.Set(list)
+	return nil
+}
+
+func (e *entity) prop(name string) *field {
+	for _, f := range e.props {
+		if f.prop == name {
+			return f
+		}
+	}
+	return nil
+}
+
+var (
+	entitiesMutex sync.RWMutex
+	entities      = map[reflect.Type]*entity{}
+)
+
+// entityOf returns the entity of a struct type, parsing and caching its tags the first time it is seen.
+func entityOf(t reflect.Type) (*entity, error) {
+	entitiesMutex.RLock()
+	e, ok := entities[t]
+	entitiesMutex.RUnlock()
+	if ok {
+		return e, nil
+	}
+
+	e, err := parseEntity(t)
+	if err != nil {
+		return nil, err
+	}
+	entitiesMutex.Lock()
+	entities[t] = e
+	entitiesMutex.Unlock()
+	return e, nil
+}
+
+func parseEntity(t reflect.Type) (*entity, error) {
+	if t.Kind() != reflect.Struct {
+		return nil, errors.Errorf("ogm: %s is not a struct", t)
+	}
+
+	e := &entity{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		tag, tagged := sf.Tag.Lookup("bolt")
+		if sf.Type == nodeType {
+			e.label = tag
+			continue
+		}
+		if !tagged || tag == "-" || sf.PkgPath != "" {
+			continue
+		}
+
+		parts := strings.Split(tag, ",")
+		if isRelation(parts) {
+			r, err := parseRelation(t, sf, parts)
+			if err != nil {
+				return nil, err
+			}
+			e.rels = append(e.rels, r)
+			continue
+		}
+
+		f := &field{prop: parts[0], index: sf.Index}
+		if f.prop == "" {
+			f.prop = sf.Name
+		}
+		if e.prop(f.prop) != nil {
+			return nil, errors.Errorf("ogm: %s maps property %q more than once", t, f.prop)
+		}
+		for _, opt := range parts[1:] {
+			if opt != "key" {
+				return nil, errors.Errorf("ogm: unknown option %q on %s.%s", opt, t, sf.Name)
+			}
+			if e.key != nil {
+				return nil, errors.Errorf("ogm: %s has more than one key", t)
+			}
+			e.key = f
+		}
+		e.props = append(e.props, f)
+	}
+
+	if e.label == "" {
+		return nil, errors.Errorf("ogm: %s does not embed ogm.Node with a label", t)
+	}
+	if e.key == nil {
+		return nil, errors.Errorf("ogm: %s has no key property", t)
+	}
+	return e, nil
+}
+
+func isRelation(opts []string) bool {
+	for _, opt := range opts {
+		if strings.HasPrefix(opt, "rel=") {
+			return true
+		}
+	}
+	return false
+}
+
+// parseRelation parses a tag such as "rel=HAS_CODE,dir=out" on a field that is a pointer to a struct, or a slice of
+// structs or struct pointers. The direction defaults to out.
+func parseRelation(t reflect.Type, sf reflect.StructField, opts []string) (*relation, error) {
+	r := &relation{name: t.Name() + "." + sf.Name, index: sf.Index, outgoing: true}
+	for _, opt := range opts {
+		kv := strings.SplitN(opt, "=", 2)
+		switch {
+		case len(kv) == 2 && kv[0] == "rel":
+			r.relType = kv[1]
+		case len(kv) == 2 && kv[0] == "dir" && (kv[1] == "out" || kv[1] == "in"):
+			r.outgoing = kv[1] == "out"
+		default:
+			return nil, errors.Errorf("ogm: unknown option %q on %s", opt, r.name)
+		}
+	}
+	if r.relType == "" {
+		return nil, errors.Errorf("ogm: %s has no relationship type", r.name)
+	}
+
+	ft := sf.Type
+	if ft.Kind() == reflect.Slice {
+		r.many = true
+		ft = ft.Elem()
+	}
+	if ft.Kind() == reflect.Ptr {
+		r.ptrElem = true
+		ft = ft.Elem()
+	}
+	if ft.Kind() != reflect.Struct || (!r.many && !r.ptrElem) {
+		return nil, errors.Errorf("ogm: %s must be a pointer to a struct or a slice of structs", r.name)
+	}
+	r.target = ft
+	return r, nil
+}
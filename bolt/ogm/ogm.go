@@ -0,0 +1,269 @@
+// Package ogm maps Go structs to Neo4j nodes, generating the parameterised statements to save, load, delete and find
+// them through a bolt.Conn.
+//
+// A struct is mapped by embedding Node with its label as the tag, and tagging each field stored as a property with
+// the property name. Exactly one property is marked as the key identifying the node:
+//
+//	type Dataset struct {
+//		ogm.Node `bolt:"Dataset"`
+//		ID       string    `bolt:"id,key"`
+//		Title    string    `bolt:"title"`
+//		Released time.Time `bolt:"released"`
+//		Internal string    `bolt:"-"`
+//	}
+//
+// Fields without a tag are not mapped. Integers are stored as int64, floats as float64, times as RFC 3339 strings and
+// slices as lists, and are converted back to the type of the field when loaded.
+//
+// A field that is a pointer to a mapped struct, or a slice of them, may instead be declared as a relation with the
+// relationship type and direction, which defaults to out:
+//
+//	type CodeList struct {
+//		ogm.Node `bolt:"CodeList"`
+//		ID       string  `bolt:"id,key"`
+//		Codes    []*Code `bolt:"rel=HAS_CODE,dir=out"`
+//	}
+//
+// Relations are followed to the depth given by WithDepth, both when loading and when saving. Related nodes are
+// loaded in a single query, one row per root node with the paths from it, and assembled into the struct tree. Saving
+// replaces the relationships of each node within the depth with those of its relation fields, so a tree should be
+// saved with the depth it was loaded with. The relationships are deleted and created again in the order of the field,
+// as that is the order they are loaded in, so saving costs a write per relationship even when none have changed. A
+// node that is reached again from itself through its relations is reported as ErrCycle.
+//
+// The context given to each method is checked before every statement it runs and between the rows it reads. A
+// statement that is already running is not interrupted.
+package ogm
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrNotFound = errors.New("ogm: node not found")
+	ErrCycle    = errors.New("ogm: relations form a cycle")
+)
+
+// Filter matches nodes whose properties equal the values given, keyed by property name.
+type Filter map[string]interface{}
+
+// Mapper saves and loads tagged structs as nodes. Because it runs statements through a bolt.Conn, a Mapper created
+// from a Tx works within the transaction.
+type Mapper struct {
+	conn  bolt.Conn
+	depth int
+}
+
+// Option configures a Mapper.
+type Option func(m *Mapper)
+
+// WithDepth sets how many relationships away from an entity its relations are loaded and saved. The default of 0
+// loads and saves only the properties of the entity itself.
+func WithDepth(depth int) Option {
+	return func(m *Mapper) {
+		m.depth = depth
+	}
+}
+
+// New returns a Mapper running statements through conn, which is usually a *bolt.DB or *bolt.Tx.
+func New(conn bolt.Conn, opts ...Option) *Mapper {
+	m := &Mapper{conn: conn}
+	for _, opt := range opts {
+		opt(m)
+	}
+	return m
+}
+
+// Save creates the node for entity, or updates the properties of the node with the same key. Relations are saved to
+// the depth of the mapper, within a transaction unless the mapper was created from a Tx.
+func (m *Mapper) Save(ctx context.Context, entity interface{}) error {
+	v, e, err := structOf(entity)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	return m.inTx(func(conn bolt.Conn) error {
+		s := &saver{ctx: ctx, conn: conn, depth: m.depth, saved: map[string]int{}, onPath: map[string]bool{}}
+		return s.save(v, e, 0, nil)
+	})
+}
+
+// Load sets the fields of entity, which must be a pointer to a struct, from the node with the key and its relations
+// to the depth of the mapper. ErrNotFound is returned if there is no such node.
+func (m *Mapper) Load(ctx context.Context, entity interface{}, key interface{}) error {
+	v, e, err := structOf(entity)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	types, depth, err := relTypes(e, m.depth)
+	if err != nil {
+		return err
+	}
+
+	query := treeQuery(fmt.Sprintf("MATCH (n0:%s {%s: $key})",
+		bolt.EscapeIdentifier(e.label), bolt.EscapeIdentifier(e.key.prop)), types, depth)
+	g := newSubgraph(ctx)
+	err = m.conn.QueryForResults(query, map[string]interface{}{"key": key}, g.add)
+	if err == bolt.ErrNoResults {
+		return ErrNotFound
+	}
+	if err != nil {
+		return errors.WithMessage(err, "ogm: error loading "+e.label)
+	}
+	return g.build(v, e, g.roots[0], depth, walk{nodes: map[int64]bool{}, rels: map[int64]bool{}})
+}
+
+// Delete deletes the node with the key of entity along with its relationships. ErrNotFound is returned if there is
+// no such node.
+func (m *Mapper) Delete(ctx context.Context, entity interface{}) error {
+	v, e, err := structOf(entity)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	key, err := toNeo(e.key.value(v))
+	if err != nil {
+		return errors.WithMessage(err, "ogm: error encoding the key of "+e.label)
+	}
+	query := fmt.Sprintf("MATCH (n:%s {%s: $key})\nDETACH DELETE n",
+		bolt.EscapeIdentifier(e.label), bolt.EscapeIdentifier(e.key.prop))
+	deleted, _, err := m.conn.Exec(bolt.Stmt{Query: query, Params: bolt.Params{"key": key}})
+	if err != nil {
+		return errors.WithMessage(err, "ogm: error deleting "+e.label)
+	}
+	if deleted == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Find sets dest, which must be a pointer to a slice of structs or struct pointers, to the nodes matching the filter
+// ordered by key, along with their relations to the depth of the mapper. A nil filter matches every node with the
+// label, and no match leaves dest empty.
+func (m *Mapper) Find(ctx context.Context, dest interface{}, filter Filter) error {
+	slice := reflect.ValueOf(dest)
+	if slice.Kind() != reflect.Ptr || slice.IsNil() || slice.Elem().Kind() != reflect.Slice {
+		return errors.Errorf("ogm: Find requires a pointer to a slice, not %T", dest)
+	}
+	slice = slice.Elem()
+	elemType := slice.Type().Elem()
+	isPtr := elemType.Kind() == reflect.Ptr
+	if isPtr {
+		elemType = elemType.Elem()
+	}
+	e, err := entityOf(elemType)
+	if err != nil {
+		return err
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	types, depth, err := relTypes(e, m.depth)
+	if err != nil {
+		return err
+	}
+
+	where, params, err := e.whereClause(filter)
+	if err != nil {
+		return err
+	}
+	query := treeQuery(fmt.Sprintf("MATCH (n0:%s)%s", bolt.EscapeIdentifier(e.label), where), types, depth) +
+		"\nORDER BY n0." + bolt.EscapeIdentifier(e.key.prop)
+	g := newSubgraph(ctx)
+	err = m.conn.QueryForResults(query, params, g.add)
+	if err != nil && err != bolt.ErrNoResults {
+		return errors.WithMessage(err, "ogm: error finding "+e.label)
+	}
+
+	found := reflect.MakeSlice(slice.Type(), 0, len(g.roots))
+	for _, id := range g.roots {
+		elem := reflect.New(elemType)
+		w := walk{nodes: map[int64]bool{}, rels: map[int64]bool{}}
+		if err := g.build(elem.Elem(), e, id, depth, w); err != nil {
+			return err
+		}
+		if !isPtr {
+			elem = elem.Elem()
+		}
+		found = reflect.Append(found, elem)
+	}
+	slice.Set(found)
+	return nil
+}
+
+// inTx runs f in a transaction when relations are saved through a DB, so a tree of entities is saved atomically.
+func (m *Mapper) inTx(f func(conn bolt.Conn) error) error {
+	db, ok := m.conn.(interface {
+		Begin() (*bolt.Tx, error)
+	})
+	if !ok || m.depth == 0 {
+		return f(m.conn)
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := f(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit()
+}
+
+// structOf returns the struct entity points to and how it is mapped.
+func structOf(entity interface{}) (reflect.Value, *entity, error) {
+	v := reflect.ValueOf(entity)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return reflect.Value{}, nil, errors.Errorf("ogm: a pointer to a struct is required, not %T", entity)
+	}
+	v = v.Elem()
+	e, err := entityOf(v.Type())
+	return v, e, err
+}
+
+// whereClause matches each filtered property against a parameter. Properties are sorted so the same filter always
+// builds the same statement.
+func (e *entity) whereClause(filter Filter) (string, bolt.Params, error) {
+	params := bolt.Params{}
+	if len(filter) == 0 {
+		return "", params, nil
+	}
+
+	names := make([]string, 0, len(filter))
+	for name := range filter {
+		if e.prop(name) == nil {
+			return "", nil, errors.Errorf("ogm: %s has no property %q", e.label, name)
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	conds := make([]string, len(names))
+	for i, name := range names {
+		param := fmt.Sprintf("f_%d", i)
+		conds[i] = fmt.Sprintf("n0.%s = $%s", bolt.EscapeIdentifier(name), param)
+		value, err := toNeo(reflect.ValueOf(filter[name]))
+		if err != nil {
+			return "", nil, errors.WithMessage(err, "ogm: error encoding filter "+name)
+		}
+		params[param] = value
+	}
+	return "\nWHERE " + strings.Join(conds, " AND "), params, nil
+}
@@ -0,0 +1,396 @@
+package ogm
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	"github.com/ONSdigital/dp-bolt/boltmem"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+// status is stored by name using a codec, as enums from other packages are.
+type status int
+
+const (
+	statusDraft status = iota
+	statusPublished
+)
+
+func init() {
+	bolt.RegisterCodec(func(s status) (interface{}, error) {
+		return [...]string{"draft", "published"}[s], nil
+	}, func(src interface{}) (status, error) {
+		switch src {
+		case "draft":
+			return statusDraft, nil
+		case "published":
+			return statusPublished, nil
+		}
+		return 0, fmt.Errorf("unknown status %v", src)
+	})
+}
+
+type dataset struct {
+	Node     `bolt:"Dataset"`
+	ID       string           `bolt:"id,key"`
+	Title    string           `bolt:"title"`
+	Editions int              `bolt:"editions"`
+	Keywords []string         `bolt:"keywords"`
+	Released time.Time        `bolt:"released"`
+	Score    *float32         `bolt:"score"`
+	Unit     bolt.NullString  `bolt:"unit"`
+	Base     bolt.Null[int32] `bolt:"base"`
+	State    status           `bolt:"state"`
+	Internal string           `bolt:"-"`
+	Ignored  string
+}
+
+func TestMapper(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("given a mapper over an empty graph", t, func() {
+		db := bolt.New(boltmem.NewPool())
+		m := New(db)
+		released := time.Date(2018, 5, 1, 9, 30, 0, 0, time.UTC)
+		score := float32(1.5)
+		d := dataset{ID: "cpih01", Title: "CPIH", Editions: 2, Keywords: []string{"prices"}, Released: released,
+			Score: &score, Unit: bolt.NullString{String: "index", Valid: true}, Internal: "secret", Ignored: "x"}
+
+		Convey("when an entity is saved and loaded", func() {
+			So(m.Save(ctx, &d), ShouldBeNil)
+			var loaded dataset
+			err := m.Load(ctx, &loaded, "cpih01")
+
+			Convey("then every mapped field round trips", func() {
+				So(err, ShouldBeNil)
+				So(loaded.ID, ShouldEqual, "cpih01")
+				So(loaded.Title, ShouldEqual, "CPIH")
+				So(loaded.Editions, ShouldEqual, 2)
+				So(loaded.Keywords, ShouldResemble, []string{"prices"})
+				So(loaded.Released.Equal(released), ShouldBeTrue)
+				So(*loaded.Score, ShouldEqual, 1.5)
+				So(loaded.Unit, ShouldResemble, bolt.NullString{String: "index", Valid: true})
+				So(loaded.Base.Valid, ShouldBeFalse)
+				So(loaded.Internal, ShouldBeEmpty)
+				So(loaded.Ignored, ShouldBeEmpty)
+			})
+		})
+
+		Convey("when an entity is saved again with changes", func() {
+			So(m.Save(ctx, &d), ShouldBeNil)
+			d.Title = "Consumer prices"
+			d.Score = nil
+			So(m.Save(ctx, &d), ShouldBeNil)
+
+			Convey("then the existing node is updated", func() {
+				var found []dataset
+				So(m.Find(ctx, &found, nil), ShouldBeNil)
+				So(found, ShouldHaveLength, 1)
+				So(found[0].Title, ShouldEqual, "Consumer prices")
+				So(found[0].Score, ShouldBeNil)
+			})
+		})
+
+		Convey("when an entity with a field of a type with a codec is saved", func() {
+			d.State = statusPublished
+			So(m.Save(ctx, &d), ShouldBeNil)
+			var stored string
+			err := db.QueryForResult("MATCH (d:Dataset) RETURN d.state", nil, func(r *bolt.Result) error {
+				return r.Scan(&stored)
+			})
+			So(err, ShouldBeNil)
+			var loaded dataset
+			loadErr := m.Load(ctx, &loaded, "cpih01")
+			var found []dataset
+			findErr := m.Find(ctx, &found, Filter{"state": statusPublished})
+
+			Convey("then it is stored as the codec encodes it and decoded back when loaded", func() {
+				So(stored, ShouldEqual, "published")
+				So(loadErr, ShouldBeNil)
+				So(loaded.State, ShouldEqual, statusPublished)
+				So(findErr, ShouldBeNil)
+				So(found, ShouldHaveLength, 1)
+			})
+		})
+
+		Convey("when entities are found with a filter", func() {
+			for _, id := range []string{"c", "a", "b"} {
+				So(m.Save(ctx, &dataset{ID: id, Title: "match"}), ShouldBeNil)
+			}
+			So(m.Save(ctx, &dataset{ID: "d", Title: "other"}), ShouldBeNil)
+			var found []*dataset
+			err := m.Find(ctx, &found, Filter{"title": "match"})
+
+			Convey("then the matching entities are returned ordered by key", func() {
+				So(err, ShouldBeNil)
+				So(found, ShouldHaveLength, 3)
+				So(found[0].ID, ShouldEqual, "a")
+				So(found[1].ID, ShouldEqual, "b")
+				So(found[2].ID, ShouldEqual, "c")
+			})
+		})
+
+		Convey("when an entity is deleted", func() {
+			So(m.Save(ctx, &d), ShouldBeNil)
+			So(m.Delete(ctx, &d), ShouldBeNil)
+
+			Convey("then it can no longer be loaded or deleted", func() {
+				So(m.Load(ctx, &dataset{}, "cpih01"), ShouldEqual, ErrNotFound)
+				So(m.Delete(ctx, &d), ShouldEqual, ErrNotFound)
+			})
+		})
+
+		Convey("when the filter names an unmapped property", func() {
+			var found []dataset
+			err := m.Find(ctx, &found, Filter{"internal": "secret"})
+
+			Convey("then an error is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, `no property "internal"`)
+			})
+		})
+
+		Convey("when the context is already cancelled", func() {
+			cancelled, cancel := context.WithCancel(ctx)
+			cancel()
+
+			Convey("then nothing is run", func() {
+				So(m.Save(cancelled, &d), ShouldEqual, context.Canceled)
+				So(m.Load(ctx, &dataset{}, "cpih01"), ShouldEqual, ErrNotFound)
+			})
+		})
+	})
+}
+
+func TestEntityOf(t *testing.T) {
+	Convey("given structs that can not be mapped", t, func() {
+		type noLabel struct {
+			ID string `bolt:"id,key"`
+		}
+		type noKey struct {
+			Node `bolt:"Thing"`
+			ID   string `bolt:"id"`
+		}
+		type twoKeys struct {
+			Node `bolt:"Thing"`
+			A    string `bolt:"a,key"`
+			B    string `bolt:"b,key"`
+		}
+		type duplicate struct {
+			Node `bolt:"Thing"`
+			A    string `bolt:"a,key"`
+			B    string `bolt:"a"`
+		}
+
+		Convey("then each reports why", func() {
+			m := New(bolt.New(boltmem.NewPool()))
+			ctx := context.Background()
+			So(m.Save(ctx, &noLabel{}).Error(), ShouldContainSubstring, "does not embed ogm.Node")
+			So(m.Save(ctx, &noKey{}).Error(), ShouldContainSubstring, "has no key property")
+			So(m.Save(ctx, &twoKeys{}).Error(), ShouldContainSubstring, "more than one key")
+			So(m.Save(ctx, &duplicate{}).Error(), ShouldContainSubstring, "more than once")
+			So(m.Save(ctx, noKey{}).Error(), ShouldContainSubstring, "pointer to a struct")
+		})
+	})
+}
+
+type codeList struct {
+	Node  `bolt:"CodeList"`
+	ID    string  `bolt:"id,key"`
+	Codes []*code `bolt:"rel=HAS_CODE,dir=out"`
+}
+
+type code struct {
+	Node     `bolt:"Code"`
+	ID       string `bolt:"id,key"`
+	Label    string `bolt:"label"`
+	Parent   *code  `bolt:"rel=PARENT_OF,dir=in"`
+	Children []code `bolt:"rel=PARENT_OF"`
+}
+
+func TestMapperRelations(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("given a code list saved with a hierarchy of codes", t, func() {
+		db := bolt.New(boltmem.NewPool())
+		list := codeList{ID: "cpih1dim1aggid", Codes: []*code{
+			{ID: "cpih1dim1A0", Label: "Overall Index", Children: []code{
+				{ID: "cpih1dim1G10", Label: "Food"},
+				{ID: "cpih1dim1G20", Label: "Alcohol"},
+			}},
+			{ID: "cpih1dim1S10", Label: "Services"},
+		}}
+		So(New(db, WithDepth(3)).Save(ctx, &list), ShouldBeNil)
+
+		Convey("when it is loaded to a depth of two", func() {
+			var loaded codeList
+			err := New(db, WithDepth(2)).Load(ctx, &loaded, "cpih1dim1aggid")
+
+			Convey("then the codes and their children are assembled in order", func() {
+				So(err, ShouldBeNil)
+				So(loaded.Codes, ShouldHaveLength, 2)
+				So(loaded.Codes[0].Label, ShouldEqual, "Overall Index")
+				So(loaded.Codes[0].Children, ShouldHaveLength, 2)
+				So(loaded.Codes[0].Children[0].Label, ShouldEqual, "Food")
+				So(loaded.Codes[0].Children[1].Label, ShouldEqual, "Alcohol")
+				So(loaded.Codes[1].Label, ShouldEqual, "Services")
+				So(loaded.Codes[1].Children, ShouldBeEmpty)
+			})
+
+			Convey("then the relationship followed is not followed back", func() {
+				So(loaded.Codes[0].Children[0].Parent, ShouldBeNil)
+			})
+		})
+
+		Convey("when a code is loaded with its parent", func() {
+			var food code
+			err := New(db, WithDepth(1)).Load(ctx, &food, "cpih1dim1G10")
+
+			Convey("then the incoming relationship is followed", func() {
+				So(err, ShouldBeNil)
+				So(food.Parent, ShouldNotBeNil)
+				So(food.Parent.Label, ShouldEqual, "Overall Index")
+				So(food.Parent.Children, ShouldBeNil)
+			})
+		})
+
+		Convey("when it is loaded without a depth", func() {
+			var loaded codeList
+			err := New(db).Load(ctx, &loaded, "cpih1dim1aggid")
+
+			Convey("then no relations are loaded", func() {
+				So(err, ShouldBeNil)
+				So(loaded.Codes, ShouldBeNil)
+			})
+		})
+
+		Convey("when it is saved again with different codes", func() {
+			list.Codes = list.Codes[1:]
+			So(New(db, WithDepth(1)).Save(ctx, &list), ShouldBeNil)
+
+			Convey("then the relationships are replaced but those beyond the depth are kept", func() {
+				var found []codeList
+				So(New(db, WithDepth(1)).Find(ctx, &found, nil), ShouldBeNil)
+				So(found, ShouldHaveLength, 1)
+				So(found[0].Codes, ShouldHaveLength, 1)
+				So(found[0].Codes[0].ID, ShouldEqual, "cpih1dim1S10")
+
+				var overall code
+				So(New(db, WithDepth(1)).Load(ctx, &overall, "cpih1dim1A0"), ShouldBeNil)
+				So(overall.Children, ShouldHaveLength, 2)
+			})
+		})
+	})
+
+	Convey("given a code list with codes and a context cancelled once the first statement has run", t, func() {
+		db := bolt.New(boltmem.NewPool())
+		cancelled, cancel := context.WithCancel(ctx)
+		defer cancel()
+		conn := &cancellingConn{Conn: db, cancel: cancel}
+		list := codeList{ID: "cpih1dim1aggid", Codes: []*code{{ID: "cpih1dim1A0"}, {ID: "cpih1dim1S10"}}}
+
+		Convey("when it is saved", func() {
+			err := New(conn, WithDepth(1)).Save(cancelled, &list)
+
+			Convey("then no further statements are run", func() {
+				So(err, ShouldEqual, context.Canceled)
+				So(conn.execs, ShouldEqual, 1)
+			})
+		})
+	})
+
+	Convey("given codes whose relations form a cycle", t, func() {
+		db := bolt.New(boltmem.NewPool())
+		a := &code{ID: "a"}
+		b := &code{ID: "b", Parent: a}
+		a.Parent = b
+
+		Convey("when they are saved", func() {
+			err := New(db, WithDepth(3)).Save(ctx, a)
+
+			Convey("then the cycle is reported and nothing is written", func() {
+				So(errors.Cause(err), ShouldEqual, ErrCycle)
+				var found []code
+				So(New(db).Find(ctx, &found, nil), ShouldBeNil)
+				So(found, ShouldBeEmpty)
+			})
+		})
+
+		Convey("when they are loaded", func() {
+			_, _, err := db.Exec(bolt.Stmt{Query: "CREATE (a:Code {id: 'a', label: 'A'})-[:PARENT_OF]->" +
+				"(b:Code {id: 'b', label: 'B'})-[:PARENT_OF]->(a)"})
+			So(err, ShouldBeNil)
+			var loaded code
+			err = New(db, WithDepth(3)).Load(ctx, &loaded, "a")
+
+			Convey("then the cycle is reported", func() {
+				So(errors.Cause(err), ShouldEqual, ErrCycle)
+			})
+		})
+	})
+}
+
+// cancellingConn cancels a context once a statement has been run through it.
+type cancellingConn struct {
+	bolt.Conn
+	cancel func()
+	execs  int
+}
+
+func (c *cancellingConn) Exec(stmt bolt.Stmt) (int64, map[string]interface{}, error) {
+	c.execs++
+	defer c.cancel()
+	return c.Conn.Exec(stmt)
+}
+
+type measure struct {
+	Node   `bolt:"Measure"`
+	ID     string `bolt:"id,key"`
+	Digits int8   `bolt:"digits"`
+	Count  int    `bolt:"count"`
+}
+
+func TestMapperConversion(t *testing.T) {
+	ctx := context.Background()
+
+	Convey("given nodes whose properties may not fit the fields they are loaded into", t, func() {
+		db := bolt.New(boltmem.NewPool())
+		_, _, err := db.Exec(bolt.Stmt{Query: "CREATE (:Measure {id: 'fits', digits: 12, count: 3.0}), " +
+			"(:Measure {id: 'overflow', digits: 300, count: 1}), (:Measure {id: 'fractional', digits: 1, count: 2.7}), " +
+			"(:Measure {id: 'missing', digits: 1})"})
+		So(err, ShouldBeNil)
+		m := New(db)
+
+		Convey("when they are loaded", func() {
+			var fits, overflow, fractional measure
+			missing := measure{Count: 7}
+			fitsErr := m.Load(ctx, &fits, "fits")
+			overflowErr := m.Load(ctx, &overflow, "overflow")
+			fractionalErr := m.Load(ctx, &fractional, "fractional")
+			missingErr := m.Load(ctx, &missing, "missing")
+
+			Convey("then numbers are converted when no precision is lost", func() {
+				So(fitsErr, ShouldBeNil)
+				So(fits.Digits, ShouldEqual, 12)
+				So(fits.Count, ShouldEqual, 3)
+			})
+
+			Convey("then a number that overflows or would be truncated is an error", func() {
+				So(overflowErr, ShouldNotBeNil)
+				So(overflowErr.Error(), ShouldContainSubstring, "property digits: ogm: can not convert int64 300 to int8")
+				So(fractionalErr, ShouldNotBeNil)
+				So(fractionalErr.Error(), ShouldContainSubstring, "property count: ogm: can not convert float64 2.7 to int")
+			})
+
+			Convey("then the field of a missing property is left unchanged", func() {
+				So(missingErr, ShouldBeNil)
+				So(missing.Digits, ShouldEqual, 1)
+				So(missing.Count, ShouldEqual, 7)
+			})
+		})
+	})
+}
@@ -0,0 +1,131 @@
+package ogm
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	"github.com/pkg/errors"
+)
+
+// saver writes a tree of entities. Each node is written once, and the relationships of the nodes within depth steps
+// of the root are replaced by those of their relation fields.
+type saver struct {
+	ctx   context.Context
+	conn  bolt.Conn
+	depth int
+	// saved holds the level each node was written at, so a node reached again closer to the root is revisited to
+	// replace its relationships.
+	saved  map[string]int
+	onPath map[string]bool
+}
+
+// step is the relationship followed to reach a node, as seen from the node it was followed from.
+type step struct {
+	from     string
+	relType  string
+	outgoing bool
+}
+
+func (s *saver) save(v reflect.Value, e *entity, level int, via *step) error {
+	key, err := toNeo(e.key.value(v))
+	if err != nil {
+		return errors.WithMessage(err, "ogm: error encoding the key of "+e.label)
+	}
+	id := identity(e, key)
+	if l, ok := s.saved[id]; ok && l <= level {
+		return nil
+	}
+	s.saved[id] = level
+
+	props := make(map[string]interface{}, len(e.props))
+	for _, f := range e.props {
+		if f == e.key {
+			continue
+		}
+		if props[f.prop], err = toNeo(f.value(v)); err != nil {
+			return errors.WithMessage(err, "ogm: error encoding "+e.label+" property "+f.prop)
+		}
+	}
+	keyProps := map[string]interface{}{e.key.prop: key}
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	if _, err := bolt.UpsertNode(e.label, keyProps, props, props).Exec(s.conn); err != nil {
+		return errors.WithMessage(err, "ogm: error saving "+e.label)
+	}
+	if level >= s.depth {
+		return nil
+	}
+
+	s.onPath[id] = true
+	defer delete(s.onPath, id)
+	for _, rel := range e.rels {
+		te, err := rel.entity()
+		if err != nil {
+			return err
+		}
+
+		targets := rel.targets(v)
+		keys := make([]interface{}, len(targets))
+		for i, t := range targets {
+			if keys[i], err = toNeo(te.key.value(t)); err != nil {
+				return errors.WithMessage(err, "ogm: error encoding the key of "+te.label)
+			}
+			tid := identity(te, keys[i])
+			if via != nil && tid == via.from && rel.relType == via.relType && rel.outgoing != via.outgoing {
+				// the relationship this node was reached through, seen from this end
+				continue
+			}
+			if s.onPath[tid] {
+				return errors.WithMessage(ErrCycle, fmt.Sprintf("%s reaches %s again", rel.name, tid))
+			}
+			if err := s.save(t, te, level+1, &step{from: id, relType: rel.relType, outgoing: rel.outgoing}); err != nil {
+				return err
+			}
+		}
+
+		stmt := replaceRelsStmt(e, rel, te, key, keys)
+		if err := s.ctx.Err(); err != nil {
+			return err
+		}
+		if _, _, err := s.conn.Exec(stmt); err != nil {
+			return errors.WithMessage(err, "ogm: error saving "+rel.name)
+		}
+	}
+	return nil
+}
+
+// replaceRelsStmt builds a statement deleting the relationships of a relation from the node with the key, then
+// creating one to each of the target nodes in order. The relationships are not compared with those already there:
+// keeping some would leave them out of the order of the field, which is the order of their ids when loaded.
+//
+//	MATCH (a:`CodeList` {`id`: $key})
+//	OPTIONAL MATCH (a)-[r:`HAS_CODE`]->(:`Code`)
+//	DELETE r
+//	WITH DISTINCT a
+//	UNWIND $targets AS target
+//	MATCH (b:`Code` {`id`: target})
+//	CREATE (a)-[:`HAS_CODE`]->(b)
+func replaceRelsStmt(e *entity, rel *relation, te *entity, key interface{}, targets []interface{}) bolt.Stmt {
+	left, right := "-", "->"
+	if !rel.outgoing {
+		left, right = "<-", "-"
+	}
+	relType := bolt.EscapeIdentifier(rel.relType)
+
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "MATCH (a:%s {%s: $key})\n", bolt.EscapeIdentifier(e.label), bolt.EscapeIdentifier(e.key.prop))
+	fmt.Fprintf(&b, "OPTIONAL MATCH (a)%s[r:%s]%s(:%s)\n", left, relType, right, bolt.EscapeIdentifier(te.label))
+	b.WriteString("DELETE r\nWITH DISTINCT a\nUNWIND $targets AS target\n")
+	fmt.Fprintf(&b, "MATCH (b:%s {%s: target})\n", bolt.EscapeIdentifier(te.label), bolt.EscapeIdentifier(te.key.prop))
+	fmt.Fprintf(&b, "CREATE (a)%s[:%s]%s(b)", left, relType, right)
+	return bolt.Stmt{Query: b.String(), Params: bolt.Params{"key": key, "targets": targets}}
+}
+
+// identity identifies the node a struct is saved as from its encoded key.
+func identity(e *entity, key interface{}) string {
+	return fmt.Sprintf("%s %#v", e.label, key)
+}
@@ -0,0 +1,163 @@
+package boltmem
+
+type expr interface{}
+
+type (
+	literal struct {
+		value interface{}
+	}
+
+	param struct {
+		name string
+	}
+
+	variable struct {
+		name string
+	}
+
+	property struct {
+		subject expr
+		key     string
+	}
+
+	index struct {
+		subject expr
+		index   expr
+	}
+
+	listLiteral struct {
+		items []expr
+	}
+
+	mapLiteral struct {
+		keys   []string
+		values []expr
+	}
+
+	unary struct {
+		op      string
+		operand expr
+	}
+
+	binary struct {
+		op          string
+		left, right expr
+	}
+
+	// hasLabels is the `n:Label` predicate.
+	hasLabels struct {
+		subject expr
+		labels  []string
+	}
+
+	funcCall struct {
+		name     string
+		distinct bool
+		star     bool
+		args     []expr
+	}
+)
+
+type nodePattern struct {
+	variable string
+	labels   []string
+	props    expr
+}
+
+type relPattern struct {
+	variable string
+	types    []string
+	props    expr
+	// direction is 1 for (a)-[]->(b), -1 for (a)<-[]-(b) and 0 for (a)-[]-(b).
+	direction int
+	// varLength is set for (a)-[*min..max]-(b), binding the variable to the list of relationships followed. maxHops
+	// is -1 when there is no upper bound.
+	varLength        bool
+	minHops, maxHops int
+}
+
+type pattern struct {
+	// variable is bound to the path matched, as in p = (a)-[]-(b).
+	variable string
+	nodes    []*nodePattern
+	rels     []*relPattern
+}
+
+type returnItem struct {
+	expr  expr
+	alias string
+}
+
+type sortItem struct {
+	expr       expr
+	descending bool
+}
+
+type projection struct {
+	distinct bool
+	star     bool
+	items    []*returnItem
+	order    []*sortItem
+	skip     expr
+	limit    expr
+	where    expr
+}
+
+type setItem struct {
+	// kind is one of "property", "replace", "merge" or "labels".
+	kind     string
+	variable string
+	key      string
+	labels   []string
+	value    expr
+}
+
+type removeItem struct {
+	variable string
+	key      string
+	labels   []string
+}
+
+type (
+	matchClause struct {
+		optional bool
+		patterns []*pattern
+		where    expr
+	}
+
+	createClause struct {
+		patterns []*pattern
+	}
+
+	mergeClause struct {
+		pattern  *pattern
+		onCreate []*setItem
+		onMatch  []*setItem
+	}
+
+	setClause struct {
+		items []*setItem
+	}
+
+	removeClause struct {
+		items []*removeItem
+	}
+
+	deleteClause struct {
+		detach bool
+		exprs  []expr
+	}
+
+	unwindClause struct {
+		expr     expr
+		variable string
+	}
+
+	withClause struct {
+		projection *projection
+	}
+
+	returnClause struct {
+		projection *projection
+	}
+)
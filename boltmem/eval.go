@@ -0,0 +1,701 @@
+package boltmem
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// row binds variable names to values: nil, bool, int64, float64, string, []interface{}, map[string]interface{},
+// *node or *relationship.
+type row map[string]interface{}
+
+func (r row) with(name string, value interface{}) row {
+	c := make(row, len(r)+1)
+	for k, v := range r {
+		c[k] = v
+	}
+	c[name] = value
+	return c
+}
+
+type evaluator struct {
+	params map[string]interface{}
+}
+
+// eval evaluates e against r. group holds the rows being aggregated when e is evaluated by a projection, and is nil
+// otherwise.
+func (ev *evaluator) eval(e expr, r row, group []row) (interface{}, error) {
+	switch e := e.(type) {
+	case *literal:
+		return e.value, nil
+
+	case *param:
+		v, ok := ev.params[e.name]
+		if !ok {
+			return nil, errors.Errorf("boltmem: expected parameter %q to be provided", e.name)
+		}
+		return v, nil
+
+	case *variable:
+		v, ok := r[e.name]
+		if !ok {
+			return nil, errors.Errorf("boltmem: variable %q is not defined", e.name)
+		}
+		return v, nil
+
+	case *property:
+		subject, err := ev.eval(e.subject, r, group)
+		if err != nil {
+			return nil, err
+		}
+		switch s := subject.(type) {
+		case nil:
+			return nil, nil
+		case *node:
+			return s.props[e.key], nil
+		case *relationship:
+			return s.props[e.key], nil
+		case map[string]interface{}:
+			return s[e.key], nil
+		}
+		return nil, errors.Errorf("boltmem: can not access property %q of %s", e.key, typeName(subject))
+
+	case *index:
+		return ev.evalIndex(e, r, group)
+
+	case *listLiteral:
+		l := make([]interface{}, len(e.items))
+		for i, item := range e.items {
+			v, err := ev.eval(item, r, group)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = v
+		}
+		return l, nil
+
+	case *mapLiteral:
+		m := make(map[string]interface{}, len(e.keys))
+		for i, k := range e.keys {
+			v, err := ev.eval(e.values[i], r, group)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = v
+		}
+		return m, nil
+
+	case *hasLabels:
+		subject, err := ev.eval(e.subject, r, group)
+		if err != nil || subject == nil {
+			return nil, err
+		}
+		n, ok := subject.(*node)
+		if !ok {
+			return nil, errors.Errorf("boltmem: can not check the labels of %s", typeName(subject))
+		}
+		for _, l := range e.labels {
+			if !n.hasLabel(l) {
+				return false, nil
+			}
+		}
+		return true, nil
+
+	case *unary:
+		return ev.evalUnary(e, r, group)
+
+	case *binary:
+		return ev.evalBinary(e, r, group)
+
+	case *funcCall:
+		if isAggregate(e.name) {
+			return ev.aggregate(e, group)
+		}
+		return ev.call(e, r, group)
+	}
+	return nil, errors.Errorf("boltmem: unsupported expression %T", e)
+}
+
+func (ev *evaluator) evalIndex(e *index, r row, group []row) (interface{}, error) {
+	subject, err := ev.eval(e.subject, r, group)
+	if err != nil {
+		return nil, err
+	}
+	i, err := ev.eval(e.index, r, group)
+	if err != nil || subject == nil || i == nil {
+		return nil, err
+	}
+
+	switch s := subject.(type) {
+	case []interface{}:
+		n, ok := i.(int64)
+		if !ok {
+			return nil, errors.Errorf("boltmem: list index must be an integer, not %s", typeName(i))
+		}
+		if n < 0 {
+			n += int64(len(s))
+		}
+		if n < 0 || n >= int64(len(s)) {
+			return nil, nil
+		}
+		return s[n], nil
+	case map[string]interface{}, *node, *relationship:
+		key, ok := i.(string)
+		if !ok {
+			return nil, errors.Errorf("boltmem: map key must be a string, not %s", typeName(i))
+		}
+		return ev.eval(&property{subject: &literal{value: subject}, key: key}, r, group)
+	}
+	return nil, errors.Errorf("boltmem: can not index %s", typeName(subject))
+}
+
+func (ev *evaluator) evalUnary(e *unary, r row, group []row) (interface{}, error) {
+	v, err := ev.eval(e.operand, r, group)
+	if err != nil {
+		return nil, err
+	}
+	switch e.op {
+	case "IS NULL":
+		return v == nil, nil
+	case "IS NOT NULL":
+		return v != nil, nil
+	case "NOT":
+		if v == nil {
+			return nil, nil
+		}
+		b, ok := v.(bool)
+		if !ok {
+			return nil, errors.Errorf("boltmem: NOT expects a boolean, not %s", typeName(v))
+		}
+		return !b, nil
+	case "-":
+		switch n := v.(type) {
+		case nil:
+			return nil, nil
+		case int64:
+			return -n, nil
+		case float64:
+			return -n, nil
+		}
+		return nil, errors.Errorf("boltmem: can not negate %s", typeName(v))
+	}
+	return nil, errors.Errorf("boltmem: unsupported operator %s", e.op)
+}
+
+func (ev *evaluator) evalBinary(e *binary, r row, group []row) (interface{}, error) {
+	left, err := ev.eval(e.left, r, group)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "AND", "OR", "XOR":
+		right, err := ev.eval(e.right, r, group)
+		if err != nil {
+			return nil, err
+		}
+		return logical(e.op, left, right)
+	}
+
+	right, err := ev.eval(e.right, r, group)
+	if err != nil {
+		return nil, err
+	}
+
+	switch e.op {
+	case "=":
+		return equal(left, right), nil
+	case "<>":
+		eq := equal(left, right)
+		if eq == nil {
+			return nil, nil
+		}
+		return !eq.(bool), nil
+	case "<", ">", "<=", ">=":
+		c, ok := compare(left, right)
+		if !ok {
+			return nil, nil
+		}
+		switch e.op {
+		case "<":
+			return c < 0, nil
+		case ">":
+			return c > 0, nil
+		case "<=":
+			return c <= 0, nil
+		}
+		return c >= 0, nil
+	case "IN":
+		return in(left, right)
+	case "STARTS WITH", "ENDS WITH", "CONTAINS", "=~":
+		return stringPredicate(e.op, left, right)
+	}
+	return arithmetic(e.op, left, right)
+}
+
+func logical(op string, left, right interface{}) (interface{}, error) {
+	for _, v := range []interface{}{left, right} {
+		if _, ok := v.(bool); v != nil && !ok {
+			return nil, errors.Errorf("boltmem: %s expects booleans, not %s", op, typeName(v))
+		}
+	}
+	l, lok := left.(bool)
+	rr, rok := right.(bool)
+
+	switch op {
+	case "AND":
+		if (lok && !l) || (rok && !rr) {
+			return false, nil
+		}
+		if !lok || !rok {
+			return nil, nil
+		}
+		return true, nil
+	case "OR":
+		if (lok && l) || (rok && rr) {
+			return true, nil
+		}
+		if !lok || !rok {
+			return nil, nil
+		}
+		return false, nil
+	}
+	if !lok || !rok {
+		return nil, nil
+	}
+	return l != rr, nil
+}
+
+// equal implements Cypher equality, returning nil when either side is null.
+func equal(a, b interface{}) interface{} {
+	if a == nil || b == nil {
+		return nil
+	}
+	switch av := a.(type) {
+	case int64, float64:
+		if c, ok := compare(a, b); ok {
+			return c == 0
+		}
+		return false
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		var result interface{} = true
+		for i := range av {
+			eq := equal(av[i], bv[i])
+			if eq == false {
+				return false
+			}
+			if eq == nil {
+				result = nil
+			}
+		}
+		return result
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok || len(av) != len(bv) {
+			return false
+		}
+		var result interface{} = true
+		for k, v := range av {
+			w, ok := bv[k]
+			if !ok {
+				return false
+			}
+			eq := equal(v, w)
+			if eq == false {
+				return false
+			}
+			if eq == nil {
+				result = nil
+			}
+		}
+		return result
+	}
+	return a == b
+}
+
+// compare orders two comparable values, returning false if they can not be compared.
+func compare(a, b interface{}) (int, bool) {
+	switch av := a.(type) {
+	case int64:
+		switch bv := b.(type) {
+		case int64:
+			return compareInts(av, bv), true
+		case float64:
+			return compareFloats(float64(av), bv), true
+		}
+	case float64:
+		switch bv := b.(type) {
+		case int64:
+			return compareFloats(av, float64(bv)), true
+		case float64:
+			return compareFloats(av, bv), true
+		}
+	case string:
+		if bv, ok := b.(string); ok {
+			return strings.Compare(av, bv), true
+		}
+	case bool:
+		if bv, ok := b.(bool); ok {
+			switch {
+			case av == bv:
+				return 0, true
+			case !av:
+				return -1, true
+			}
+			return 1, true
+		}
+	}
+	return 0, false
+}
+
+func compareInts(a, b int64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+func compareFloats(a, b float64) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// orderValues is the total order used by ORDER BY, with nulls sorting last.
+func orderValues(a, b interface{}) int {
+	if c, ok := compare(a, b); ok {
+		return c
+	}
+	ra, rb := typeRank(a), typeRank(b)
+	if ra != rb {
+		return compareInts(int64(ra), int64(rb))
+	}
+	switch av := a.(type) {
+	case *node:
+		return compareInts(av.id, b.(*node).id)
+	case *relationship:
+		return compareInts(av.id, b.(*relationship).id)
+	case []interface{}:
+		bv := b.([]interface{})
+		for i := 0; i < len(av) && i < len(bv); i++ {
+			if c := orderValues(av[i], bv[i]); c != 0 {
+				return c
+			}
+		}
+		return compareInts(int64(len(av)), int64(len(bv)))
+	}
+	return 0
+}
+
+func typeRank(v interface{}) int {
+	switch v.(type) {
+	case map[string]interface{}:
+		return 0
+	case *node:
+		return 1
+	case *relationship:
+		return 2
+	case []interface{}:
+		return 3
+	case string:
+		return 4
+	case bool:
+		return 5
+	case int64, float64:
+		return 6
+	case nil:
+		return 8
+	}
+	return 7
+}
+
+func in(left, right interface{}) (interface{}, error) {
+	if right == nil {
+		return nil, nil
+	}
+	list, ok := right.([]interface{})
+	if !ok {
+		return nil, errors.Errorf("boltmem: IN expects a list, not %s", typeName(right))
+	}
+	var result interface{} = false
+	for _, item := range list {
+		eq := equal(left, item)
+		if eq == true {
+			return true, nil
+		}
+		if eq == nil {
+			result = nil
+		}
+	}
+	return result, nil
+}
+
+func stringPredicate(op string, left, right interface{}) (interface{}, error) {
+	l, lok := left.(string)
+	r, rok := right.(string)
+	if !lok || !rok {
+		return nil, nil
+	}
+	switch op {
+	case "STARTS WITH":
+		return strings.HasPrefix(l, r), nil
+	case "ENDS WITH":
+		return strings.HasSuffix(l, r), nil
+	case "CONTAINS":
+		return strings.Contains(l, r), nil
+	}
+	re, err := regexp.Compile("^(?:" + r + ")$")
+	if err != nil {
+		return nil, errors.Errorf("boltmem: invalid regular expression %q", r)
+	}
+	return re.MatchString(l), nil
+}
+
+func arithmetic(op string, left, right interface{}) (interface{}, error) {
+	if left == nil || right == nil {
+		return nil, nil
+	}
+
+	if op == "+" {
+		switch l := left.(type) {
+		case string:
+			return l + toString(right), nil
+		case []interface{}:
+			if r, ok := right.([]interface{}); ok {
+				return append(append([]interface{}{}, l...), r...), nil
+			}
+			return append(append([]interface{}{}, l...), right), nil
+		}
+		if s, ok := right.(string); ok {
+			return toString(left) + s, nil
+		}
+		if r, ok := right.([]interface{}); ok {
+			return append([]interface{}{left}, r...), nil
+		}
+	}
+
+	li, lInt := left.(int64)
+	ri, rInt := right.(int64)
+	if lInt && rInt && op != "^" {
+		switch op {
+		case "+":
+			return li + ri, nil
+		case "-":
+			return li - ri, nil
+		case "*":
+			return li * ri, nil
+		case "/", "%":
+			if ri == 0 {
+				return nil, errors.New("boltmem: / by zero")
+			}
+			if op == "/" {
+				return li / ri, nil
+			}
+			return li % ri, nil
+		}
+	}
+
+	lf, lok := toFloat(left)
+	rf, rok := toFloat(right)
+	if !lok || !rok {
+		return nil, errors.Errorf("boltmem: can not apply %s to %s and %s", op, typeName(left), typeName(right))
+	}
+	switch op {
+	case "+":
+		return lf + rf, nil
+	case "-":
+		return lf - rf, nil
+	case "*":
+		return lf * rf, nil
+	case "/":
+		return lf / rf, nil
+	case "%":
+		return math.Mod(lf, rf), nil
+	case "^":
+		return math.Pow(lf, rf), nil
+	}
+	return nil, errors.Errorf("boltmem: unsupported operator %s", op)
+}
+
+func toFloat(v interface{}) (float64, bool) {
+	switch n := v.(type) {
+	case int64:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+func toString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	}
+	return fmt.Sprint(v)
+}
+
+// truthy reports whether a predicate holds, null counting as false.
+func truthy(v interface{}) (bool, error) {
+	switch b := v.(type) {
+	case nil:
+		return false, nil
+	case bool:
+		return b, nil
+	}
+	return false, errors.Errorf("boltmem: expected a boolean predicate, not %s", typeName(v))
+}
+
+func typeName(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "a boolean"
+	case int64:
+		return "an integer"
+	case float64:
+		return "a float"
+	case string:
+		return "a string"
+	case []interface{}:
+		return "a list"
+	case map[string]interface{}:
+		return "a map"
+	case *node:
+		return "a node"
+	case *relationship:
+		return "a relationship"
+	case *path:
+		return "a path"
+	}
+	return fmt.Sprintf("%T", v)
+}
+
+// normalise converts parameter values to the types used by the engine, so []string becomes []interface{}, int becomes
+// int64 and so on.
+func normalise(v interface{}) (interface{}, error) {
+	switch t := v.(type) {
+	case nil, bool, int64, float64, string, *node, *relationship, *path:
+		return v, nil
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, item := range t {
+			n, err := normalise(item)
+			if err != nil {
+				return nil, err
+			}
+			l[i] = n
+		}
+		return l, nil
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			n, err := normalise(item)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = n
+		}
+		return m, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int(), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return int64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return rv.Float(), nil
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Bool:
+		return rv.Bool(), nil
+	case reflect.Slice, reflect.Array:
+		l := make([]interface{}, rv.Len())
+		for i := range l {
+			n, err := normalise(rv.Index(i).Interface())
+			if err != nil {
+				return nil, err
+			}
+			l[i] = n
+		}
+		return l, nil
+	case reflect.Map:
+		if rv.Type().Key().Kind() != reflect.String {
+			break
+		}
+		m := make(map[string]interface{}, rv.Len())
+		for _, k := range rv.MapKeys() {
+			n, err := normalise(rv.MapIndex(k).Interface())
+			if err != nil {
+				return nil, err
+			}
+			m[k.String()] = n
+		}
+		return m, nil
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return nil, nil
+		}
+		return normalise(rv.Elem().Interface())
+	}
+	return nil, errors.Errorf("boltmem: unsupported parameter type %T", v)
+}
+
+// output converts a value to the types returned by the driver, nodes, relationships and paths becoming graph.Node,
+// graph.Relationship and graph.Path.
+func output(v interface{}) interface{} {
+	switch t := v.(type) {
+	case *node:
+		return t.toGraph()
+	case *relationship:
+		return t.toGraph()
+	case *path:
+		return t.toGraph()
+	case []interface{}:
+		l := make([]interface{}, len(t))
+		for i, item := range t {
+			l[i] = output(item)
+		}
+		return l
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(t))
+		for k, item := range t {
+			m[k] = output(item)
+		}
+		return m
+	}
+	return v
+}
+
+// sortedKeys returns the keys of m in order, so maps are handled deterministically.
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,1027 @@
+package boltmem
+
+import (
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// result is the outcome of running a query against the store.
+type result struct {
+	columns []string
+	records [][]interface{}
+	stats   map[string]interface{}
+	write   bool
+}
+
+type execution struct {
+	store *store
+	ev    *evaluator
+	stats map[string]int64
+}
+
+func run(s *store, clauses []interface{}, params map[string]interface{}) (*result, error) {
+	normalised, err := normalise(params)
+	if err != nil {
+		return nil, err
+	}
+	m, _ := normalised.(map[string]interface{})
+
+	x := &execution{store: s, ev: &evaluator{params: m}, stats: make(map[string]int64)}
+	res := &result{}
+	rows := []row{{}}
+
+	for _, c := range clauses {
+		switch c := c.(type) {
+		case *matchClause:
+			rows, err = x.match(c, rows)
+		case *createClause:
+			rows, err = x.create(c, rows)
+		case *mergeClause:
+			rows, err = x.merge(c, rows)
+		case *setClause:
+			err = x.forEach(rows, func(r row) error { return x.set(c.items, r) })
+		case *removeClause:
+			err = x.forEach(rows, func(r row) error { return x.remove(c.items, r) })
+		case *deleteClause:
+			err = x.delete(c, rows)
+		case *unwindClause:
+			rows, err = x.unwind(c, rows)
+		case *withClause:
+			_, rows, err = x.project(c.projection, rows)
+		case *returnClause:
+			var columns []string
+			if columns, rows, err = x.project(c.projection, rows); err != nil {
+				return nil, err
+			}
+			res.columns = columns
+			for _, r := range rows {
+				record := make([]interface{}, len(columns))
+				for i, col := range columns {
+					record[i] = output(r[col])
+				}
+				res.records = append(res.records, record)
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res.stats = make(map[string]interface{}, len(x.stats))
+	for k, v := range x.stats {
+		res.stats[k] = v
+	}
+	res.write = len(x.stats) > 0
+	return res, nil
+}
+
+func (x *execution) forEach(rows []row, f func(r row) error) error {
+	for _, r := range rows {
+		if err := f(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (x *execution) match(c *matchClause, rows []row) ([]row, error) {
+	var out []row
+	for _, r := range rows {
+		matches := []row{r}
+		for _, pat := range c.patterns {
+			var next []row
+			for _, m := range matches {
+				found, err := x.matchPattern(pat, m)
+				if err != nil {
+					return nil, err
+				}
+				next = append(next, found...)
+			}
+			matches = next
+		}
+
+		var kept []row
+		for _, m := range matches {
+			if c.where != nil {
+				v, err := x.ev.eval(c.where, m, nil)
+				if err != nil {
+					return nil, err
+				}
+				ok, err := truthy(v)
+				if err != nil {
+					return nil, err
+				}
+				if !ok {
+					continue
+				}
+			}
+			kept = append(kept, m)
+		}
+
+		if len(kept) == 0 && c.optional {
+			nulls := r
+			for _, pat := range c.patterns {
+				for _, name := range pat.variables() {
+					if _, ok := nulls[name]; !ok {
+						nulls = nulls.with(name, nil)
+					}
+				}
+			}
+			kept = []row{nulls}
+		}
+		out = append(out, kept...)
+	}
+	return out, nil
+}
+
+func (p *pattern) variables() []string {
+	var names []string
+	if p.variable != "" {
+		names = append(names, p.variable)
+	}
+	for i, n := range p.nodes {
+		if n.variable != "" {
+			names = append(names, n.variable)
+		}
+		if i < len(p.rels) && p.rels[i].variable != "" {
+			names = append(names, p.rels[i].variable)
+		}
+	}
+	return names
+}
+
+// matchPattern returns a row for every way pat can be matched in the store, extending r. A relationship is only
+// matched once per path.
+func (x *execution) matchPattern(pat *pattern, r row) ([]row, error) {
+	candidates, err := x.nodeCandidates(pat.nodes[0], r)
+	if err != nil {
+		return nil, err
+	}
+
+	var out []row
+	for _, n := range candidates {
+		start := r
+		if v := pat.nodes[0].variable; v != "" {
+			start = start.with(v, n)
+		}
+		found, err := x.expand(pat, 0, &path{nodes: []*node{n}}, start, map[int64]bool{})
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, found...)
+	}
+	return out, nil
+}
+
+// expand matches the relationships of pat from the i-th onwards, continuing trail from its last node.
+func (x *execution) expand(pat *pattern, i int, trail *path, r row, used map[int64]bool) ([]row, error) {
+	if i == len(pat.rels) {
+		if pat.variable != "" {
+			r = r.with(pat.variable, trail)
+		}
+		return []row{r}, nil
+	}
+	if pat.rels[i].varLength {
+		return x.expandHops(pat, i, trail, len(trail.rels), r, used)
+	}
+	rp, np := pat.rels[i], pat.nodes[i+1]
+	from := trail.nodes[len(trail.nodes)-1]
+
+	var out []row
+	for _, rel := range relationshipsOf(from) {
+		if used[rel.id] {
+			continue
+		}
+		for _, other := range neighbours(rp, rel, from) {
+			ok, err := x.relMatches(rp, rel, r)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			if ok, err = x.nodeMatches(np, other, r); err != nil {
+				return nil, err
+			} else if !ok {
+				continue
+			}
+
+			next := r
+			if rp.variable != "" {
+				next = next.with(rp.variable, rel)
+			}
+			if np.variable != "" {
+				next = next.with(np.variable, other)
+			}
+			used[rel.id] = true
+			found, err := x.expand(pat, i+1, trail.extend(rel, other), next, used)
+			delete(used, rel.id)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, found...)
+		}
+	}
+	return out, nil
+}
+
+// expandHops matches the i-th relationship of pat, which has a variable length and has followed the relationships of
+// trail from start so far. Shorter matches are returned before the longer ones continuing them.
+func (x *execution) expandHops(pat *pattern, i int, trail *path, start int, r row, used map[int64]bool) ([]row, error) {
+	rp, np := pat.rels[i], pat.nodes[i+1]
+	from := trail.nodes[len(trail.nodes)-1]
+	hops := trail.rels[start:]
+
+	var out []row
+	if len(hops) >= rp.minHops {
+		ok, err := x.nodeMatches(np, from, r)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			next := r
+			if rp.variable != "" {
+				rels := make([]interface{}, len(hops))
+				for j, rel := range hops {
+					rels[j] = rel
+				}
+				next = next.with(rp.variable, rels)
+			}
+			if np.variable != "" {
+				next = next.with(np.variable, from)
+			}
+			found, err := x.expand(pat, i+1, trail, next, used)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, found...)
+		}
+	}
+	if rp.maxHops >= 0 && len(hops) >= rp.maxHops {
+		return out, nil
+	}
+
+	for _, rel := range relationshipsOf(from) {
+		if used[rel.id] {
+			continue
+		}
+		for _, other := range neighbours(rp, rel, from) {
+			ok, err := x.relMatches(rp, rel, r)
+			if err != nil {
+				return nil, err
+			}
+			if !ok {
+				continue
+			}
+			used[rel.id] = true
+			found, err := x.expandHops(pat, i, trail.extend(rel, other), start, r, used)
+			delete(used, rel.id)
+			if err != nil {
+				return nil, err
+			}
+			out = append(out, found...)
+		}
+	}
+	return out, nil
+}
+
+// neighbours returns the nodes rel leads to from the node from in the direction of rp.
+func neighbours(rp *relPattern, rel *relationship, from *node) []*node {
+	var others []*node
+	if rp.direction >= 0 && rel.start == from {
+		others = append(others, rel.end)
+	}
+	if rp.direction <= 0 && rel.end == from && (rp.direction != 0 || rel.start != from) {
+		others = append(others, rel.start)
+	}
+	return others
+}
+
+func (x *execution) nodeCandidates(np *nodePattern, r row) ([]*node, error) {
+	var candidates []*node
+	if v, ok := r[np.variable]; ok && np.variable != "" {
+		n, ok := v.(*node)
+		if !ok {
+			if v == nil {
+				return nil, nil
+			}
+			return nil, errors.Errorf("boltmem: variable %q is %s, not a node", np.variable, typeName(v))
+		}
+		candidates = []*node{n}
+	} else {
+		candidates = x.store.allNodes()
+	}
+
+	var out []*node
+	for _, n := range candidates {
+		ok, err := x.nodeMatches(np, n, r)
+		if err != nil {
+			return nil, err
+		}
+		if ok {
+			out = append(out, n)
+		}
+	}
+	return out, nil
+}
+
+func (x *execution) nodeMatches(np *nodePattern, n *node, r row) (bool, error) {
+	if v, ok := r[np.variable]; ok && np.variable != "" && v != n {
+		return false, nil
+	}
+	for _, l := range np.labels {
+		if !n.hasLabel(l) {
+			return false, nil
+		}
+	}
+	return x.propsMatch(np.props, n.props, r)
+}
+
+func (x *execution) relMatches(rp *relPattern, rel *relationship, r row) (bool, error) {
+	if v, ok := r[rp.variable]; ok && rp.variable != "" && !rp.varLength && v != rel {
+		return false, nil
+	}
+	if len(rp.types) > 0 {
+		found := false
+		for _, t := range rp.types {
+			if t == rel.typ {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false, nil
+		}
+	}
+	return x.propsMatch(rp.props, rel.props, r)
+}
+
+func (x *execution) propsMatch(e expr, props map[string]interface{}, r row) (bool, error) {
+	if e == nil {
+		return true, nil
+	}
+	want, err := x.evalProps(e, r)
+	if err != nil {
+		return false, err
+	}
+	for k, v := range want {
+		if equal(props[k], v) != true {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (x *execution) evalProps(e expr, r row) (map[string]interface{}, error) {
+	if e == nil {
+		return map[string]interface{}{}, nil
+	}
+	v, err := x.ev.eval(e, r, nil)
+	if err != nil {
+		return nil, err
+	}
+	switch m := v.(type) {
+	case nil:
+		return map[string]interface{}{}, nil
+	case map[string]interface{}:
+		return m, nil
+	}
+	return nil, errors.Errorf("boltmem: expected a map of properties, not %s", typeName(v))
+}
+
+func (x *execution) create(c *createClause, rows []row) ([]row, error) {
+	out := make([]row, 0, len(rows))
+	for _, r := range rows {
+		var err error
+		for _, pat := range c.patterns {
+			if r, err = x.createPattern(pat, r); err != nil {
+				return nil, err
+			}
+		}
+		out = append(out, r)
+	}
+	return out, nil
+}
+
+// createPattern creates the nodes and relationships of pat, reusing nodes already bound in r.
+func (x *execution) createPattern(pat *pattern, r row) (row, error) {
+	nodes := make([]*node, len(pat.nodes))
+	for i, np := range pat.nodes {
+		if v, ok := r[np.variable]; ok && np.variable != "" {
+			n, ok := v.(*node)
+			if !ok {
+				return nil, errors.Errorf("boltmem: can not create a relationship to %s bound to %q", typeName(v), np.variable)
+			}
+			if len(np.labels) > 0 || np.props != nil {
+				return nil, errors.Errorf("boltmem: variable %q is already declared", np.variable)
+			}
+			nodes[i] = n
+			continue
+		}
+
+		props, err := x.propertiesToStore(np.props, r)
+		if err != nil {
+			return nil, err
+		}
+		n := x.store.createNode(append([]string{}, np.labels...), props)
+		x.count("nodes-created", 1)
+		x.count("labels-added", int64(len(np.labels)))
+		x.count("properties-set", int64(len(props)))
+		nodes[i] = n
+		if np.variable != "" {
+			r = r.with(np.variable, n)
+		}
+	}
+
+	for i, rp := range pat.rels {
+		if len(rp.types) != 1 {
+			return nil, errors.New("boltmem: exactly one relationship type must be specified for CREATE")
+		}
+		if rp.direction == 0 {
+			return nil, errors.New("boltmem: only directed relationships are supported in CREATE")
+		}
+		if _, ok := r[rp.variable]; ok && rp.variable != "" {
+			return nil, errors.Errorf("boltmem: variable %q is already declared", rp.variable)
+		}
+
+		props, err := x.propertiesToStore(rp.props, r)
+		if err != nil {
+			return nil, err
+		}
+		start, end := nodes[i], nodes[i+1]
+		if rp.direction < 0 {
+			start, end = end, start
+		}
+		rel := x.store.createRelationship(rp.types[0], start, end, props)
+		x.count("relationships-created", 1)
+		x.count("properties-set", int64(len(props)))
+		if rp.variable != "" {
+			r = r.with(rp.variable, rel)
+		}
+	}
+	return r, nil
+}
+
+// propertiesToStore evaluates the properties of a pattern, dropping nulls and checking the rest can be stored.
+func (x *execution) propertiesToStore(e expr, r row) (map[string]interface{}, error) {
+	props, err := x.evalProps(e, r)
+	if err != nil {
+		return nil, err
+	}
+	stored := make(map[string]interface{}, len(props))
+	for k, v := range props {
+		if v == nil {
+			continue
+		}
+		if err := checkProperty(k, v); err != nil {
+			return nil, err
+		}
+		stored[k] = v
+	}
+	return stored, nil
+}
+
+func checkProperty(key string, v interface{}) error {
+	switch t := v.(type) {
+	case bool, int64, float64, string:
+		return nil
+	case []interface{}:
+		for _, item := range t {
+			switch item.(type) {
+			case bool, int64, float64, string:
+			default:
+				return errors.Errorf("boltmem: property %q can only hold a list of primitive values", key)
+			}
+		}
+		return nil
+	}
+	return errors.Errorf("boltmem: property %q can not hold %s, only primitive types or lists of them", key, typeName(v))
+}
+
+func (x *execution) merge(c *mergeClause, rows []row) ([]row, error) {
+	var out []row
+	for _, r := range rows {
+		matches, err := x.matchPattern(c.pattern, r)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matches) > 0 {
+			for _, m := range matches {
+				if err := x.set(c.onMatch, m); err != nil {
+					return nil, err
+				}
+			}
+			out = append(out, matches...)
+			continue
+		}
+
+		created, err := x.createPattern(c.pattern, r)
+		if err != nil {
+			return nil, err
+		}
+		if err := x.set(c.onCreate, created); err != nil {
+			return nil, err
+		}
+		out = append(out, created)
+	}
+	return out, nil
+}
+
+func (x *execution) set(items []*setItem, r row) error {
+	for _, item := range items {
+		target, ok := r[item.variable]
+		if !ok {
+			return errors.Errorf("boltmem: variable %q is not defined", item.variable)
+		}
+		if target == nil {
+			continue
+		}
+
+		var props map[string]interface{}
+		switch t := target.(type) {
+		case *node:
+			props = t.props
+			if item.kind == "labels" {
+				for _, l := range item.labels {
+					if !t.hasLabel(l) {
+						t.labels = append(t.labels, l)
+						x.count("labels-added", 1)
+					}
+				}
+				continue
+			}
+		case *relationship:
+			props = t.props
+		default:
+			return errors.Errorf("boltmem: can not set properties on %s", typeName(target))
+		}
+		if item.kind == "labels" {
+			return errors.New("boltmem: labels can only be set on nodes")
+		}
+
+		v, err := x.ev.eval(item.value, r, nil)
+		if err != nil {
+			return err
+		}
+
+		if item.kind == "property" {
+			if err := x.setProperty(props, item.key, v); err != nil {
+				return err
+			}
+			continue
+		}
+
+		var values map[string]interface{}
+		if v != nil {
+			if values, err = propertiesOf(v); err != nil {
+				return errors.WithMessage(err, "boltmem: SET "+item.variable)
+			}
+		}
+		if item.kind == "replace" {
+			for k := range props {
+				if _, ok := values[k]; !ok {
+					delete(props, k)
+					x.count("properties-set", 1)
+				}
+			}
+		}
+		for _, k := range sortedKeys(values) {
+			if err := x.setProperty(props, k, values[k]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (x *execution) setProperty(props map[string]interface{}, key string, v interface{}) error {
+	if v == nil {
+		if _, ok := props[key]; ok {
+			delete(props, key)
+			x.count("properties-set", 1)
+		}
+		return nil
+	}
+	if err := checkProperty(key, v); err != nil {
+		return err
+	}
+	props[key] = v
+	x.count("properties-set", 1)
+	return nil
+}
+
+func (x *execution) remove(items []*removeItem, r row) error {
+	for _, item := range items {
+		target, ok := r[item.variable]
+		if !ok {
+			return errors.Errorf("boltmem: variable %q is not defined", item.variable)
+		}
+		switch t := target.(type) {
+		case nil:
+		case *node:
+			if item.key != "" {
+				x.setProperty(t.props, item.key, nil)
+				continue
+			}
+			for _, l := range item.labels {
+				for i, existing := range t.labels {
+					if existing == l {
+						t.labels = append(t.labels[:i:i], t.labels[i+1:]...)
+						x.count("labels-removed", 1)
+						break
+					}
+				}
+			}
+		case *relationship:
+			if item.key == "" {
+				return errors.New("boltmem: labels can only be removed from nodes")
+			}
+			x.setProperty(t.props, item.key, nil)
+		default:
+			return errors.Errorf("boltmem: can not remove from %s", typeName(target))
+		}
+	}
+	return nil
+}
+
+// delete removes the nodes and relationships the clause evaluates to once every row has been evaluated, so a node
+// and its relationships can be deleted by the same clause. Without DETACH, nothing is deleted if a node would be left
+// with relationships.
+func (x *execution) delete(c *deleteClause, rows []row) error {
+	var nodes []*node
+	var rels []*relationship
+
+	var collect func(v interface{}) error
+	collect = func(v interface{}) error {
+		switch t := v.(type) {
+		case nil:
+		case *node:
+			nodes = append(nodes, t)
+		case *relationship:
+			rels = append(rels, t)
+		case []interface{}:
+			for _, item := range t {
+				if err := collect(item); err != nil {
+					return err
+				}
+			}
+		default:
+			return errors.Errorf("boltmem: can not delete %s", typeName(v))
+		}
+		return nil
+	}
+
+	for _, r := range rows {
+		for _, e := range c.exprs {
+			v, err := x.ev.eval(e, r, nil)
+			if err != nil {
+				return err
+			}
+			if err := collect(v); err != nil {
+				return err
+			}
+		}
+	}
+
+	if !c.detach {
+		deleting := make(map[*relationship]bool, len(rels))
+		for _, rel := range rels {
+			deleting[rel] = true
+		}
+		for _, n := range nodes {
+			for _, rel := range n.rels {
+				if !deleting[rel] {
+					return errors.Errorf("boltmem: cannot delete node<%d>, because it still has relationships. To "+
+						"delete this node, you must first delete its relationships", n.id)
+				}
+			}
+		}
+	}
+
+	for _, rel := range rels {
+		if !rel.deleted {
+			x.store.deleteRelationship(rel)
+			x.count("relationships-deleted", 1)
+		}
+	}
+	for _, n := range nodes {
+		if n.deleted {
+			continue
+		}
+		if c.detach {
+			for _, rel := range relationshipsOf(n) {
+				x.store.deleteRelationship(rel)
+				x.count("relationships-deleted", 1)
+			}
+		}
+		x.store.deleteNode(n)
+		x.count("nodes-deleted", 1)
+	}
+	return nil
+}
+
+func (x *execution) unwind(c *unwindClause, rows []row) ([]row, error) {
+	var out []row
+	for _, r := range rows {
+		v, err := x.ev.eval(c.expr, r, nil)
+		if err != nil {
+			return nil, err
+		}
+		switch l := v.(type) {
+		case nil:
+		case []interface{}:
+			for _, item := range l {
+				out = append(out, r.with(c.variable, item))
+			}
+		default:
+			out = append(out, r.with(c.variable, v))
+		}
+	}
+	return out, nil
+}
+
+func (x *execution) count(stat string, n int64) {
+	if n != 0 {
+		x.stats[stat] += n
+	}
+}
+
+// projected is a row produced by RETURN or WITH, along with the row and group it was projected from so ORDER BY can
+// refer to variables and aggregates that are not returned.
+type projected struct {
+	values row
+	source row
+	group  []row
+}
+
+func (x *execution) project(p *projection, rows []row) ([]string, []row, error) {
+	items := p.items
+	if p.star {
+		items = nil
+		if len(rows) > 0 {
+			for _, name := range sortedKeys(rows[0]) {
+				items = append(items, &returnItem{expr: &variable{name: name}, alias: name})
+			}
+		}
+	}
+
+	columns := make([]string, len(items))
+	aggregating := false
+	for i, item := range items {
+		columns[i] = item.alias
+		if containsAggregate(item.expr) {
+			aggregating = true
+		}
+	}
+
+	var out []*projected
+	var err error
+	if aggregating {
+		out, err = x.projectGroups(items, rows)
+	} else {
+		out, err = x.projectRows(items, rows)
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if p.distinct {
+		out = distinct(out, columns)
+	}
+	if len(p.order) > 0 {
+		if err := x.order(p.order, out); err != nil {
+			return nil, nil, err
+		}
+	}
+	if out, err = x.page(p, out); err != nil {
+		return nil, nil, err
+	}
+
+	result := make([]row, 0, len(out))
+	for _, o := range out {
+		if p.where != nil {
+			v, err := x.ev.eval(p.where, o.values, nil)
+			if err != nil {
+				return nil, nil, err
+			}
+			ok, err := truthy(v)
+			if err != nil {
+				return nil, nil, err
+			}
+			if !ok {
+				continue
+			}
+		}
+		result = append(result, o.values)
+	}
+	return columns, result, nil
+}
+
+func (x *execution) projectRows(items []*returnItem, rows []row) ([]*projected, error) {
+	out := make([]*projected, 0, len(rows))
+	for _, r := range rows {
+		values := make(row, len(items))
+		for _, item := range items {
+			v, err := x.ev.eval(item.expr, r, nil)
+			if err != nil {
+				return nil, err
+			}
+			values[item.alias] = v
+		}
+		out = append(out, &projected{values: values, source: r})
+	}
+	return out, nil
+}
+
+func (x *execution) projectGroups(items []*returnItem, rows []row) ([]*projected, error) {
+	type group struct {
+		key  []interface{}
+		rows []row
+	}
+	var groups []*group
+
+	for _, r := range rows {
+		var key []interface{}
+		for _, item := range items {
+			if containsAggregate(item.expr) {
+				continue
+			}
+			v, err := x.ev.eval(item.expr, r, nil)
+			if err != nil {
+				return nil, err
+			}
+			key = append(key, v)
+		}
+
+		var g *group
+		for _, existing := range groups {
+			if sameValues(existing.key, key) {
+				g = existing
+				break
+			}
+		}
+		if g == nil {
+			g = &group{key: key}
+			groups = append(groups, g)
+		}
+		g.rows = append(g.rows, r)
+	}
+
+	// Aggregating without grouping keys always returns a row, e.g. count(*) of nothing is 0.
+	if len(groups) == 0 {
+		grouped := false
+		for _, item := range items {
+			if !containsAggregate(item.expr) {
+				grouped = true
+			}
+		}
+		if !grouped {
+			groups = append(groups, &group{rows: []row{}})
+		}
+	}
+
+	out := make([]*projected, 0, len(groups))
+	for _, g := range groups {
+		first := row{}
+		if len(g.rows) > 0 {
+			first = g.rows[0]
+		}
+		values := make(row, len(items))
+		for _, item := range items {
+			v, err := x.ev.eval(item.expr, first, g.rows)
+			if err != nil {
+				return nil, err
+			}
+			values[item.alias] = v
+		}
+		out = append(out, &projected{values: values, source: first, group: g.rows})
+	}
+	return out, nil
+}
+
+// sameValues compares grouping keys, where unlike Cypher equality null is the same as null.
+func sameValues(a, b []interface{}) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] == nil || b[i] == nil {
+			if a[i] != b[i] {
+				return false
+			}
+			continue
+		}
+		if equal(a[i], b[i]) != true {
+			return false
+		}
+	}
+	return true
+}
+
+func distinct(rows []*projected, columns []string) []*projected {
+	var out []*projected
+	var seen [][]interface{}
+	for _, r := range rows {
+		key := make([]interface{}, len(columns))
+		for i, col := range columns {
+			key[i] = r.values[col]
+		}
+		dup := false
+		for _, s := range seen {
+			if sameValues(s, key) {
+				dup = true
+				break
+			}
+		}
+		if !dup {
+			seen = append(seen, key)
+			out = append(out, r)
+		}
+	}
+	return out
+}
+
+func (x *execution) order(items []*sortItem, rows []*projected) error {
+	keys := make([][]interface{}, len(rows))
+	for i, r := range rows {
+		scope := make(row, len(r.source)+len(r.values))
+		for k, v := range r.source {
+			scope[k] = v
+		}
+		for k, v := range r.values {
+			scope[k] = v
+		}
+		for _, item := range items {
+			group := r.group
+			if group == nil && containsAggregate(item.expr) {
+				return errors.New("boltmem: aggregates in ORDER BY must also be returned")
+			}
+			v, err := x.ev.eval(item.expr, scope, group)
+			if err != nil {
+				return err
+			}
+			keys[i] = append(keys[i], v)
+		}
+	}
+
+	index := make([]int, len(rows))
+	for i := range index {
+		index[i] = i
+	}
+	sort.SliceStable(index, func(a, b int) bool {
+		for k, item := range items {
+			c := orderValues(keys[index[a]][k], keys[index[b]][k])
+			if item.descending {
+				c = -c
+			}
+			if c != 0 {
+				return c < 0
+			}
+		}
+		return false
+	})
+
+	sorted := make([]*projected, len(rows))
+	for i, j := range index {
+		sorted[i] = rows[j]
+	}
+	copy(rows, sorted)
+	return nil
+}
+
+func (x *execution) page(p *projection, rows []*projected) ([]*projected, error) {
+	bound := func(e expr, name string) (int, error) {
+		v, err := x.ev.eval(e, row{}, nil)
+		if err != nil {
+			return 0, err
+		}
+		n, ok := v.(int64)
+		if !ok || n < 0 {
+			return 0, errors.Errorf("boltmem: %s expects a non-negative integer, not %v", name, v)
+		}
+		return int(n), nil
+	}
+
+	if p.skip != nil {
+		n, err := bound(p.skip, "SKIP")
+		if err != nil {
+			return nil, err
+		}
+		if n > len(rows) {
+			n = len(rows)
+		}
+		rows = rows[n:]
+	}
+	if p.limit != nil {
+		n, err := bound(p.limit, "LIMIT")
+		if err != nil {
+			return nil, err
+		}
+		if n < len(rows) {
+			rows = rows[:n]
+		}
+	}
+	return rows, nil
+}
@@ -0,0 +1,422 @@
+package boltmem
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+func isAggregate(name string) bool {
+	switch name {
+	case "count", "collect", "sum", "avg", "min", "max":
+		return true
+	}
+	return false
+}
+
+// containsAggregate reports whether e calls an aggregating function, making the projection it is part of group rows.
+func containsAggregate(e expr) bool {
+	switch e := e.(type) {
+	case *funcCall:
+		if isAggregate(e.name) {
+			return true
+		}
+		for _, a := range e.args {
+			if containsAggregate(a) {
+				return true
+			}
+		}
+	case *property:
+		return containsAggregate(e.subject)
+	case *index:
+		return containsAggregate(e.subject) || containsAggregate(e.index)
+	case *listLiteral:
+		for _, item := range e.items {
+			if containsAggregate(item) {
+				return true
+			}
+		}
+	case *mapLiteral:
+		for _, v := range e.values {
+			if containsAggregate(v) {
+				return true
+			}
+		}
+	case *unary:
+		return containsAggregate(e.operand)
+	case *binary:
+		return containsAggregate(e.left) || containsAggregate(e.right)
+	case *hasLabels:
+		return containsAggregate(e.subject)
+	}
+	return false
+}
+
+func (ev *evaluator) aggregate(f *funcCall, group []row) (interface{}, error) {
+	if group == nil {
+		return nil, errors.Errorf("boltmem: %s() can only be used in RETURN or WITH", f.name)
+	}
+	if f.star {
+		if f.name != "count" {
+			return nil, errors.Errorf("boltmem: %s(*) is not supported", f.name)
+		}
+		return int64(len(group)), nil
+	}
+	if len(f.args) != 1 {
+		return nil, errors.Errorf("boltmem: %s() expects a single argument", f.name)
+	}
+
+	var values []interface{}
+	for _, r := range group {
+		v, err := ev.eval(f.args[0], r, nil)
+		if err != nil {
+			return nil, err
+		}
+		if v == nil {
+			continue
+		}
+		if f.distinct && containsValue(values, v) {
+			continue
+		}
+		values = append(values, v)
+	}
+
+	switch f.name {
+	case "count":
+		return int64(len(values)), nil
+	case "collect":
+		if values == nil {
+			values = []interface{}{}
+		}
+		return values, nil
+	case "min", "max":
+		var result interface{}
+		for _, v := range values {
+			c := orderValues(v, result)
+			if result == nil || (f.name == "min" && c < 0) || (f.name == "max" && c > 0) {
+				result = v
+			}
+		}
+		return result, nil
+	}
+
+	var result interface{} = int64(0)
+	for _, v := range values {
+		if _, ok := toFloat(v); !ok {
+			return nil, errors.Errorf("boltmem: %s() expects numbers, not %s", f.name, typeName(v))
+		}
+		var err error
+		if result, err = arithmetic("+", result, v); err != nil {
+			return nil, err
+		}
+	}
+	if f.name == "avg" {
+		if len(values) == 0 {
+			return nil, nil
+		}
+		sum, _ := toFloat(result)
+		return sum / float64(len(values)), nil
+	}
+	return result, nil
+}
+
+func containsValue(values []interface{}, v interface{}) bool {
+	for _, w := range values {
+		if equal(v, w) == true {
+			return true
+		}
+	}
+	return false
+}
+
+func (ev *evaluator) call(f *funcCall, r row, group []row) (interface{}, error) {
+	args := make([]interface{}, len(f.args))
+	for i, a := range f.args {
+		v, err := ev.eval(a, r, group)
+		if err != nil {
+			return nil, err
+		}
+		args[i] = v
+	}
+
+	if f.name == "coalesce" {
+		for _, a := range args {
+			if a != nil {
+				return a, nil
+			}
+		}
+		return nil, nil
+	}
+
+	fn, ok := functions[f.name]
+	if !ok {
+		return nil, errors.Errorf("boltmem: unknown function %s()", f.name)
+	}
+	if len(args) < fn.minArgs || len(args) > fn.maxArgs {
+		return nil, errors.Errorf("boltmem: wrong number of arguments to %s()", f.name)
+	}
+	if args[0] == nil && f.name != "exists" {
+		return nil, nil
+	}
+	v, err := fn.call(args)
+	if err != nil {
+		return nil, errors.WithMessage(err, "boltmem: "+f.name+"()")
+	}
+	return v, nil
+}
+
+type function struct {
+	minArgs, maxArgs int
+	call             func(args []interface{}) (interface{}, error)
+}
+
+func unaryFunction(call func(v interface{}) (interface{}, error)) function {
+	return function{minArgs: 1, maxArgs: 1, call: func(args []interface{}) (interface{}, error) {
+		return call(args[0])
+	}}
+}
+
+func stringFunction(call func(s string) interface{}) function {
+	return unaryFunction(func(v interface{}) (interface{}, error) {
+		s, ok := v.(string)
+		if !ok {
+			return nil, errors.Errorf("expected a string, not %s", typeName(v))
+		}
+		return call(s), nil
+	})
+}
+
+var functions map[string]function
+
+func init() {
+	functions = map[string]function{
+		"id": unaryFunction(func(v interface{}) (interface{}, error) {
+			switch e := v.(type) {
+			case *node:
+				return e.id, nil
+			case *relationship:
+				return e.id, nil
+			}
+			return nil, errors.Errorf("expected a node or relationship, not %s", typeName(v))
+		}),
+		"labels": unaryFunction(func(v interface{}) (interface{}, error) {
+			n, ok := v.(*node)
+			if !ok {
+				return nil, errors.Errorf("expected a node, not %s", typeName(v))
+			}
+			labels := make([]interface{}, len(n.labels))
+			for i, l := range n.labels {
+				labels[i] = l
+			}
+			return labels, nil
+		}),
+		"type": unaryFunction(func(v interface{}) (interface{}, error) {
+			r, ok := v.(*relationship)
+			if !ok {
+				return nil, errors.Errorf("expected a relationship, not %s", typeName(v))
+			}
+			return r.typ, nil
+		}),
+		"startnode": unaryFunction(func(v interface{}) (interface{}, error) {
+			r, ok := v.(*relationship)
+			if !ok {
+				return nil, errors.Errorf("expected a relationship, not %s", typeName(v))
+			}
+			return r.start, nil
+		}),
+		"endnode": unaryFunction(func(v interface{}) (interface{}, error) {
+			r, ok := v.(*relationship)
+			if !ok {
+				return nil, errors.Errorf("expected a relationship, not %s", typeName(v))
+			}
+			return r.end, nil
+		}),
+		"nodes": unaryFunction(func(v interface{}) (interface{}, error) {
+			p, ok := v.(*path)
+			if !ok {
+				return nil, errors.Errorf("expected a path, not %s", typeName(v))
+			}
+			nodes := make([]interface{}, len(p.nodes))
+			for i, n := range p.nodes {
+				nodes[i] = n
+			}
+			return nodes, nil
+		}),
+		"relationships": unaryFunction(func(v interface{}) (interface{}, error) {
+			p, ok := v.(*path)
+			if !ok {
+				return nil, errors.Errorf("expected a path, not %s", typeName(v))
+			}
+			rels := make([]interface{}, len(p.rels))
+			for i, r := range p.rels {
+				rels[i] = r
+			}
+			return rels, nil
+		}),
+		"length": unaryFunction(func(v interface{}) (interface{}, error) {
+			p, ok := v.(*path)
+			if !ok {
+				return nil, errors.Errorf("expected a path, not %s", typeName(v))
+			}
+			return int64(len(p.rels)), nil
+		}),
+		"properties": unaryFunction(func(v interface{}) (interface{}, error) {
+			props, err := propertiesOf(v)
+			if err != nil {
+				return nil, err
+			}
+			return copyMap(props), nil
+		}),
+		"keys": unaryFunction(func(v interface{}) (interface{}, error) {
+			props, err := propertiesOf(v)
+			if err != nil {
+				return nil, err
+			}
+			keys := []interface{}{}
+			for _, k := range sortedKeys(props) {
+				keys = append(keys, k)
+			}
+			return keys, nil
+		}),
+		"exists": unaryFunction(func(v interface{}) (interface{}, error) {
+			return v != nil, nil
+		}),
+		"size": unaryFunction(func(v interface{}) (interface{}, error) {
+			switch t := v.(type) {
+			case string:
+				return int64(len([]rune(t))), nil
+			case []interface{}:
+				return int64(len(t)), nil
+			}
+			return nil, errors.Errorf("expected a string or list, not %s", typeName(v))
+		}),
+		"head": unaryFunction(func(v interface{}) (interface{}, error) {
+			l, ok := v.([]interface{})
+			if !ok {
+				return nil, errors.Errorf("expected a list, not %s", typeName(v))
+			}
+			if len(l) == 0 {
+				return nil, nil
+			}
+			return l[0], nil
+		}),
+		"last": unaryFunction(func(v interface{}) (interface{}, error) {
+			l, ok := v.([]interface{})
+			if !ok {
+				return nil, errors.Errorf("expected a list, not %s", typeName(v))
+			}
+			if len(l) == 0 {
+				return nil, nil
+			}
+			return l[len(l)-1], nil
+		}),
+		"tail": unaryFunction(func(v interface{}) (interface{}, error) {
+			l, ok := v.([]interface{})
+			if !ok {
+				return nil, errors.Errorf("expected a list, not %s", typeName(v))
+			}
+			if len(l) == 0 {
+				return []interface{}{}, nil
+			}
+			return append([]interface{}{}, l[1:]...), nil
+		}),
+		"tolower": stringFunction(func(s string) interface{} {
+			return strings.ToLower(s)
+		}),
+		"toupper": stringFunction(func(s string) interface{} {
+			return strings.ToUpper(s)
+		}),
+		"trim": stringFunction(func(s string) interface{} {
+			return strings.TrimSpace(s)
+		}),
+		"tostring": unaryFunction(func(v interface{}) (interface{}, error) {
+			switch v.(type) {
+			case string, int64, float64, bool:
+				return toString(v), nil
+			}
+			return nil, errors.Errorf("can not convert %s to a string", typeName(v))
+		}),
+		"tointeger": unaryFunction(func(v interface{}) (interface{}, error) {
+			switch t := v.(type) {
+			case int64:
+				return t, nil
+			case float64:
+				return int64(t), nil
+			case string:
+				if i, err := strconv.ParseInt(t, 10, 64); err == nil {
+					return i, nil
+				}
+				if f, err := strconv.ParseFloat(t, 64); err == nil {
+					return int64(f), nil
+				}
+				return nil, nil
+			}
+			return nil, errors.Errorf("can not convert %s to an integer", typeName(v))
+		}),
+		"tofloat": unaryFunction(func(v interface{}) (interface{}, error) {
+			switch t := v.(type) {
+			case int64:
+				return float64(t), nil
+			case float64:
+				return t, nil
+			case string:
+				if f, err := strconv.ParseFloat(t, 64); err == nil {
+					return f, nil
+				}
+				return nil, nil
+			}
+			return nil, errors.Errorf("can not convert %s to a float", typeName(v))
+		}),
+		"split": {minArgs: 2, maxArgs: 2, call: func(args []interface{}) (interface{}, error) {
+			s, ok1 := args[0].(string)
+			sep, ok2 := args[1].(string)
+			if !ok1 || !ok2 {
+				return nil, errors.New("expected strings")
+			}
+			l := []interface{}{}
+			for _, part := range strings.Split(s, sep) {
+				l = append(l, part)
+			}
+			return l, nil
+		}},
+		"replace": {minArgs: 3, maxArgs: 3, call: func(args []interface{}) (interface{}, error) {
+			s, ok1 := args[0].(string)
+			old, ok2 := args[1].(string)
+			repl, ok3 := args[2].(string)
+			if !ok1 || !ok2 || !ok3 {
+				return nil, errors.New("expected strings")
+			}
+			return strings.Replace(s, old, repl, -1), nil
+		}},
+		"range": {minArgs: 2, maxArgs: 3, call: func(args []interface{}) (interface{}, error) {
+			step := interface{}(int64(1))
+			if len(args) == 3 {
+				step = args[2]
+			}
+			start, ok1 := args[0].(int64)
+			end, ok2 := args[1].(int64)
+			by, ok3 := step.(int64)
+			if !ok1 || !ok2 || !ok3 || by == 0 {
+				return nil, errors.New("expected integer bounds and a non-zero step")
+			}
+			l := []interface{}{}
+			for i := start; (by > 0 && i <= end) || (by < 0 && i >= end); i += by {
+				l = append(l, i)
+			}
+			return l, nil
+		}},
+	}
+}
+
+func propertiesOf(v interface{}) (map[string]interface{}, error) {
+	switch t := v.(type) {
+	case *node:
+		return t.props, nil
+	case *relationship:
+		return t.props, nil
+	case map[string]interface{}:
+		return t, nil
+	}
+	return nil, errors.Errorf("expected a node, relationship or map, not %s", typeName(v))
+}
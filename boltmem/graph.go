@@ -0,0 +1,188 @@
+package boltmem
+
+import (
+	"sort"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+)
+
+type node struct {
+	id     int64
+	labels []string
+	props  map[string]interface{}
+	// rels holds the relationships starting or ending at the node, keyed by id.
+	rels    map[int64]*relationship
+	deleted bool
+}
+
+func (n *node) hasLabel(label string) bool {
+	for _, l := range n.labels {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
+
+func (n *node) toGraph() graph.Node {
+	return graph.Node{
+		NodeIdentity: n.id,
+		Labels:       append([]string{}, n.labels...),
+		Properties:   copyMap(n.props),
+	}
+}
+
+type relationship struct {
+	id         int64
+	typ        string
+	start, end *node
+	props      map[string]interface{}
+	deleted    bool
+}
+
+func (r *relationship) toGraph() graph.Relationship {
+	return graph.Relationship{
+		RelIdentity:       r.id,
+		StartNodeIdentity: r.start.id,
+		EndNodeIdentity:   r.end.id,
+		Type:              r.typ,
+		Properties:        copyMap(r.props),
+	}
+}
+
+// path is a walk through alternating nodes and relationships, holding one more node than relationships.
+type path struct {
+	nodes []*node
+	rels  []*relationship
+}
+
+// extend returns a copy of the path continued through rel to n.
+func (p *path) extend(rel *relationship, n *node) *path {
+	return &path{
+		nodes: append(append(make([]*node, 0, len(p.nodes)+1), p.nodes...), n),
+		rels:  append(append(make([]*relationship, 0, len(p.rels)+1), p.rels...), rel),
+	}
+}
+
+// toGraph lists each node and relationship of the path once, as the server does, with a sequence of the 1-based
+// index of each relationship followed and the index of the node it leads to. A relationship followed against its
+// direction has a negative index.
+func (p *path) toGraph() graph.Path {
+	gp := graph.Path{
+		Nodes:         []graph.Node{p.nodes[0].toGraph()},
+		Relationships: []graph.UnboundRelationship{},
+		Sequence:      []int{},
+	}
+	nodeIndex := map[int64]int{p.nodes[0].id: 0}
+	relIndex := map[int64]int{}
+	for i, rel := range p.rels {
+		n := p.nodes[i+1]
+		ni, ok := nodeIndex[n.id]
+		if !ok {
+			ni = len(gp.Nodes)
+			nodeIndex[n.id] = ni
+			gp.Nodes = append(gp.Nodes, n.toGraph())
+		}
+		ri, ok := relIndex[rel.id]
+		if !ok {
+			gp.Relationships = append(gp.Relationships, graph.UnboundRelationship{
+				RelIdentity: rel.id,
+				Type:        rel.typ,
+				Properties:  copyMap(rel.props),
+			})
+			ri = len(gp.Relationships)
+			relIndex[rel.id] = ri
+		}
+		if rel.start != p.nodes[i] {
+			ri = -ri
+		}
+		gp.Sequence = append(gp.Sequence, ri, ni)
+	}
+	return gp
+}
+
+// store is an in-memory property graph. It is not safe for concurrent use, the Pool serialising access to it.
+type store struct {
+	nodes  map[int64]*node
+	rels   map[int64]*relationship
+	nextID int64
+}
+
+func newStore() *store {
+	return &store{nodes: make(map[int64]*node), rels: make(map[int64]*relationship)}
+}
+
+func (s *store) createNode(labels []string, props map[string]interface{}) *node {
+	n := &node{id: s.nextID, labels: labels, props: props, rels: make(map[int64]*relationship)}
+	s.nextID++
+	s.nodes[n.id] = n
+	return n
+}
+
+func (s *store) createRelationship(typ string, start, end *node, props map[string]interface{}) *relationship {
+	r := &relationship{id: s.nextID, typ: typ, start: start, end: end, props: props}
+	s.nextID++
+	s.rels[r.id] = r
+	start.rels[r.id] = r
+	end.rels[r.id] = r
+	return r
+}
+
+func (s *store) deleteRelationship(r *relationship) {
+	if r.deleted {
+		return
+	}
+	r.deleted = true
+	delete(s.rels, r.id)
+	delete(r.start.rels, r.id)
+	delete(r.end.rels, r.id)
+}
+
+func (s *store) deleteNode(n *node) {
+	n.deleted = true
+	delete(s.nodes, n.id)
+}
+
+// allNodes returns the nodes in id order so results are deterministic.
+func (s *store) allNodes() []*node {
+	nodes := make([]*node, 0, len(s.nodes))
+	for _, n := range s.nodes {
+		nodes = append(nodes, n)
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].id < nodes[j].id })
+	return nodes
+}
+
+// relationshipsOf returns the relationships of n in id order.
+func relationshipsOf(n *node) []*relationship {
+	rels := make([]*relationship, 0, len(n.rels))
+	for _, r := range n.rels {
+		rels = append(rels, r)
+	}
+	sort.Slice(rels, func(i, j int) bool { return rels[i].id < rels[j].id })
+	return rels
+}
+
+// clone returns a deep copy of the store, used to roll back transactions.
+func (s *store) clone() *store {
+	c := &store{nodes: make(map[int64]*node, len(s.nodes)), rels: make(map[int64]*relationship, len(s.rels)), nextID: s.nextID}
+	for id, n := range s.nodes {
+		c.nodes[id] = &node{id: id, labels: append([]string{}, n.labels...), props: copyMap(n.props), rels: make(map[int64]*relationship)}
+	}
+	for id, r := range s.rels {
+		start, end := c.nodes[r.start.id], c.nodes[r.end.id]
+		cr := &relationship{id: id, typ: r.typ, start: start, end: end, props: copyMap(r.props)}
+		c.rels[id] = cr
+		start.rels[id] = cr
+		end.rels[id] = cr
+	}
+	return c
+}
+
+func copyMap(m map[string]interface{}) map[string]interface{} {
+	c := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		c[k] = v
+	}
+	return c
+}
@@ -0,0 +1,184 @@
+package boltmem
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/pkg/errors"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokIdent
+	tokString
+	tokInt
+	tokFloat
+	tokParam
+	tokSymbol
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// is reports whether the token is the keyword or symbol s, keywords being matched case insensitively.
+func (t token) is(s string) bool {
+	if t.kind == tokIdent {
+		return strings.EqualFold(t.text, s)
+	}
+	return t.kind == tokSymbol && t.text == s
+}
+
+var symbols = []string{"<>", "<=", ">=", "=~", "+=", "->", "<-", "..", "(", ")", "[", "]", "{", "}", ":", ",", ".",
+	"=", "<", ">", "+", "-", "*", "/", "%", "|", "^"}
+
+func lex(query string) ([]token, error) {
+	var tokens []token
+	r := []rune(query)
+
+	for i := 0; i < len(r); {
+		c := r[i]
+		switch {
+		case unicode.IsSpace(c):
+			i++
+
+		case c == '/' && i+1 < len(r) && r[i+1] == '/':
+			for i < len(r) && r[i] != '\n' {
+				i++
+			}
+
+		case c == '\'' || c == '"':
+			s, n, err := lexString(r, i)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokString, text: s, pos: i})
+			i = n
+
+		case c == '`':
+			// A backtick within a quoted identifier is escaped by doubling it.
+			var ident []rune
+			end := i + 1
+			for ; end < len(r); end++ {
+				if r[end] == '`' {
+					if end+1 < len(r) && r[end+1] == '`' {
+						end++
+					} else {
+						break
+					}
+				}
+				ident = append(ident, r[end])
+			}
+			if end == len(r) {
+				return nil, errors.Errorf("boltmem: unterminated identifier at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(ident), pos: i})
+			i = end + 1
+
+		case c == '$':
+			end := i + 1
+			for end < len(r) && isIdentRune(r[end]) {
+				end++
+			}
+			if end == i+1 {
+				return nil, errors.Errorf("boltmem: expected a parameter name at position %d", i)
+			}
+			tokens = append(tokens, token{kind: tokParam, text: string(r[i+1 : end]), pos: i})
+			i = end
+
+		case unicode.IsDigit(c):
+			end := i
+			for end < len(r) && unicode.IsDigit(r[end]) {
+				end++
+			}
+			kind := tokInt
+			if end+1 < len(r) && r[end] == '.' && unicode.IsDigit(r[end+1]) {
+				kind = tokFloat
+				end++
+				for end < len(r) && unicode.IsDigit(r[end]) {
+					end++
+				}
+			}
+			if end < len(r) && (r[end] == 'e' || r[end] == 'E') {
+				kind = tokFloat
+				end++
+				if end < len(r) && (r[end] == '-' || r[end] == '+') {
+					end++
+				}
+				for end < len(r) && unicode.IsDigit(r[end]) {
+					end++
+				}
+			}
+			tokens = append(tokens, token{kind: kind, text: string(r[i:end]), pos: i})
+			i = end
+
+		case isIdentRune(c):
+			end := i
+			for end < len(r) && isIdentRune(r[end]) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokIdent, text: string(r[i:end]), pos: i})
+			i = end
+
+		default:
+			matched := false
+			for _, s := range symbols {
+				if strings.HasPrefix(string(r[i:min(i+len(s), len(r))]), s) {
+					tokens = append(tokens, token{kind: tokSymbol, text: s, pos: i})
+					i += len(s)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, errors.Errorf("boltmem: unexpected character %q at position %d", c, i)
+			}
+		}
+	}
+
+	return append(tokens, token{kind: tokEOF, pos: len(r)}), nil
+}
+
+func lexString(r []rune, start int) (string, int, error) {
+	quote := r[start]
+	var b strings.Builder
+	for i := start + 1; i < len(r); i++ {
+		switch r[i] {
+		case quote:
+			return b.String(), i + 1, nil
+		case '\\':
+			i++
+			if i == len(r) {
+				break
+			}
+			switch r[i] {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			case 'r':
+				b.WriteRune('\r')
+			default:
+				b.WriteRune(r[i])
+			}
+		default:
+			b.WriteRune(r[i])
+		}
+	}
+	return "", 0, errors.Errorf("boltmem: unterminated string at position %d", start)
+}
+
+func isIdentRune(c rune) bool {
+	return c == '_' || unicode.IsLetter(c) || unicode.IsDigit(c)
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
@@ -0,0 +1,867 @@
+package boltmem
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+type parser struct {
+	query  string
+	runes  []rune
+	tokens []token
+	pos    int
+}
+
+func parse(query string) ([]interface{}, error) {
+	tokens, err := lex(query)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{query: query, runes: []rune(query), tokens: tokens}
+
+	var clauses []interface{}
+	for p.peek().kind != tokEOF {
+		c, err := p.clause()
+		if err != nil {
+			return nil, err
+		}
+		clauses = append(clauses, c)
+	}
+	if len(clauses) == 0 {
+		return nil, errors.New("boltmem: empty query")
+	}
+	return clauses, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) peekAt(n int) token {
+	if p.pos+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.pos+n]
+}
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *parser) accept(s string) bool {
+	if p.peek().is(s) {
+		p.pos++
+		return true
+	}
+	return false
+}
+
+func (p *parser) expect(s string) error {
+	if !p.accept(s) {
+		return p.errorf("expected %s", s)
+	}
+	return nil
+}
+
+func (p *parser) ident() (string, error) {
+	t := p.peek()
+	if t.kind != tokIdent {
+		return "", p.errorf("expected an identifier")
+	}
+	p.pos++
+	return t.text, nil
+}
+
+func (p *parser) errorf(format string, args ...interface{}) error {
+	t := p.peek()
+	found := t.text
+	if t.kind == tokEOF {
+		found = "end of query"
+	}
+	return errors.Errorf("boltmem: %s but found %q at position %d in %q", fmt.Sprintf(format, args...), found, t.pos, p.query)
+}
+
+func (p *parser) clause() (interface{}, error) {
+	switch {
+	case p.accept("OPTIONAL"):
+		if err := p.expect("MATCH"); err != nil {
+			return nil, err
+		}
+		return p.match(true)
+	case p.accept("MATCH"):
+		return p.match(false)
+	case p.accept("CREATE"):
+		patterns, err := p.patterns()
+		if err != nil {
+			return nil, err
+		}
+		for _, pat := range patterns {
+			if err := p.creatable(pat); err != nil {
+				return nil, err
+			}
+		}
+		return &createClause{patterns: patterns}, nil
+	case p.accept("MERGE"):
+		return p.merge()
+	case p.accept("SET"):
+		items, err := p.setItems()
+		if err != nil {
+			return nil, err
+		}
+		return &setClause{items: items}, nil
+	case p.accept("REMOVE"):
+		return p.remove()
+	case p.accept("DETACH"):
+		if err := p.expect("DELETE"); err != nil {
+			return nil, err
+		}
+		return p.delete(true)
+	case p.accept("DELETE"):
+		return p.delete(false)
+	case p.accept("UNWIND"):
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.expect("AS"); err != nil {
+			return nil, err
+		}
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		return &unwindClause{expr: e, variable: name}, nil
+	case p.accept("WITH"):
+		proj, err := p.projection(true)
+		if err != nil {
+			return nil, err
+		}
+		return &withClause{projection: proj}, nil
+	case p.accept("RETURN"):
+		proj, err := p.projection(false)
+		if err != nil {
+			return nil, err
+		}
+		if p.peek().kind != tokEOF {
+			return nil, p.errorf("expected end of query after RETURN")
+		}
+		return &returnClause{projection: proj}, nil
+	}
+	return nil, p.errorf("expected a clause")
+}
+
+func (p *parser) match(optional bool) (interface{}, error) {
+	patterns, err := p.patterns()
+	if err != nil {
+		return nil, err
+	}
+	c := &matchClause{optional: optional, patterns: patterns}
+	if p.accept("WHERE") {
+		if c.where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) merge() (interface{}, error) {
+	pat, err := p.pattern()
+	if err != nil {
+		return nil, err
+	}
+	if err := p.creatable(pat); err != nil {
+		return nil, err
+	}
+	c := &mergeClause{pattern: pat}
+	for p.peek().is("ON") {
+		p.next()
+		switch {
+		case p.accept("CREATE"):
+			if err := p.expect("SET"); err != nil {
+				return nil, err
+			}
+			items, err := p.setItems()
+			if err != nil {
+				return nil, err
+			}
+			c.onCreate = append(c.onCreate, items...)
+		case p.accept("MATCH"):
+			if err := p.expect("SET"); err != nil {
+				return nil, err
+			}
+			items, err := p.setItems()
+			if err != nil {
+				return nil, err
+			}
+			c.onMatch = append(c.onMatch, items...)
+		default:
+			return nil, p.errorf("expected CREATE or MATCH")
+		}
+	}
+	return c, nil
+}
+
+func (p *parser) setItems() ([]*setItem, error) {
+	var items []*setItem
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		item := &setItem{variable: name}
+		switch {
+		case p.accept("."):
+			if item.key, err = p.ident(); err != nil {
+				return nil, err
+			}
+			if err := p.expect("="); err != nil {
+				return nil, err
+			}
+			item.kind = "property"
+		case p.accept("="):
+			item.kind = "replace"
+		case p.accept("+="):
+			item.kind = "merge"
+		case p.peek().is(":"):
+			if item.labels, err = p.labels(); err != nil {
+				return nil, err
+			}
+			item.kind = "labels"
+		default:
+			return nil, p.errorf("expected a property, label or map to set")
+		}
+		if item.kind != "labels" {
+			if item.value, err = p.expr(); err != nil {
+				return nil, err
+			}
+		}
+		items = append(items, item)
+		if !p.accept(",") {
+			return items, nil
+		}
+	}
+}
+
+func (p *parser) remove() (interface{}, error) {
+	c := &removeClause{}
+	for {
+		name, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		item := &removeItem{variable: name}
+		if p.accept(".") {
+			if item.key, err = p.ident(); err != nil {
+				return nil, err
+			}
+		} else if item.labels, err = p.labels(); err != nil {
+			return nil, err
+		}
+		c.items = append(c.items, item)
+		if !p.accept(",") {
+			return c, nil
+		}
+	}
+}
+
+func (p *parser) delete(detach bool) (interface{}, error) {
+	c := &deleteClause{detach: detach}
+	for {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		c.exprs = append(c.exprs, e)
+		if !p.accept(",") {
+			return c, nil
+		}
+	}
+}
+
+func (p *parser) projection(with bool) (*projection, error) {
+	proj := &projection{distinct: p.accept("DISTINCT")}
+
+	if p.accept("*") {
+		proj.star = true
+	} else {
+		for {
+			start := p.peek().pos
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			item := &returnItem{expr: e}
+			if p.accept("AS") {
+				if item.alias, err = p.ident(); err != nil {
+					return nil, err
+				}
+			} else {
+				item.alias = strings.TrimSpace(string(p.runes[start:p.peek().pos]))
+				if v, ok := e.(*variable); ok {
+					item.alias = v.name
+				}
+			}
+			proj.items = append(proj.items, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	if p.peek().is("ORDER") {
+		p.next()
+		if err := p.expect("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			item := &sortItem{expr: e}
+			switch {
+			case p.accept("DESC"), p.accept("DESCENDING"):
+				item.descending = true
+			case p.accept("ASC"), p.accept("ASCENDING"):
+			}
+			proj.order = append(proj.order, item)
+			if !p.accept(",") {
+				break
+			}
+		}
+	}
+
+	var err error
+	if p.accept("SKIP") {
+		if proj.skip, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if p.accept("LIMIT") {
+		if proj.limit, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	if with && p.accept("WHERE") {
+		if proj.where, err = p.expr(); err != nil {
+			return nil, err
+		}
+	}
+	return proj, nil
+}
+
+func (p *parser) patterns() ([]*pattern, error) {
+	var patterns []*pattern
+	for {
+		pat, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+		patterns = append(patterns, pat)
+		if !p.accept(",") {
+			return patterns, nil
+		}
+	}
+}
+
+func (p *parser) pattern() (*pattern, error) {
+	var variable string
+	if p.peek().kind == tokIdent && p.peekAt(1).is("=") {
+		variable = p.next().text
+		p.next()
+	}
+	n, err := p.nodePattern()
+	if err != nil {
+		return nil, err
+	}
+	pat := &pattern{variable: variable, nodes: []*nodePattern{n}}
+
+	for p.peek().is("-") || p.peek().is("<-") {
+		r, err := p.relPattern()
+		if err != nil {
+			return nil, err
+		}
+		n, err := p.nodePattern()
+		if err != nil {
+			return nil, err
+		}
+		pat.rels = append(pat.rels, r)
+		pat.nodes = append(pat.nodes, n)
+	}
+	return pat, nil
+}
+
+// creatable returns an error if pat has parts that can only be matched.
+func (p *parser) creatable(pat *pattern) error {
+	if pat.variable != "" {
+		return errors.Errorf("boltmem: a path variable can not be created in %q", p.query)
+	}
+	for _, r := range pat.rels {
+		if r.varLength {
+			return errors.Errorf("boltmem: a variable length relationship can not be created in %q", p.query)
+		}
+	}
+	return nil
+}
+
+func (p *parser) nodePattern() (*nodePattern, error) {
+	if err := p.expect("("); err != nil {
+		return nil, err
+	}
+	n := &nodePattern{}
+	if p.peek().kind == tokIdent {
+		n.variable = p.next().text
+	}
+
+	var err error
+	if p.peek().is(":") {
+		if n.labels, err = p.labels(); err != nil {
+			return nil, err
+		}
+	}
+	if p.peek().is("{") || p.peek().kind == tokParam {
+		if n.props, err = p.patternProps(); err != nil {
+			return nil, err
+		}
+	}
+	return n, p.expect(")")
+}
+
+func (p *parser) relPattern() (*relPattern, error) {
+	r := &relPattern{}
+	incoming := p.accept("<-")
+	if !incoming {
+		if err := p.expect("-"); err != nil {
+			return nil, err
+		}
+	}
+
+	if p.accept("[") {
+		if p.peek().kind == tokIdent {
+			r.variable = p.next().text
+		}
+		if p.accept(":") {
+			for {
+				t, err := p.ident()
+				if err != nil {
+					return nil, err
+				}
+				r.types = append(r.types, t)
+				if !p.accept("|") {
+					break
+				}
+				p.accept(":")
+			}
+		}
+		if p.accept("*") {
+			if err := p.hops(r); err != nil {
+				return nil, err
+			}
+		}
+		if p.peek().is("{") || p.peek().kind == tokParam {
+			var err error
+			if r.props, err = p.patternProps(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.expect("]"); err != nil {
+			return nil, err
+		}
+	}
+
+	outgoing := p.accept("->")
+	if !outgoing {
+		if err := p.expect("-"); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case incoming && outgoing:
+		return nil, errors.Errorf("boltmem: a relationship can not point in both directions in %q", p.query)
+	case incoming:
+		r.direction = -1
+	case outgoing:
+		r.direction = 1
+	}
+	return r, nil
+}
+
+// hops parses the bounds following the * of a variable length relationship: *, *n, *min.., *..max or *min..max.
+func (p *parser) hops(r *relPattern) error {
+	r.varLength = true
+	r.minHops, r.maxHops = 1, -1
+	if p.peek().kind == tokInt {
+		n, err := strconv.Atoi(p.next().text)
+		if err != nil {
+			return err
+		}
+		r.minHops, r.maxHops = n, n
+	}
+	if !p.accept("..") {
+		return nil
+	}
+	r.maxHops = -1
+	if p.peek().kind == tokInt {
+		n, err := strconv.Atoi(p.next().text)
+		if err != nil {
+			return err
+		}
+		r.maxHops = n
+	}
+	if r.maxHops >= 0 && r.maxHops < r.minHops {
+		return p.errorf("expected a maximum length of at least %d", r.minHops)
+	}
+	return nil
+}
+
+// patternProps parses the properties of a node or relationship pattern, which may be a map literal or a parameter
+// holding a map.
+func (p *parser) patternProps() (expr, error) {
+	e, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	switch e.(type) {
+	case *mapLiteral, *param:
+		return e, nil
+	}
+	return nil, p.errorf("expected a map of properties")
+}
+
+func (p *parser) labels() ([]string, error) {
+	var labels []string
+	for p.accept(":") {
+		l, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		labels = append(labels, l)
+	}
+	return labels, nil
+}
+
+func (p *parser) expr() (expr, error) {
+	return p.or()
+}
+
+func (p *parser) or() (expr, error) {
+	left, err := p.xor()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("OR") {
+		right, err := p.xor()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "OR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) xor() (expr, error) {
+	left, err := p.and()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("XOR") {
+		right, err := p.and()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "XOR", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) and() (expr, error) {
+	left, err := p.not()
+	if err != nil {
+		return nil, err
+	}
+	for p.accept("AND") {
+		right, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: "AND", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) not() (expr, error) {
+	if p.accept("NOT") {
+		operand, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: "NOT", operand: operand}, nil
+	}
+	return p.comparison()
+}
+
+func (p *parser) comparison() (expr, error) {
+	left, err := p.additive()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		t := p.peek()
+		var op string
+		switch {
+		case t.is("="), t.is("<>"), t.is("<"), t.is(">"), t.is("<="), t.is(">="), t.is("=~"):
+			op = t.text
+			p.next()
+		case t.is("IN"):
+			op = "IN"
+			p.next()
+		case t.is("CONTAINS"):
+			op = "CONTAINS"
+			p.next()
+		case t.is("STARTS") && p.peekAt(1).is("WITH"):
+			op = "STARTS WITH"
+			p.pos += 2
+		case t.is("ENDS") && p.peekAt(1).is("WITH"):
+			op = "ENDS WITH"
+			p.pos += 2
+		case t.is("IS"):
+			p.next()
+			op = "IS NULL"
+			if p.accept("NOT") {
+				op = "IS NOT NULL"
+			}
+			if err := p.expect("NULL"); err != nil {
+				return nil, err
+			}
+			left = &unary{op: op, operand: left}
+			continue
+		default:
+			return left, nil
+		}
+
+		right, err := p.additive()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+}
+
+func (p *parser) additive() (expr, error) {
+	left, err := p.multiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("+") || p.peek().is("-") {
+		op := p.next().text
+		right, err := p.multiplicative()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) multiplicative() (expr, error) {
+	left, err := p.unary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().is("*") || p.peek().is("/") || p.peek().is("%") || p.peek().is("^") {
+		op := p.next().text
+		right, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		left = &binary{op: op, left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *parser) unary() (expr, error) {
+	if p.accept("-") {
+		operand, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &unary{op: "-", operand: operand}, nil
+	}
+	p.accept("+")
+	return p.postfix()
+}
+
+func (p *parser) postfix() (expr, error) {
+	e, err := p.primary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		switch {
+		case p.accept("."):
+			key, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			e = &property{subject: e, key: key}
+		case p.peek().is("["):
+			p.next()
+			i, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			if err := p.expect("]"); err != nil {
+				return nil, err
+			}
+			e = &index{subject: e, index: i}
+		case p.peek().is(":") && p.peekAt(1).kind == tokIdent:
+			labels, err := p.labels()
+			if err != nil {
+				return nil, err
+			}
+			e = &hasLabels{subject: e, labels: labels}
+		default:
+			return e, nil
+		}
+	}
+}
+
+func (p *parser) primary() (expr, error) {
+	t := p.peek()
+	switch t.kind {
+	case tokString:
+		p.next()
+		return &literal{value: t.text}, nil
+	case tokInt:
+		p.next()
+		i, err := strconv.ParseInt(t.text, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("boltmem: invalid integer %s at position %d", t.text, t.pos)
+		}
+		return &literal{value: i}, nil
+	case tokFloat:
+		p.next()
+		f, err := strconv.ParseFloat(t.text, 64)
+		if err != nil {
+			return nil, errors.Errorf("boltmem: invalid float %s at position %d", t.text, t.pos)
+		}
+		return &literal{value: f}, nil
+	case tokParam:
+		p.next()
+		return &param{name: t.text}, nil
+	case tokIdent:
+		return p.identifier()
+	}
+
+	switch {
+	case p.accept("("):
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		return e, p.expect(")")
+
+	case p.accept("["):
+		l := &listLiteral{}
+		if p.accept("]") {
+			return l, nil
+		}
+		for {
+			e, err := p.expr()
+			if err != nil {
+				return nil, err
+			}
+			l.items = append(l.items, e)
+			if !p.accept(",") {
+				break
+			}
+		}
+		return l, p.expect("]")
+
+	case p.peek().is("{"):
+		// {name} is the legacy parameter syntax, anything else is a map literal.
+		if p.peekAt(1).kind == tokIdent && p.peekAt(2).is("}") {
+			name := p.peekAt(1).text
+			p.pos += 3
+			return &param{name: name}, nil
+		}
+		return p.mapLiteral()
+	}
+	return nil, p.errorf("expected an expression")
+}
+
+func (p *parser) identifier() (expr, error) {
+	t := p.next()
+	switch strings.ToUpper(t.text) {
+	case "TRUE":
+		return &literal{value: true}, nil
+	case "FALSE":
+		return &literal{value: false}, nil
+	case "NULL":
+		return &literal{value: nil}, nil
+	}
+
+	if !p.accept("(") {
+		return &variable{name: t.text}, nil
+	}
+
+	f := &funcCall{name: strings.ToLower(t.text)}
+	if p.accept("*") {
+		f.star = true
+		return f, p.expect(")")
+	}
+	f.distinct = p.accept("DISTINCT")
+	if p.accept(")") {
+		return f, nil
+	}
+	for {
+		e, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		f.args = append(f.args, e)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return f, p.expect(")")
+}
+
+func (p *parser) mapLiteral() (*mapLiteral, error) {
+	if err := p.expect("{"); err != nil {
+		return nil, err
+	}
+	m := &mapLiteral{}
+	if p.accept("}") {
+		return m, nil
+	}
+	for {
+		t := p.next()
+		if t.kind != tokIdent && t.kind != tokString {
+			p.pos--
+			return nil, p.errorf("expected a map key")
+		}
+		if err := p.expect(":"); err != nil {
+			return nil, err
+		}
+		v, err := p.expr()
+		if err != nil {
+			return nil, err
+		}
+		m.keys = append(m.keys, t.text)
+		m.values = append(m.values, v)
+		if !p.accept(",") {
+			break
+		}
+	}
+	return m, p.expect("}")
+}
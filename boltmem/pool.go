@@ -0,0 +1,338 @@
+// Package boltmem provides a bolt.DBPool backed by an in-memory property graph, executing a practical subset of
+// Cypher so repository code can be unit tested end to end without a Neo4j instance.
+//
+// Supported clauses are MATCH, OPTIONAL MATCH, WHERE, CREATE, MERGE with ON CREATE SET and ON MATCH SET, SET, REMOVE,
+// DELETE, DETACH DELETE, UNWIND, WITH and RETURN with DISTINCT, ORDER BY, SKIP and LIMIT. Patterns may use labels,
+// property maps and relationships in either direction. A relationship may have a variable length, as in
+// (a)-[:PARENT_OF*..3]->(b), when matched, and a matched pattern may be bound to a path variable, as in p = (a)-[]-(b),
+// for use with nodes(), relationships() and length(). CASE, list comprehensions, indexes and constraints are not
+// supported.
+package boltmem
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+var (
+	ErrPoolClosed           = errors.New("boltmem: pool is closed")
+	ErrConnClosed           = errors.New("boltmem: connection is closed")
+	ErrTxInProgress         = errors.New("boltmem: a transaction is already in progress on the connection")
+	ErrTxConflict           = errors.New("boltmem: another connection has a transaction open, which is not isolated")
+	ErrPipelineNotSupported = errors.New("boltmem: pipelined statements are not supported")
+)
+
+// Pool is a bolt.DBPool whose connections all share a single in-memory graph. Statements are executed one at a
+// time, so a Pool is safe for concurrent use. Each statement is applied in full or, if it fails, not at all.
+//
+// Transactions are not isolated, so while one is open, beginning another or writing on another connection fails
+// with ErrTxConflict rather than being undone by its rollback.
+type Pool struct {
+	mutex   sync.Mutex
+	store   *store
+	queries map[string][]interface{}
+	tx      *tx
+	closed  bool
+}
+
+// NewPool creates a Pool with an empty graph.
+func NewPool() *Pool {
+	return &Pool{store: newStore(), queries: make(map[string][]interface{})}
+}
+
+// OpenPool returns a connection to the graph.
+func (p *Pool) OpenPool() (neo4j.Conn, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	return &conn{pool: p}, nil
+}
+
+// Close closes the pool, the graph is kept until Reset is called.
+func (p *Pool) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.closed = true
+	return nil
+}
+
+// Reset empties the graph.
+func (p *Pool) Reset() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.store = newStore()
+}
+
+// execute runs the query for c on a copy of the graph, which replaces the graph only if the query succeeds.
+func (p *Pool) execute(c *conn, query string, params map[string]interface{}) (*result, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	clauses, ok := p.queries[query]
+	if !ok {
+		var err error
+		if clauses, err = parse(query); err != nil {
+			return nil, err
+		}
+		p.queries[query] = clauses
+	}
+
+	working := p.store.clone()
+	res, err := run(working, clauses, params)
+	if err != nil {
+		return nil, err
+	}
+	if res.write {
+		if p.tx != nil && p.tx.conn != c {
+			return nil, ErrTxConflict
+		}
+		p.store = working
+	}
+	return res, nil
+}
+
+type conn struct {
+	pool   *Pool
+	tx     *tx
+	closed bool
+}
+
+func (c *conn) QueryNeo(query string, params map[string]interface{}) (neo4j.Rows, error) {
+	if c.closed {
+		return nil, ErrConnClosed
+	}
+	res, err := c.pool.execute(c, query, params)
+	if err != nil {
+		return nil, err
+	}
+	return newRows(res), nil
+}
+
+func (c *conn) QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	rows, err := c.QueryNeo(query, params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer rows.Close()
+
+	data, summary, err := rows.All()
+	return data, rows.Metadata(), summary, err
+}
+
+func (c *conn) ExecNeo(query string, params map[string]interface{}) (neo4j.Result, error) {
+	if c.closed {
+		return nil, ErrConnClosed
+	}
+	res, err := c.pool.execute(c, query, params)
+	if err != nil {
+		return nil, err
+	}
+	return execResult{metadata: map[string]interface{}{"type": queryType(res), "stats": res.stats}}, nil
+}
+
+func (c *conn) PrepareNeo(query string) (neo4j.Stmt, error) {
+	if c.closed {
+		return nil, ErrConnClosed
+	}
+	return &stmt{conn: c, query: query}, nil
+}
+
+// Begin snapshots the graph so the transaction can be rolled back. As transactions are not isolated, no other
+// connection may begin one or write until it ends.
+func (c *conn) Begin() (driver.Tx, error) {
+	if c.closed {
+		return nil, ErrConnClosed
+	}
+	if c.tx != nil {
+		return nil, ErrTxInProgress
+	}
+
+	c.pool.mutex.Lock()
+	defer c.pool.mutex.Unlock()
+	if c.pool.tx != nil {
+		return nil, ErrTxConflict
+	}
+	c.tx = &tx{conn: c, snapshot: c.pool.store.clone()}
+	c.pool.tx = c.tx
+	return c.tx, nil
+}
+
+func (c *conn) Close() error {
+	if c.tx != nil {
+		c.tx.Rollback()
+	}
+	c.closed = true
+	return nil
+}
+
+func (c *conn) SetChunkSize(uint16) {}
+
+func (c *conn) SetTimeout(time.Duration) {}
+
+func (c *conn) PreparePipeline(query ...string) (neo4j.PipelineStmt, error) {
+	return nil, ErrPipelineNotSupported
+}
+
+func (c *conn) QueryPipeline(query []string, params ...map[string]interface{}) (neo4j.PipelineRows, error) {
+	return nil, ErrPipelineNotSupported
+}
+
+func (c *conn) ExecPipeline(query []string, params ...map[string]interface{}) ([]neo4j.Result, error) {
+	return nil, ErrPipelineNotSupported
+}
+
+type tx struct {
+	conn     *conn
+	snapshot *store
+}
+
+func (t *tx) Commit() error {
+	if t.conn.tx != t {
+		return errors.New("boltmem: transaction has already been committed or rolled back")
+	}
+	t.conn.tx = nil
+
+	p := t.conn.pool
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.tx = nil
+	return nil
+}
+
+// Rollback restores the graph to the snapshot taken by Begin, which only undoes the transaction's own writes as no
+// other connection can write while it is open.
+func (t *tx) Rollback() error {
+	if t.conn.tx != t {
+		return errors.New("boltmem: transaction has already been committed or rolled back")
+	}
+	t.conn.tx = nil
+
+	p := t.conn.pool
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.store = t.snapshot
+	p.tx = nil
+	return nil
+}
+
+type stmt struct {
+	conn  *conn
+	query string
+}
+
+func (s *stmt) QueryNeo(params map[string]interface{}) (neo4j.Rows, error) {
+	return s.conn.QueryNeo(s.query, params)
+}
+
+func (s *stmt) ExecNeo(params map[string]interface{}) (neo4j.Result, error) {
+	return s.conn.ExecNeo(s.query, params)
+}
+
+func (s *stmt) Close() error {
+	return nil
+}
+
+func queryType(res *result) string {
+	switch {
+	case res.write && res.columns != nil:
+		return "rw"
+	case res.write:
+		return "w"
+	}
+	return "r"
+}
+
+type rows struct {
+	columns []string
+	records [][]interface{}
+	summary map[string]interface{}
+	index   int
+	closed  bool
+}
+
+func newRows(res *result) *rows {
+	summary := map[string]interface{}{"type": queryType(res)}
+	if res.write {
+		summary["stats"] = res.stats
+	}
+	return &rows{columns: res.columns, records: res.records, summary: summary}
+}
+
+func (r *rows) Columns() []string {
+	return r.columns
+}
+
+func (r *rows) Metadata() map[string]interface{} {
+	fields := make([]interface{}, len(r.columns))
+	for i, c := range r.columns {
+		fields[i] = c
+	}
+	return map[string]interface{}{"fields": fields}
+}
+
+func (r *rows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *rows) NextNeo() ([]interface{}, map[string]interface{}, error) {
+	if r.closed {
+		return nil, nil, errors.New("Rows are already closed")
+	}
+	if r.index >= len(r.records) {
+		return nil, r.summary, io.EOF
+	}
+	data := r.records[r.index]
+	r.index++
+	return data, nil, nil
+}
+
+func (r *rows) All() ([][]interface{}, map[string]interface{}, error) {
+	output := [][]interface{}{}
+	for {
+		data, meta, err := r.NextNeo()
+		if err == io.EOF {
+			return output, meta, nil
+		}
+		if err != nil {
+			return output, meta, err
+		}
+		output = append(output, data)
+	}
+}
+
+type execResult struct {
+	metadata map[string]interface{}
+}
+
+func (r execResult) Metadata() map[string]interface{} {
+	return r.metadata
+}
+
+func (r execResult) LastInsertId() (int64, error) {
+	return -1, nil
+}
+
+// RowsAffected matches the driver, counting the nodes and relationships created and deleted.
+func (r execResult) RowsAffected() (int64, error) {
+	stats, ok := r.metadata["stats"].(map[string]interface{})
+	if !ok {
+		return -1, fmt.Errorf("Unrecognized type for stats metadata: %#v", r.metadata)
+	}
+
+	var rowsAffected int64
+	for _, stat := range []string{"nodes-created", "relationships-created", "nodes-deleted", "relationships-deleted"} {
+		if n, ok := stats[stat].(int64); ok {
+			rowsAffected += n
+		}
+	}
+	return rowsAffected, nil
+}
@@ -0,0 +1,371 @@
+package boltmem
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func results(db *bolt.DB, query string, params map[string]interface{}) ([][]interface{}, error) {
+	var rows [][]interface{}
+	err := db.QueryForResults(query, params, func(r *bolt.Result) error {
+		rows = append(rows, r.Data)
+		return nil
+	})
+	return rows, err
+}
+
+func exec(db *bolt.DB, query string, params map[string]interface{}) int64 {
+	rowsAffected, _, err := db.Exec(bolt.Stmt{Query: query, Params: params})
+	So(err, ShouldBeNil)
+	return rowsAffected
+}
+
+func TestPool_CreateAndMatch(t *testing.T) {
+	Convey("given a graph of code lists and codes", t, func() {
+		db := bolt.New(NewPool())
+
+		So(exec(db, "CREATE (cl:_code_list {id: {id}, label: 'Geography'})", bolt.Params{"id": "geo"}), ShouldEqual, 1)
+		So(exec(db, `UNWIND $codes AS code
+			MATCH (cl:_code_list {id: 'geo'})
+			CREATE (c:_code {value: code.value, order: code.order})-[:usedBy {label: code.label}]->(cl)`,
+			bolt.Params{"codes": []map[string]interface{}{
+				{"value": "K02000001", "label": "United Kingdom", "order": 3},
+				{"value": "E92000001", "label": "England", "order": 1},
+				{"value": "W92000004", "label": "Wales", "order": 2},
+			}}), ShouldEqual, 6)
+
+		Convey("when matching a relationship pattern with WHERE, ORDER BY, SKIP and LIMIT", func() {
+			rows, err := results(db, `MATCH (c:_code)-[r:usedBy]->(:_code_list {id: $id})
+				WHERE c.order >= 1 AND c.value IN ['E92000001', 'W92000004', 'K02000001']
+				RETURN c.value AS code, r.label ORDER BY c.order DESC SKIP 1 LIMIT 2`, bolt.Params{"id": "geo"})
+
+			Convey("then the matching rows are returned in order", func() {
+				So(err, ShouldBeNil)
+				So(rows, ShouldResemble, [][]interface{}{
+					{"W92000004", "Wales"},
+					{"E92000001", "England"},
+				})
+			})
+		})
+
+		Convey("when counting with an implicit grouping key", func() {
+			rows, err := results(db, "MATCH (cl:_code_list)<-[:usedBy]-(c) RETURN cl.id, count(c) AS codes", nil)
+
+			Convey("then the aggregate is returned per group", func() {
+				So(err, ShouldBeNil)
+				So(rows, ShouldResemble, [][]interface{}{{"geo", int64(3)}})
+			})
+		})
+
+		Convey("when a node is returned", func() {
+			var node graph.Node
+			err := db.QueryForResult("MATCH (c:_code {value: 'E92000001'}) RETURN c", nil, func(r *bolt.Result) error {
+				node = r.Data[0].(graph.Node)
+				return nil
+			})
+
+			Convey("then it is returned as the driver would", func() {
+				So(err, ShouldBeNil)
+				So(node.Labels, ShouldResemble, []string{"_code"})
+				So(node.Properties, ShouldResemble, map[string]interface{}{"value": "E92000001", "order": int64(1)})
+			})
+		})
+
+		Convey("when a path is returned", func() {
+			var p graph.Path
+			err := db.QueryForResult("MATCH p = (:_code_list)<-[:usedBy]-(:_code {value: 'E92000001'}) RETURN p", nil,
+				func(r *bolt.Result) error {
+					p = r.Data[0].(graph.Path)
+					return nil
+				})
+
+			Convey("then it is returned as the driver would, following the relationship backwards", func() {
+				So(err, ShouldBeNil)
+				So(p.Nodes, ShouldHaveLength, 2)
+				So(p.Nodes[0].Labels, ShouldResemble, []string{"_code_list"})
+				So(p.Relationships, ShouldHaveLength, 1)
+				So(p.Relationships[0].Type, ShouldEqual, "usedBy")
+				So(p.Sequence, ShouldResemble, []int{-1, 1})
+			})
+		})
+
+		Convey("when no nodes match", func() {
+			err := db.QueryForResult("MATCH (c:_code {value: 'missing'}) RETURN c", nil, nil)
+
+			Convey("then ErrNoResults is returned", func() {
+				So(err, ShouldEqual, bolt.ErrNoResults)
+			})
+		})
+	})
+}
+
+func TestPool_MergeSetDelete(t *testing.T) {
+	Convey("given an empty graph", t, func() {
+		db := bolt.New(NewPool())
+		merge := "MERGE (d:_dataset {id: $id}) ON CREATE SET d.created = true ON MATCH SET d.matched = true RETURN d.created, d.matched"
+
+		Convey("when the same node is merged twice", func() {
+			first, err := results(db, merge, bolt.Params{"id": "cpih01"})
+			So(err, ShouldBeNil)
+			second, err := results(db, merge, bolt.Params{"id": "cpih01"})
+			So(err, ShouldBeNil)
+
+			Convey("then it is created once and matched the second time", func() {
+				So(first, ShouldResemble, [][]interface{}{{true, nil}})
+				So(second, ShouldResemble, [][]interface{}{{true, true}})
+				rows, err := results(db, "MATCH (d:_dataset) RETURN count(*)", nil)
+				So(err, ShouldBeNil)
+				So(rows, ShouldResemble, [][]interface{}{{int64(1)}})
+			})
+		})
+
+		Convey("when properties and labels are set and removed", func() {
+			exec(db, "CREATE (d:_dataset {id: 'cpih01', state: 'created'})", nil)
+			exec(db, "MATCH (d:_dataset {id: 'cpih01'}) SET d += {state: 'published', title: 'CPIH'}, d:_published REMOVE d.id", nil)
+			rows, err := results(db, "MATCH (d:_published) RETURN properties(d), labels(d)", nil)
+
+			Convey("then the node is updated", func() {
+				So(err, ShouldBeNil)
+				So(rows, ShouldResemble, [][]interface{}{{
+					map[string]interface{}{"state": "published", "title": "CPIH"},
+					[]interface{}{"_dataset", "_published"},
+				}})
+			})
+		})
+
+		Convey("when a node with relationships is deleted", func() {
+			exec(db, "CREATE (:_dataset {id: 'cpih01'})-[:HAS_EDITION]->(:_edition {id: 'time-series'})", nil)
+			_, _, err := db.Exec(bolt.Stmt{Query: "MATCH (d:_dataset) DELETE d"})
+
+			Convey("then DELETE fails and DETACH DELETE succeeds", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "still has relationships")
+				So(exec(db, "MATCH (d:_dataset) DETACH DELETE d", nil), ShouldEqual, 2)
+
+				rows, err := results(db, "MATCH (n) OPTIONAL MATCH (n)-[r]-() RETURN n.id, r", nil)
+				So(err, ShouldBeNil)
+				So(rows, ShouldResemble, [][]interface{}{{"time-series", nil}})
+			})
+		})
+	})
+}
+
+func TestPool_FailedStatements(t *testing.T) {
+	Convey("given a dataset with an edition and a licence", t, func() {
+		db := bolt.New(NewPool())
+		exec(db, "CREATE (e:_edition)<-[:HAS_EDITION]-(d:_dataset {id: 'cpih01'})-[:HAS_LICENCE]->(l:_licence)", nil)
+
+		Convey("when a statement creates a node then fails to delete the dataset", func() {
+			_, _, err := db.Exec(bolt.Stmt{Query: "MATCH (d:_dataset)-[r:HAS_EDITION]->() CREATE (:_instance) DELETE r, d"})
+
+			Convey("then none of the statement is applied", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "still has relationships")
+				rows, err := results(db, "MATCH (n) OPTIONAL MATCH (n)-[r]->() RETURN count(DISTINCT n), count(r)", nil)
+				So(err, ShouldBeNil)
+				So(rows, ShouldResemble, [][]interface{}{{int64(3), int64(2)}})
+			})
+		})
+	})
+}
+
+func TestPool_Transactions(t *testing.T) {
+	Convey("given a transaction creating a node", t, func() {
+		db := bolt.New(NewPool())
+		tx, err := db.Begin()
+		So(err, ShouldBeNil)
+		_, _, err = tx.Exec(bolt.Stmt{Query: "CREATE (:_dataset {id: 'cpih01'})"})
+		So(err, ShouldBeNil)
+
+		Convey("when it is rolled back", func() {
+			So(tx.Rollback(), ShouldBeNil)
+
+			Convey("then the node is gone", func() {
+				So(db.QueryForResult("MATCH (d:_dataset) RETURN d", nil, nil), ShouldEqual, bolt.ErrNoResults)
+			})
+		})
+
+		Convey("when it is committed", func() {
+			So(tx.Commit(), ShouldBeNil)
+
+			Convey("then the node remains", func() {
+				So(db.QueryForResult("MATCH (d:_dataset) RETURN d", nil, nil), ShouldBeNil)
+			})
+		})
+
+		Convey("when another connection writes or begins a transaction before it ends", func() {
+			So(db.QueryForResult("MATCH (d:_dataset) RETURN d", nil, nil), ShouldBeNil)
+			_, _, writeErr := db.Exec(bolt.Stmt{Query: "CREATE (:_dataset {id: 'cpih02'})"})
+			_, beginErr := db.Begin()
+
+			Convey("then both are rejected and a rollback undoes only the transaction's write", func() {
+				So(errors.Cause(writeErr), ShouldEqual, ErrTxConflict)
+				So(errors.Cause(beginErr), ShouldEqual, ErrTxConflict)
+				So(tx.Rollback(), ShouldBeNil)
+				exec(db, "CREATE (:_dataset {id: 'cpih02'})", nil)
+
+				rows, err := results(db, "MATCH (d:_dataset) RETURN d.id", nil)
+				So(err, ShouldBeNil)
+				So(rows, ShouldResemble, [][]interface{}{{"cpih02"}})
+			})
+		})
+	})
+}
+
+func TestPool_Clauses(t *testing.T) {
+	const people = `CREATE (ann:Person {name: 'Ann', age: 30}), (bob:Person {name: 'Bob', age: 25}),
+		(cat:Person {name: 'Cat'}), (ann)-[:KNOWS {since: 2010}]->(bob), (cat)-[:KNOWS]->(ann)`
+
+	// each case runs write, if set, then query against a fresh copy of the people graph
+	cases := []struct {
+		name   string
+		write  string
+		query  string
+		params bolt.Params
+		rows   [][]interface{}
+	}{
+		{name: "comparing with null matches nothing",
+			query: "MATCH (p:Person) WHERE p.age = null RETURN p.name"},
+		{name: "comparing a missing property is null rather than false",
+			query: "MATCH (p:Person) WHERE p.age <> 30 RETURN p.name",
+			rows:  [][]interface{}{{"Bob"}}},
+		{name: "negating a null comparison is still null",
+			query: "MATCH (p:Person) WHERE NOT p.age > 26 RETURN p.name",
+			rows:  [][]interface{}{{"Bob"}}},
+		{name: "IS NULL matches missing properties",
+			query: "MATCH (p:Person) WHERE p.age IS NULL RETURN p.name",
+			rows:  [][]interface{}{{"Cat"}}},
+		{name: "IS NOT NULL matches present properties",
+			query: "MATCH (p:Person) WHERE p.age IS NOT NULL RETURN p.name ORDER BY p.name",
+			rows:  [][]interface{}{{"Ann"}, {"Bob"}}},
+		{name: "null comparisons and IN with null return null",
+			query: "MATCH (p:Person) RETURN p.name, p.age = null, null = null, p.age IN [30, null] ORDER BY p.name",
+			rows:  [][]interface{}{{"Ann", nil, nil, true}, {"Bob", nil, nil, nil}, {"Cat", nil, nil, nil}}},
+		{name: "IN with a list parameter",
+			query:  "MATCH (p:Person) WHERE p.name IN $names RETURN count(p) AS n",
+			params: bolt.Params{"names": []string{"Ann", "Cat", "Eve"}},
+			rows:   [][]interface{}{{int64(2)}}},
+		{name: "count of a property skips nulls",
+			query: "MATCH (p:Person) RETURN count(p.age), count(*)",
+			rows:  [][]interface{}{{int64(2), int64(3)}}},
+
+		{name: "variable length relationships bind the list followed",
+			query: "MATCH (:Person {name: 'Cat'})-[rs:KNOWS*..2]->(p) RETURN p.name, size(rs) ORDER BY p.name",
+			rows:  [][]interface{}{{"Ann", int64(1)}, {"Bob", int64(2)}}},
+		{name: "variable length relationships of a fixed length",
+			query: "MATCH (:Person {name: 'Cat'})-[*2]->(p) RETURN p.name",
+			rows:  [][]interface{}{{"Bob"}}},
+		{name: "variable length relationships from zero include the start node",
+			query: "MATCH (:Person {name: 'Ann'})-[*0..]-(p) RETURN p.name ORDER BY p.name",
+			rows:  [][]interface{}{{"Ann"}, {"Bob"}, {"Cat"}}},
+		{name: "path variables and functions",
+			query: "MATCH p = (:Person {name: 'Cat'})-[:KNOWS*]->(:Person {name: 'Bob'}) " +
+				"RETURN length(p), size(nodes(p))",
+			rows: [][]interface{}{{int64(2), int64(3)}}},
+		{name: "optional paths that do not match are null",
+			query: "MATCH (p:Person) OPTIONAL MATCH path = (p)-[:KNOWS*]->() " +
+				"RETURN p.name, count(path) ORDER BY p.name",
+			rows: [][]interface{}{{"Ann", int64(1)}, {"Bob", int64(0)}, {"Cat", int64(2)}}},
+
+		{name: "ORDER BY puts nulls last",
+			query: "MATCH (p:Person) RETURN p.name ORDER BY p.age",
+			rows:  [][]interface{}{{"Bob"}, {"Ann"}, {"Cat"}}},
+		{name: "ORDER BY DESC puts nulls first",
+			query: "MATCH (p:Person) RETURN p.name ORDER BY p.age DESC",
+			rows:  [][]interface{}{{"Cat"}, {"Ann"}, {"Bob"}}},
+
+		{name: "outgoing relationship pattern",
+			query: "MATCH (a:Person {name: 'Ann'})-[:KNOWS]->(b) RETURN b.name",
+			rows:  [][]interface{}{{"Bob"}}},
+		{name: "incoming relationship pattern",
+			query: "MATCH (a:Person {name: 'Ann'})<-[:KNOWS]-(b) RETURN b.name",
+			rows:  [][]interface{}{{"Cat"}}},
+		{name: "undirected relationship pattern from a node",
+			query: "MATCH (a:Person {name: 'Ann'})-[:KNOWS]-(b) RETURN b.name ORDER BY b.name",
+			rows:  [][]interface{}{{"Bob"}, {"Cat"}}},
+		{name: "undirected relationship pattern matches each relationship both ways",
+			query: "MATCH (a)-[r:KNOWS]-(b) RETURN a.name, b.name, r.since ORDER BY a.name, b.name",
+			rows: [][]interface{}{
+				{"Ann", "Bob", int64(2010)}, {"Ann", "Cat", nil}, {"Bob", "Ann", int64(2010)}, {"Cat", "Ann", nil},
+			}},
+		{name: "reverse pattern with aliases",
+			query: "MATCH (b:Person {name: 'Bob'})<-[r]-(a) RETURN a.name AS who, r.since AS since",
+			rows:  [][]interface{}{{"Ann", int64(2010)}}},
+
+		{name: "MERGE ON MATCH sets properties of an existing node",
+			write: "MERGE (p:Person {name: 'Ann'}) ON CREATE SET p.created = true ON MATCH SET p.matched = true",
+			query: "MATCH (p:Person {name: 'Ann'}) RETURN p.created, p.matched, p.age",
+			rows:  [][]interface{}{{nil, true, int64(30)}}},
+		{name: "MERGE ON CREATE sets properties of a new node",
+			write: "MERGE (p:Person {name: 'Dan'}) ON CREATE SET p.created = true ON MATCH SET p.matched = true",
+			query: "MATCH (p:Person) WHERE p.created RETURN p.name, p.matched",
+			rows:  [][]interface{}{{"Dan", nil}}},
+		{name: "SET from an expression",
+			write: "MATCH (p:Person {name: 'Bob'}) SET p.age = p.age + 1",
+			query: "MATCH (p:Person {name: 'Bob'}) RETURN p.age",
+			rows:  [][]interface{}{{int64(26)}}},
+		{name: "DETACH DELETE removes a node and its relationships",
+			write: "MATCH (p:Person {name: 'Cat'}) DETACH DELETE p",
+			query: "MATCH (p:Person) OPTIONAL MATCH (p)-[r]-() RETURN count(DISTINCT p), count(r)",
+			rows:  [][]interface{}{{int64(2), int64(2)}}},
+		{name: "DELETE removes relationships",
+			write: "MATCH ()-[r:KNOWS]->() DELETE r",
+			query: "MATCH ()-[r]->() RETURN count(r)",
+			rows:  [][]interface{}{{int64(0)}}},
+
+		{name: "parameterised SKIP and LIMIT",
+			query:  "MATCH (p:Person) RETURN p.name ORDER BY p.name SKIP $skip LIMIT $limit",
+			params: bolt.Params{"skip": 1, "limit": 1},
+			rows:   [][]interface{}{{"Bob"}}},
+		{name: "parameterised SKIP past all but the last row",
+			query:  "MATCH (p:Person) RETURN p.name ORDER BY p.name SKIP $skip",
+			params: bolt.Params{"skip": 2},
+			rows:   [][]interface{}{{"Cat"}}},
+		{name: "parameterised LIMIT of zero",
+			query:  "MATCH (p:Person) RETURN p.name ORDER BY p.name LIMIT $limit",
+			params: bolt.Params{"limit": 0}},
+	}
+
+	for _, c := range cases {
+		Convey("given a graph of people who know each other", t, func() {
+			db := bolt.New(NewPool())
+			exec(db, people, nil)
+
+			Convey("when the query is run: "+c.name, func() {
+				if c.write != "" {
+					exec(db, c.write, c.params)
+				}
+				rows, err := results(db, c.query, c.params)
+
+				Convey("then the rows are those Neo4j returns", func() {
+					if c.rows == nil {
+						So(err, ShouldEqual, bolt.ErrNoResults)
+						return
+					}
+					So(err, ShouldBeNil)
+					So(rows, ShouldResemble, c.rows)
+				})
+			})
+		})
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	Convey("should describe where a query could not be parsed", t, func() {
+		_, err := parse("MATCH (n RETURN n")
+		So(err.Error(), ShouldContainSubstring, `expected ) but found "RETURN" at position 9`)
+
+		_, err = parse("MATCH (a)-[*3..2]->(b) RETURN a")
+		So(err.Error(), ShouldContainSubstring, `expected a maximum length of at least 3 but found "]"`)
+
+		_, err = parse("CREATE (a)-[:R*2]->(b)")
+		So(err.Error(), ShouldContainSubstring, "a variable length relationship can not be created")
+
+		_, err = parse("CREATE p = (a)-[:R]->(b)")
+		So(err.Error(), ShouldContainSubstring, "a path variable can not be created")
+	})
+}
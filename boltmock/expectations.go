@@ -0,0 +1,357 @@
+package boltmock
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	"github.com/pkg/errors"
+)
+
+const (
+	queryCall    = "query"
+	execCall     = "exec"
+	beginCall    = "begin"
+	commitCall   = "commit"
+	rollbackCall = "rollback"
+)
+
+// Expectation is a call the Mock expects to receive.
+type Expectation struct {
+	kind         string
+	re           *regexp.Regexp
+	params       map[string]interface{}
+	paramsSet    bool
+	columns      []string
+	rows         [][]interface{}
+	meta         map[string]interface{}
+	rowsAffected int64
+	err          error
+	times        int
+	calls        int
+}
+
+// WithParams expects the call to be made with params equal to those provided.
+func (e *Expectation) WithParams(params map[string]interface{}) *Expectation {
+	e.params = params
+	e.paramsSet = true
+	return e
+}
+
+// WillReturnRows responds to a query with the provided rows, each passed to the ResultMapper in turn.
+func (e *Expectation) WillReturnRows(rows ...[]interface{}) *Expectation {
+	e.rows = rows
+	return e
+}
+
+// WillReturnColumns names the columns of the rows a query responds with, so they can be read by name.
+func (e *Expectation) WillReturnColumns(columns ...string) *Expectation {
+	e.columns = columns
+	return e
+}
+
+// WillReturnSummary responds to an exec with the provided rows affected count and metadata.
+func (e *Expectation) WillReturnSummary(rowsAffected int64, meta map[string]interface{}) *Expectation {
+	e.rowsAffected = rowsAffected
+	e.meta = meta
+	return e
+}
+
+// WillReturnError responds to the call with err.
+func (e *Expectation) WillReturnError(err error) *Expectation {
+	e.err = err
+	return e
+}
+
+// Times sets the number of times the call is expected, the default is once.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) met() bool {
+	return e.calls >= e.times
+}
+
+func (e *Expectation) matches(kind, query string, params map[string]interface{}) bool {
+	if e.kind != kind {
+		return false
+	}
+	if e.re != nil && !e.re.MatchString(query) {
+		return false
+	}
+	if e.paramsSet && !paramsEqual(e.params, params) {
+		return false
+	}
+	return true
+}
+
+func (e *Expectation) String() string {
+	s := e.kind
+	if e.re != nil {
+		s += fmt.Sprintf(" matching %q", e.re.String())
+	}
+	if e.paramsSet {
+		s += fmt.Sprintf(" with params %v", e.params)
+	}
+	return s
+}
+
+func paramsEqual(expected, actual map[string]interface{}) bool {
+	if len(expected) == 0 && len(actual) == 0 {
+		return true
+	}
+	return reflect.DeepEqual(expected, actual)
+}
+
+// Mock is an expectation based mock of bolt.DB. Calls are matched against the expectations set on it, in the order
+// they were set unless MatchExpectationsInOrder(false) is called. A Mock is safe for concurrent use.
+type Mock struct {
+	mutex        sync.Mutex
+	ordered      bool
+	expectations []*Expectation
+	unexpected   []string
+}
+
+// NewMock creates a Mock matching expectations in order.
+func NewMock() *Mock {
+	return &Mock{ordered: true}
+}
+
+// MatchExpectationsInOrder sets whether calls must be made in the order the expectations were set.
+func (m *Mock) MatchExpectationsInOrder(ordered bool) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.ordered = ordered
+}
+
+// ExpectQuery expects a call to QueryForResult or QueryForResults with a query matching the regular expression.
+func (m *Mock) ExpectQuery(pattern string) *Expectation {
+	return m.expect(queryCall, pattern)
+}
+
+// ExpectExec expects a call to Exec with a query matching the regular expression.
+func (m *Mock) ExpectExec(pattern string) *Expectation {
+	return m.expect(execCall, pattern)
+}
+
+// ExpectBegin expects a call to Begin.
+func (m *Mock) ExpectBegin() *Expectation {
+	return m.expect(beginCall, "")
+}
+
+// ExpectCommit expects a call to Tx.Commit.
+func (m *Mock) ExpectCommit() *Expectation {
+	return m.expect(commitCall, "")
+}
+
+// ExpectRollback expects a call to Tx.Rollback.
+func (m *Mock) ExpectRollback() *Expectation {
+	return m.expect(rollbackCall, "")
+}
+
+func (m *Mock) expect(kind, pattern string) *Expectation {
+	e := &Expectation{kind: kind, times: 1}
+	if pattern != "" {
+		e.re = regexp.MustCompile(pattern)
+	}
+
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.expectations = append(m.expectations, e)
+	return e
+}
+
+// ExpectationsWereMet returns an error describing any expectation that was not met and any call that matched no
+// expectation.
+func (m *Mock) ExpectationsWereMet() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	var problems []string
+	for _, e := range m.expectations {
+		if !e.met() {
+			problems = append(problems, fmt.Sprintf("expected %s %d time(s) but it was called %d time(s)", e, e.times, e.calls))
+		}
+	}
+	problems = append(problems, m.unexpected...)
+
+	if len(problems) > 0 {
+		return errors.New("boltmock: " + strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+// QueryForResults matches the call against the expected queries and passes the expected rows to mapResult.
+func (m *Mock) QueryForResults(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error {
+	return m.query(query, params, mapResult, false)
+}
+
+// QueryForResult matches the call against the expected queries and passes the expected row to mapResult.
+func (m *Mock) QueryForResult(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error {
+	return m.query(query, params, mapResult, true)
+}
+
+// Exec matches the call against the expected execs and returns the expected summary.
+func (m *Mock) Exec(s bolt.Stmt) (int64, map[string]interface{}, error) {
+	e, err := m.match(execCall, s.Query, s.Params)
+	if err != nil {
+		return 0, nil, err
+	}
+	if e.err != nil {
+		return 0, nil, e.err
+	}
+	return e.rowsAffected, e.meta, nil
+}
+
+// Begin matches the call against the expected begins and returns a Tx whose calls are matched by the same Mock.
+func (m *Mock) Begin() (*Tx, error) {
+	e, err := m.match(beginCall, "", nil)
+	if err != nil {
+		return nil, err
+	}
+	if e.err != nil {
+		return nil, e.err
+	}
+	return &Tx{mock: m}, nil
+}
+
+// Close is a no-op, satisfying the same method set as bolt.DB.
+func (m *Mock) Close() error {
+	return nil
+}
+
+func (m *Mock) query(query string, params map[string]interface{}, mapResult bolt.ResultMapper, singleResult bool) error {
+	e, err := m.match(queryCall, query, params)
+	if err != nil {
+		return err
+	}
+	if e.err != nil {
+		return e.err
+	}
+
+	if len(e.rows) == 0 {
+		return bolt.ErrNoResults
+	}
+
+	for i, row := range e.rows {
+		if singleResult && i > 0 {
+			return bolt.NonUniqueResult
+		}
+		if mapResult != nil {
+			data := make([]interface{}, len(row))
+			copy(data, row)
+			if err := mapResult(bolt.NewResult(e.columns, data, i)); err != nil {
+				return errors.WithMessage(err, "mapResult returned an error")
+			}
+		}
+	}
+	return nil
+}
+
+func (m *Mock) match(kind, query string, params map[string]interface{}) (*Expectation, error) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	for _, e := range m.expectations {
+		if e.met() {
+			continue
+		}
+		if e.matches(kind, query, params) {
+			e.calls++
+			return e, nil
+		}
+		if m.ordered {
+			break
+		}
+	}
+
+	call := kind
+	if query != "" {
+		call += fmt.Sprintf(" %q", query)
+	}
+	if len(params) > 0 {
+		call += fmt.Sprintf(" with params %v", params)
+	}
+	msg := "unexpected call to " + call
+	if next := m.next(); m.ordered && next != nil {
+		msg += ", next expectation is " + next.String()
+	}
+	m.unexpected = append(m.unexpected, msg)
+	return nil, errors.New("boltmock: " + msg)
+}
+
+func (m *Mock) next() *Expectation {
+	for _, e := range m.expectations {
+		if !e.met() {
+			return e
+		}
+	}
+	return nil
+}
+
+// Tx is a transaction begun on a Mock.
+type Tx struct {
+	mock   *Mock
+	mutex  sync.Mutex
+	closed bool
+}
+
+// QueryForResults matches the call against the expected queries of the Mock.
+func (t *Tx) QueryForResults(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error {
+	if t.isClosed() {
+		return bolt.ErrTxClosed
+	}
+	return t.mock.query(query, params, mapResult, false)
+}
+
+// QueryForResult matches the call against the expected queries of the Mock.
+func (t *Tx) QueryForResult(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error {
+	if t.isClosed() {
+		return bolt.ErrTxClosed
+	}
+	return t.mock.query(query, params, mapResult, true)
+}
+
+// Exec matches the call against the expected execs of the Mock.
+func (t *Tx) Exec(s bolt.Stmt) (int64, map[string]interface{}, error) {
+	if t.isClosed() {
+		return 0, nil, bolt.ErrTxClosed
+	}
+	return t.mock.Exec(s)
+}
+
+// Commit matches the call against the expected commits of the Mock.
+func (t *Tx) Commit() error {
+	return t.end(commitCall)
+}
+
+// Rollback matches the call against the expected rollbacks of the Mock.
+func (t *Tx) Rollback() error {
+	return t.end(rollbackCall)
+}
+
+func (t *Tx) end(kind string) error {
+	t.mutex.Lock()
+	if t.closed {
+		t.mutex.Unlock()
+		return bolt.ErrTxClosed
+	}
+	t.closed = true
+	t.mutex.Unlock()
+
+	e, err := t.mock.match(kind, "", nil)
+	if err != nil {
+		return err
+	}
+	return e.err
+}
+
+func (t *Tx) isClosed() bool {
+	t.mutex.Lock()
+	defer t.mutex.Unlock()
+	return t.closed
+}
@@ -0,0 +1,117 @@
+package boltmock
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	"github.com/pkg/errors"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestMock_InOrder(t *testing.T) {
+	Convey("given a mock expecting a transaction", t, func() {
+		m := NewMock()
+		m.ExpectBegin()
+		m.ExpectExec("^CREATE").WithParams(bolt.Params{"id": "cpih01"}).WillReturnSummary(1, nil)
+		m.ExpectQuery("^MATCH").WillReturnRows([]interface{}{"a"}, []interface{}{"b"})
+		m.ExpectCommit()
+
+		Convey("when the calls are made in order", func() {
+			tx, err := m.Begin()
+			So(err, ShouldBeNil)
+			rowsAffected, _, err := tx.Exec(bolt.Stmt{Query: "CREATE (n)", Params: bolt.Params{"id": "cpih01"}})
+			So(err, ShouldBeNil)
+
+			var values []interface{}
+			err = tx.QueryForResults("MATCH (n) RETURN n", nil, func(r *bolt.Result) error {
+				values = append(values, r.Data[0])
+				return nil
+			})
+			So(err, ShouldBeNil)
+			So(tx.Commit(), ShouldBeNil)
+
+			Convey("then the expected responses are returned and the expectations are met", func() {
+				So(rowsAffected, ShouldEqual, 1)
+				So(values, ShouldResemble, []interface{}{"a", "b"})
+				So(m.ExpectationsWereMet(), ShouldBeNil)
+			})
+		})
+
+		Convey("when a call is made out of order", func() {
+			_, _, err := m.Exec(bolt.Stmt{Query: "CREATE (n)", Params: bolt.Params{"id": "cpih01"}})
+
+			Convey("then an error is returned and recorded", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, `unexpected call to exec "CREATE (n)"`)
+				So(err.Error(), ShouldContainSubstring, "next expectation is begin")
+				So(m.ExpectationsWereMet(), ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestMock_QueryForResult(t *testing.T) {
+	Convey("given a mock expecting queries", t, func() {
+		m := NewMock()
+		m.MatchExpectationsInOrder(false)
+		m.ExpectQuery("many").WillReturnRows([]interface{}{1}, []interface{}{2})
+		m.ExpectQuery("none")
+		m.ExpectQuery("fails").WillReturnError(errors.New("query failed"))
+
+		Convey("then single result semantics match bolt.DB", func() {
+			So(m.QueryForResult("none", nil, nil), ShouldEqual, bolt.ErrNoResults)
+			So(m.QueryForResult("many", nil, nil), ShouldEqual, bolt.NonUniqueResult)
+			So(m.QueryForResult("fails", nil, nil).Error(), ShouldEqual, "query failed")
+			So(m.ExpectationsWereMet(), ShouldBeNil)
+		})
+	})
+
+	Convey("given a mock expecting a query with named columns", t, func() {
+		m := NewMock()
+		m.ExpectQuery("MATCH").WillReturnColumns("id", "editions").WillReturnRows([]interface{}{"cpih01", int64(2)})
+
+		Convey("when the result is read by column name", func() {
+			var id string
+			var editions int
+			err := m.QueryForResult("MATCH (d:Dataset) RETURN d.id AS id, d.editions AS editions", nil,
+				func(r *bolt.Result) error {
+					var err error
+					if editions, err = r.IntByName("editions"); err != nil {
+						return err
+					}
+					return r.ScanByName("id", &id)
+				})
+
+			Convey("then the named columns are found", func() {
+				So(err, ShouldBeNil)
+				So(id, ShouldEqual, "cpih01")
+				So(editions, ShouldEqual, 2)
+			})
+		})
+	})
+}
+
+func TestMock_Concurrent(t *testing.T) {
+	Convey("given a mock expecting a query many times", t, func() {
+		m := NewMock()
+		m.ExpectQuery("MATCH").WillReturnRows([]interface{}{1}).Times(50)
+
+		Convey("when it is queried concurrently", func() {
+			var wg sync.WaitGroup
+			for i := 0; i < 50; i++ {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					m.QueryForResult("MATCH (n) RETURN n", nil, nil)
+				}()
+			}
+			wg.Wait()
+
+			Convey("then every call is counted", func() {
+				So(m.ExpectationsWereMet(), ShouldBeNil)
+				So(m.QueryForResult("MATCH (n) RETURN n", nil, nil), ShouldNotBeNil)
+			})
+		})
+	})
+}
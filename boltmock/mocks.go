@@ -3,6 +3,7 @@ package boltmock
 import (
 	"github.com/pkg/errors"
 	"github.com/ONSdigital/dp-bolt/bolt"
+	"sync"
 )
 
 type QueryParams struct {
@@ -12,8 +13,14 @@ type QueryParams struct {
 
 type QueryFunc func(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error
 
+type ExecFunc func(s bolt.Stmt) (int64, map[string]interface{}, error)
+
 var Err = errors.New("queryFunc error")
 
+var ErrNoQueryFunc = errors.New("no QueryFunc configured for call")
+
+var ErrNoExecFunc = errors.New("no ExecFunc configured for call")
+
 var ErrQueryFunc QueryFunc = func(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error {
 	return Err
 }
@@ -28,32 +35,80 @@ type DB struct {
 	CloseFunc            func() error
 	QueryForResultsCalls []QueryParams
 	QueryForResultsFuncs []QueryFunc
+	ExecCalls            []bolt.Stmt
+	ExecFuncs            []ExecFunc
+	mutex                sync.Mutex
 }
 
+var (
+	_ bolt.Conn = (*DB)(nil)
+	_ bolt.Conn = (*Mock)(nil)
+	_ bolt.Conn = (*Tx)(nil)
+	_ bolt.Conn = (*ConnMock)(nil)
+)
+
 func (m *DB) QueryForResult(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error {
+	m.mutex.Lock()
 	if m.QueryForResultCalls == nil {
 		m.QueryForResultCalls = []QueryParams{}
 	}
 
 	index := len(m.QueryForResultCalls)
 	m.QueryForResultCalls = append(m.QueryForResultCalls, newQueryParams(query, params))
-	return m.QueryForResultFuncs[index](query, params, mapResult)
+	queryFunc := funcAt(m.QueryForResultFuncs, index)
+	m.mutex.Unlock()
+
+	return queryFunc(query, params, mapResult)
 }
 
 func (m *DB) QueryForResults(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error {
+	m.mutex.Lock()
 	if m.QueryForResultsCalls == nil {
 		m.QueryForResultsCalls = []QueryParams{}
 	}
 
 	index := len(m.QueryForResultsCalls)
 	m.QueryForResultsCalls = append(m.QueryForResultsCalls, newQueryParams(query, params))
-	return m.QueryForResultsFuncs[index](query, params, mapResult)
+	queryFunc := funcAt(m.QueryForResultsFuncs, index)
+	m.mutex.Unlock()
+
+	return queryFunc(query, params, mapResult)
+}
+
+func (m *DB) Exec(s bolt.Stmt) (int64, map[string]interface{}, error) {
+	m.mutex.Lock()
+	index := len(m.ExecCalls)
+	call := s
+	call.Params = newQueryParams(s.Query, s.Params).Params
+	call.Tags = append([]string(nil), s.Tags...)
+	m.ExecCalls = append(m.ExecCalls, call)
+	var execFunc ExecFunc
+	if index < len(m.ExecFuncs) {
+		execFunc = m.ExecFuncs[index]
+	}
+	m.mutex.Unlock()
+
+	if execFunc == nil {
+		return 0, nil, ErrNoExecFunc
+	}
+	return execFunc(s)
 }
 
 func (m *DB) Close() error {
 	return m.CloseFunc()
 }
 
+// funcAt returns the QueryFunc configured for the call at index, or a func returning ErrNoQueryFunc if more calls
+// have been made than funcs configured.
+func funcAt(funcs []QueryFunc, index int) QueryFunc {
+	if index >= len(funcs) || funcs[index] == nil {
+		return func(query string, params map[string]interface{}, mapResult bolt.ResultMapper) error {
+			return ErrNoQueryFunc
+		}
+	}
+	return funcs[index]
+}
+
 func newQueryParams(query string, params map[string]interface{}) QueryParams {
 	var p map[string]interface{}
 	if params != nil {
@@ -0,0 +1,36 @@
+package boltmock
+
+import (
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDB_Exec(t *testing.T) {
+	Convey("given a mock DB with a single ExecFunc", t, func() {
+		m := &DB{
+			ExecFuncs: []ExecFunc{
+				func(s bolt.Stmt) (int64, map[string]interface{}, error) {
+					return 1, nil, nil
+				},
+			},
+		}
+		var conn bolt.Conn = m
+
+		Convey("when Exec is called more times than funcs configured", func() {
+			stmt := bolt.Stmt{Query: "MERGE (n {id: {id}})", Params: bolt.Params{"id": "cpih01"}, Tags: []string{"dataset"},
+				Idempotent: true}
+			rowsAffected, _, err := conn.Exec(stmt)
+			_, _, extraErr := conn.Exec(bolt.Stmt{Query: "CREATE (m)"})
+
+			Convey("then the calls are recorded and the extra call returns ErrNoExecFunc", func() {
+				So(err, ShouldBeNil)
+				So(rowsAffected, ShouldEqual, 1)
+				So(extraErr, ShouldEqual, ErrNoExecFunc)
+				So(m.ExecCalls, ShouldHaveLength, 2)
+				So(m.ExecCalls[0], ShouldResemble, stmt)
+			})
+		})
+	})
+}
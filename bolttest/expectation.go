@@ -0,0 +1,244 @@
+package bolttest
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Matcher matches a single parameter value sent with a statement.
+type Matcher interface {
+	Match(v interface{}) bool
+	String() string
+}
+
+// MatcherFunc adapts a function to a Matcher.
+type MatcherFunc func(v interface{}) bool
+
+// Match calls f(v).
+func (f MatcherFunc) Match(v interface{}) bool {
+	return f(v)
+}
+
+func (f MatcherFunc) String() string {
+	return "func"
+}
+
+type anyMatcher struct{}
+
+func (anyMatcher) Match(v interface{}) bool { return true }
+func (anyMatcher) String() string           { return "any" }
+
+// Any matches any value, including a missing parameter.
+func Any() Matcher {
+	return anyMatcher{}
+}
+
+type equalMatcher struct {
+	expected interface{}
+}
+
+func (m equalMatcher) Match(v interface{}) bool {
+	return reflect.DeepEqual(m.expected, v)
+}
+
+func (m equalMatcher) String() string {
+	return fmt.Sprintf("%#v", m.expected)
+}
+
+// Equal matches a value equal to expected once both are in the form they take on the wire, so int(1) and int64(1)
+// are equal.
+func Equal(expected interface{}) Matcher {
+	return equalMatcher{expected: normalise(expected)}
+}
+
+type regexpMatcher struct {
+	re *regexp.Regexp
+}
+
+func (m regexpMatcher) Match(v interface{}) bool {
+	s, ok := v.(string)
+	return ok && m.re.MatchString(s)
+}
+
+func (m regexpMatcher) String() string {
+	return "=~ " + m.re.String()
+}
+
+// MatchRegexp matches a string value against the provided pattern.
+func MatchRegexp(pattern string) Matcher {
+	return regexpMatcher{re: regexp.MustCompile(pattern)}
+}
+
+// Expectation describes a statement the server expects to receive and how it responds to it.
+type Expectation struct {
+	statement string
+	re        *regexp.Regexp
+	params    map[string]Matcher
+	exact     bool
+	fields    []interface{}
+	records   [][]interface{}
+	metadata  map[string]interface{}
+	failure   map[string]interface{}
+	delay     time.Duration
+	times     int
+	calls     int
+}
+
+// WithParams expects the statement to be sent with exactly the provided params. Values may be Matchers, any other
+// value is compared with Equal.
+func (e *Expectation) WithParams(params map[string]interface{}) *Expectation {
+	e.exact = true
+	e.params = make(map[string]Matcher, len(params))
+	for k, v := range params {
+		e.WithParam(k, v)
+	}
+	return e
+}
+
+// WithParam expects the statement to be sent with the named param matching value. Value may be a Matcher, any other
+// value is compared with Equal.
+func (e *Expectation) WithParam(name string, value interface{}) *Expectation {
+	if e.params == nil {
+		e.params = make(map[string]Matcher)
+	}
+	if m, ok := value.(Matcher); ok {
+		e.params[name] = m
+	} else {
+		e.params[name] = Equal(value)
+	}
+	return e
+}
+
+// WillReturn responds to the statement with the provided columns and records.
+func (e *Expectation) WillReturn(columns []string, records ...[]interface{}) *Expectation {
+	e.fields = make([]interface{}, len(columns))
+	for i, c := range columns {
+		e.fields[i] = c
+	}
+	e.records = make([][]interface{}, len(records))
+	for i, r := range records {
+		e.records[i] = normalise(r).([]interface{})
+	}
+	return e
+}
+
+// WithMetadata sets the metadata of the summary sent once all records have been streamed, for example the "stats"
+// of a write statement.
+func (e *Expectation) WithMetadata(metadata map[string]interface{}) *Expectation {
+	e.metadata = normalise(metadata).(map[string]interface{})
+	return e
+}
+
+// WillFail responds to the statement with a FAILURE message carrying the provided code and message.
+func (e *Expectation) WillFail(code, message string) *Expectation {
+	e.failure = map[string]interface{}{"code": code, "message": message}
+	return e
+}
+
+// WillDelayFor delays the response to the statement by d, or until the server is closed, so the client can be
+// observed while the statement is in flight.
+func (e *Expectation) WillDelayFor(d time.Duration) *Expectation {
+	e.delay = d
+	return e
+}
+
+// Times sets the number of times the statement is expected, the default is once.
+func (e *Expectation) Times(n int) *Expectation {
+	e.times = n
+	return e
+}
+
+func (e *Expectation) met() bool {
+	return e.calls >= e.times
+}
+
+func (e *Expectation) matches(statement string, params map[string]interface{}) bool {
+	if e.re != nil {
+		if !e.re.MatchString(statement) {
+			return false
+		}
+	} else if strings.TrimSpace(e.statement) != strings.TrimSpace(statement) {
+		return false
+	}
+
+	if e.exact {
+		for k := range params {
+			if _, ok := e.params[k]; !ok {
+				return false
+			}
+		}
+	}
+
+	for k, m := range e.params {
+		v, ok := params[k]
+		if !ok {
+			if _, isAny := m.(anyMatcher); !isAny {
+				return false
+			}
+		}
+		if !m.Match(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func (e *Expectation) String() string {
+	s := fmt.Sprintf("%q", e.statement)
+	if e.re != nil {
+		s = "=~ " + e.re.String()
+	}
+	if len(e.params) > 0 {
+		parts := make([]string, 0, len(e.params))
+		for k, m := range e.params {
+			parts = append(parts, k+": "+m.String())
+		}
+		s += " with params {" + strings.Join(parts, ", ") + "}"
+	}
+	return s
+}
+
+// normalise converts a value to the types it decodes to after being sent over the wire.
+func normalise(v interface{}) interface{} {
+	switch val := v.(type) {
+	case int:
+		return int64(val)
+	case int8:
+		return int64(val)
+	case int16:
+		return int64(val)
+	case int32:
+		return int64(val)
+	case uint8:
+		return int64(val)
+	case uint16:
+		return int64(val)
+	case uint32:
+		return int64(val)
+	case float32:
+		return float64(val)
+	case []string:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			list[i] = item
+		}
+		return list
+	case []interface{}:
+		list := make([]interface{}, len(val))
+		for i, item := range val {
+			list[i] = normalise(item)
+		}
+		return list
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(val))
+		for k, item := range val {
+			m[k] = normalise(item)
+		}
+		return m
+	default:
+		return v
+	}
+}
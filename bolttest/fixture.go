@@ -0,0 +1,241 @@
+package bolttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	"github.com/pkg/errors"
+)
+
+// RecordEnv is the environment variable that switches FixturePool into recording mode when set to 1.
+const RecordEnv = "BOLT_RECORD"
+
+// Interaction types recorded in a fixture.
+const (
+	QueryInteraction    = "query"
+	ExecInteraction     = "exec"
+	BeginInteraction    = "begin"
+	CommitInteraction   = "commit"
+	RollbackInteraction = "rollback"
+)
+
+// Interaction is a single exchange with the database captured in a fixture.
+type Interaction struct {
+	Type     string                 `json:"type"`
+	Query    string                 `json:"query,omitempty"`
+	Params   map[string]interface{} `json:"params,omitempty"`
+	Columns  []string               `json:"columns,omitempty"`
+	Metadata map[string]interface{} `json:"metadata,omitempty"`
+	Records  [][]interface{}        `json:"records,omitempty"`
+	Summary  map[string]interface{} `json:"summary,omitempty"`
+	Error    string                 `json:"error,omitempty"`
+	// StreamError is the error returned while reading the rows of a query that had started successfully.
+	StreamError string `json:"streamError,omitempty"`
+}
+
+// Fixture is the content of a golden file.
+type Fixture struct {
+	Interactions []*Interaction `json:"interactions"`
+}
+
+// FixturePath returns the path of the golden file for name, under testdata in the working directory.
+func FixturePath(name string) string {
+	return filepath.Join("testdata", name+".json")
+}
+
+// FixturePool returns a DBPool for a test fixture. When the BOLT_RECORD environment variable is 1 the pool returned
+// by open is wrapped to record every interaction, and the golden file for name is written when the pool is closed.
+// Otherwise the golden file is replayed without a database.
+func FixturePool(name string, open func() (bolt.DBPool, error)) (bolt.DBPool, error) {
+	path := FixturePath(name)
+	if os.Getenv(RecordEnv) == "1" {
+		pool, err := open()
+		if err != nil {
+			return nil, err
+		}
+		return NewRecordingPool(pool, path), nil
+	}
+	return NewReplayPool(path)
+}
+
+func readFixture(path string) (*Fixture, error) {
+	b, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "error reading fixture")
+	}
+
+	d := json.NewDecoder(bytes.NewReader(b))
+	d.UseNumber()
+
+	var f Fixture
+	if err := d.Decode(&f); err != nil {
+		return nil, errors.WithMessage(err, "error decoding fixture "+path)
+	}
+
+	for n, i := range f.Interactions {
+		if err := i.fromFixture(); err != nil {
+			return nil, errors.WithMessage(err, fmt.Sprintf("error decoding interaction %d of fixture %s", n+1, path))
+		}
+	}
+	return &f, nil
+}
+
+func writeFixture(path string, f *Fixture) error {
+	out := &Fixture{Interactions: make([]*Interaction, len(f.Interactions))}
+	for n, i := range f.Interactions {
+		c, err := i.toFixture()
+		if err != nil {
+			return errors.WithMessage(err, fmt.Sprintf("error encoding interaction %d", n+1))
+		}
+		out.Interactions[n] = c
+	}
+
+	b, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return errors.WithMessage(err, "error encoding fixture")
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return errors.WithMessage(err, "error creating fixture directory")
+	}
+	return ioutil.WriteFile(path, append(b, '\n'), 0644)
+}
+
+// toFixture returns a copy of the interaction with its values in the form bolt.Result marshals them to JSON, so they
+// survive a JSON round trip: floats keep a decimal point, integers a JavaScript number can not hold and floats JSON
+// can not hold are tagged, and graph values are written as readable objects.
+func (i *Interaction) toFixture() (*Interaction, error) {
+	c := *i
+	var err error
+	if c.Params, err = toFixtureMap(i.Params); err != nil {
+		return nil, errors.WithMessage(err, "params")
+	}
+	if c.Metadata, err = toFixtureMap(i.Metadata); err != nil {
+		return nil, errors.WithMessage(err, "metadata")
+	}
+	if c.Summary, err = toFixtureMap(i.Summary); err != nil {
+		return nil, errors.WithMessage(err, "summary")
+	}
+	c.Records = make([][]interface{}, len(i.Records))
+	for r, record := range i.Records {
+		v, err := bolt.JSONValue(record)
+		if err != nil {
+			return nil, errors.WithMessage(err, "record "+strconv.Itoa(r+1))
+		}
+		c.Records[r] = v.([]interface{})
+	}
+	return &c, nil
+}
+
+// fromFixture reverses toFixture on an interaction decoded with json.Decoder.UseNumber.
+func (i *Interaction) fromFixture() error {
+	var err error
+	if i.Params, err = fromFixtureMap(i.Params); err != nil {
+		return errors.WithMessage(err, "params")
+	}
+	if i.Metadata, err = fromFixtureMap(i.Metadata); err != nil {
+		return errors.WithMessage(err, "metadata")
+	}
+	if i.Summary, err = fromFixtureMap(i.Summary); err != nil {
+		return errors.WithMessage(err, "summary")
+	}
+	for r, record := range i.Records {
+		v, err := bolt.ValueFromJSON(record)
+		if err != nil {
+			return errors.WithMessage(err, "record "+strconv.Itoa(r+1))
+		}
+		list, ok := v.([]interface{})
+		if !ok {
+			return errors.Errorf("record %d is not a list", r+1)
+		}
+		i.Records[r] = list
+	}
+	return nil
+}
+
+func toFixtureMap(m map[string]interface{}) (map[string]interface{}, error) {
+	return convertMap(m, bolt.JSONValue)
+}
+
+// fromFixtureMap decodes the values of m one by one, so a map of params is never itself mistaken for a node.
+func fromFixtureMap(m map[string]interface{}) (map[string]interface{}, error) {
+	return convertMap(m, bolt.ValueFromJSON)
+}
+
+func convertMap(m map[string]interface{},
+	convert func(v interface{}) (interface{}, error)) (map[string]interface{}, error) {
+	if m == nil {
+		return nil, nil
+	}
+	out := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		var err error
+		if out[k], err = convert(v); err != nil {
+			return nil, errors.WithMessage(err, k)
+		}
+	}
+	return out, nil
+}
+
+// diffInteraction describes how the statement made during replay differs from the recorded one.
+func diffInteraction(recorded *Interaction, typ, query string, params map[string]interface{}) string {
+	var b strings.Builder
+	if recorded.Type != typ {
+		fmt.Fprintf(&b, "  type:\n    - %s\n    + %s\n", recorded.Type, typ)
+	}
+	if recorded.Query != query {
+		fmt.Fprintf(&b, "  query:\n    - %s\n    + %s\n", recorded.Query, query)
+	}
+
+	keys := map[string]bool{}
+	for k := range recorded.Params {
+		keys[k] = true
+	}
+	for k := range params {
+		keys[k] = true
+	}
+	sorted := make([]string, 0, len(keys))
+	for k := range keys {
+		sorted = append(sorted, k)
+	}
+	sort.Strings(sorted)
+
+	var paramDiff strings.Builder
+	for _, k := range sorted {
+		r, inRecorded := recorded.Params[k]
+		a, inActual := params[k]
+		switch {
+		case !inActual:
+			fmt.Fprintf(&paramDiff, "    - %s: %s\n", k, fixtureString(r))
+		case !inRecorded:
+			fmt.Fprintf(&paramDiff, "    + %s: %s\n", k, fixtureString(a))
+		case fixtureString(r) != fixtureString(a):
+			fmt.Fprintf(&paramDiff, "    - %s: %s\n    + %s: %s\n", k, fixtureString(r), k, fixtureString(a))
+		}
+	}
+	if paramDiff.Len() > 0 {
+		b.WriteString("  params:\n")
+		b.WriteString(paramDiff.String())
+	}
+	return b.String()
+}
+
+func fixtureString(v interface{}) string {
+	j, err := bolt.JSONValue(v)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	b, err := json.Marshal(j)
+	if err != nil {
+		return fmt.Sprintf("%#v", v)
+	}
+	return string(b)
+}
@@ -0,0 +1,172 @@
+package bolttest
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/graph"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestRecordAndReplay(t *testing.T) {
+	Convey("given a session recorded against a server", t, func() {
+		dir, err := ioutil.TempDir("", "bolttest")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "testdata", "session.json")
+
+		s, err := NewServer()
+		So(err, ShouldBeNil)
+		defer s.Close()
+
+		node := graph.Node{NodeIdentity: 7, Labels: []string{"_code"}, Properties: map[string]interface{}{"value": "K02000001"}}
+		s.Expect("MATCH (c:_code) RETURN c, c.weight").
+			WillReturn([]string{"c", "c.weight"}, []interface{}{node, 2.0})
+		s.Expect("CREATE (n:_dataset {id: {id}})").
+			WithMetadata(map[string]interface{}{"stats": map[string]interface{}{"nodes-created": 1}})
+
+		driverPool, err := neo4j.NewClosableDriverPool(s.URL(), 1)
+		So(err, ShouldBeNil)
+
+		recorder := NewRecordingPool(driverPool, path)
+		db := bolt.New(recorder)
+
+		var recorded []interface{}
+		err = db.QueryForResult("MATCH (c:_code) RETURN c, c.weight", nil, func(r *bolt.Result) error {
+			recorded = r.Data
+			return nil
+		})
+		So(err, ShouldBeNil)
+		_, _, err = db.Exec(bolt.Stmt{Query: "CREATE (n:_dataset {id: {id}})", Params: bolt.Params{"id": "cpih01"}})
+		So(err, ShouldBeNil)
+		So(db.Close(), ShouldBeNil)
+
+		Convey("when the session is replayed with the same statements", func() {
+			replay, err := NewReplayPool(path)
+			So(err, ShouldBeNil)
+			db := bolt.New(replay)
+
+			var replayed []interface{}
+			err = db.QueryForResult("MATCH (c:_code) RETURN c, c.weight", nil, func(r *bolt.Result) error {
+				replayed = r.Data
+				return nil
+			})
+			So(err, ShouldBeNil)
+			rowsAffected, _, err := db.Exec(bolt.Stmt{Query: "CREATE (n:_dataset {id: {id}})", Params: bolt.Params{"id": "cpih01"}})
+			So(err, ShouldBeNil)
+
+			Convey("then the recorded rows are returned with their original types", func() {
+				So(replayed, ShouldResemble, recorded)
+				So(replayed[1], ShouldHaveSameTypeAs, float64(0))
+				So(rowsAffected, ShouldEqual, 1)
+				So(db.Close(), ShouldBeNil)
+			})
+		})
+
+		Convey("when the session is replayed with different params", func() {
+			replay, err := NewReplayPool(path)
+			So(err, ShouldBeNil)
+			db := bolt.New(replay)
+
+			db.QueryForResult("MATCH (c:_code) RETURN c, c.weight", nil, nil)
+			_, _, err = db.Exec(bolt.Stmt{Query: "CREATE (n:_dataset {id: {id}})", Params: bolt.Params{"id": "cpih02"}})
+
+			Convey("then an error describing the difference is returned", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, "exec statement 2 differs from the recording")
+				So(err.Error(), ShouldContainSubstring, `- id: "cpih01"`)
+				So(err.Error(), ShouldContainSubstring, `+ id: "cpih02"`)
+				So(db.Close(), ShouldNotBeNil)
+			})
+		})
+	})
+}
+
+func TestFixtureValues(t *testing.T) {
+	Convey("given records holding floats JSON can not hold", t, func() {
+		dir, err := ioutil.TempDir("", "bolttest")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "floats.json")
+
+		records := [][]interface{}{{math.NaN(), math.Inf(1), math.Inf(-1), 2.0, "NaN"}}
+		So(writeFixture(path, &Fixture{Interactions: []*Interaction{{Type: QueryInteraction, Records: records}}}),
+			ShouldBeNil)
+
+		Convey("when the fixture is read back", func() {
+			f, err := readFixture(path)
+			So(err, ShouldBeNil)
+			record := f.Interactions[0].Records[0]
+
+			Convey("then they are floats again and strings are left as strings", func() {
+				So(math.IsNaN(record[0].(float64)), ShouldBeTrue)
+				So(record[1], ShouldEqual, math.Inf(1))
+				So(record[2], ShouldEqual, math.Inf(-1))
+				So(record[3], ShouldEqual, 2.0)
+				So(record[4], ShouldEqual, "NaN")
+			})
+		})
+	})
+
+	Convey("given records holding graph values", t, func() {
+		dir, err := ioutil.TempDir("", "bolttest")
+		So(err, ShouldBeNil)
+		defer os.RemoveAll(dir)
+		path := filepath.Join(dir, "graph.json")
+
+		props := map[string]interface{}{}
+		node := graph.Node{NodeIdentity: 1, Labels: []string{"Code"}, Properties: map[string]interface{}{"order": int64(2)}}
+		parent := graph.Node{NodeIdentity: 2, Labels: []string{"Code"}, Properties: props}
+		rel := graph.Relationship{RelIdentity: 3, StartNodeIdentity: 2, EndNodeIdentity: 1, Type: "PARENT_OF",
+			Properties: props}
+		p := graph.Path{Nodes: []graph.Node{node, parent},
+			Relationships: []graph.UnboundRelationship{{RelIdentity: 3, Type: "PARENT_OF", Properties: props}},
+			Sequence:      []int{-1, 1}}
+		record := []interface{}{node, rel, p}
+		So(writeFixture(path, &Fixture{Interactions: []*Interaction{{Type: QueryInteraction,
+			Records: [][]interface{}{record}}}}), ShouldBeNil)
+
+		Convey("when the fixture is written", func() {
+			b, err := ioutil.ReadFile(path)
+			So(err, ShouldBeNil)
+			written, err := json.Marshal(bolt.Result{Data: record})
+			So(err, ShouldBeNil)
+
+			Convey("then the values are in the form bolt.Result marshals them to", func() {
+				var fixture struct {
+					Interactions []struct {
+						Records []json.RawMessage `json:"records"`
+					} `json:"interactions"`
+				}
+				var result struct {
+					Data json.RawMessage `json:"data"`
+				}
+				So(json.Unmarshal(b, &fixture), ShouldBeNil)
+				So(json.Unmarshal(written, &result), ShouldBeNil)
+				So(compactJSON(fixture.Interactions[0].Records[0]), ShouldEqual, compactJSON(result.Data))
+			})
+
+			Convey("then reading it back gives the graph values", func() {
+				f, err := readFixture(path)
+				So(err, ShouldBeNil)
+				read := f.Interactions[0].Records[0]
+				So(read[0], ShouldResemble, node)
+				So(read[1], ShouldResemble, rel)
+				So(read[2], ShouldHaveSameTypeAs, graph.Path{})
+			})
+		})
+	})
+}
+
+func compactJSON(b []byte) string {
+	var buf bytes.Buffer
+	json.Compact(&buf, b)
+	return buf.String()
+}
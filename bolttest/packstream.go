@@ -0,0 +1,192 @@
+package bolttest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+)
+
+// request is a message sent by the client, decoded from its packstream structure.
+type request struct {
+	signature byte
+	fields    []interface{}
+}
+
+// readMessage reads a single chunked message from r.
+func readMessage(r io.Reader) (*bytes.Buffer, error) {
+	msg := &bytes.Buffer{}
+	header := make([]byte, 2)
+	for {
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, err
+		}
+
+		size := binary.BigEndian.Uint16(header)
+		if size == 0 {
+			return msg, nil
+		}
+
+		if _, err := io.CopyN(msg, r, int64(size)); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// decodeRequest decodes a client message. The vendored driver only decodes the messages a server sends, so the
+// requests a client sends are unpacked here.
+func decodeRequest(buf *bytes.Buffer) (*request, error) {
+	marker, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	var size int
+	switch {
+	case marker >= 0xB0 && marker <= 0xBF:
+		size = int(marker - 0xB0)
+	case marker == 0xDC:
+		b, err := buf.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		size = int(b)
+	default:
+		return nil, fmt.Errorf("expected a message structure but found marker %x", marker)
+	}
+
+	signature, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	req := &request{signature: signature, fields: make([]interface{}, size)}
+	for i := range req.fields {
+		if req.fields[i], err = unpack(buf); err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
+func unpack(buf *bytes.Buffer) (interface{}, error) {
+	marker, err := buf.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case marker < 0x80:
+		return int64(marker), nil
+	case marker >= 0xF0:
+		return int64(int8(marker)), nil
+	case marker >= 0x80 && marker <= 0x8F:
+		return unpackString(buf, int(marker-0x80))
+	case marker >= 0x90 && marker <= 0x9F:
+		return unpackList(buf, int(marker-0x90))
+	case marker >= 0xA0 && marker <= 0xAF:
+		return unpackMap(buf, int(marker-0xA0))
+	}
+
+	switch marker {
+	case 0xC0:
+		return nil, nil
+	case 0xC2:
+		return false, nil
+	case 0xC3:
+		return true, nil
+	case 0xC1:
+		var f float64
+		err := binary.Read(buf, binary.BigEndian, &f)
+		return f, err
+	case 0xC8:
+		var i int8
+		err := binary.Read(buf, binary.BigEndian, &i)
+		return int64(i), err
+	case 0xC9:
+		var i int16
+		err := binary.Read(buf, binary.BigEndian, &i)
+		return int64(i), err
+	case 0xCA:
+		var i int32
+		err := binary.Read(buf, binary.BigEndian, &i)
+		return int64(i), err
+	case 0xCB:
+		var i int64
+		err := binary.Read(buf, binary.BigEndian, &i)
+		return i, err
+	case 0xD0, 0xD4, 0xD8:
+		b, err := buf.ReadByte()
+		if err != nil {
+			return nil, err
+		}
+		return unpackSized(buf, marker, int(b))
+	case 0xD1, 0xD5, 0xD9:
+		var size uint16
+		if err := binary.Read(buf, binary.BigEndian, &size); err != nil {
+			return nil, err
+		}
+		return unpackSized(buf, marker, int(size))
+	case 0xD2, 0xD6, 0xDA:
+		var size uint32
+		if err := binary.Read(buf, binary.BigEndian, &size); err != nil {
+			return nil, err
+		}
+		if size > math.MaxInt32 {
+			return nil, fmt.Errorf("value too large: %d", size)
+		}
+		return unpackSized(buf, marker, int(size))
+	default:
+		return nil, fmt.Errorf("unsupported packstream marker %x", marker)
+	}
+}
+
+func unpackSized(buf *bytes.Buffer, marker byte, size int) (interface{}, error) {
+	switch marker {
+	case 0xD0, 0xD1, 0xD2:
+		return unpackString(buf, size)
+	case 0xD4, 0xD5, 0xD6:
+		return unpackList(buf, size)
+	default:
+		return unpackMap(buf, size)
+	}
+}
+
+func unpackString(buf *bytes.Buffer, size int) (interface{}, error) {
+	b := buf.Next(size)
+	if len(b) != size {
+		return nil, io.ErrUnexpectedEOF
+	}
+	return string(b), nil
+}
+
+func unpackList(buf *bytes.Buffer, size int) (interface{}, error) {
+	list := make([]interface{}, size)
+	for i := range list {
+		v, err := unpack(buf)
+		if err != nil {
+			return nil, err
+		}
+		list[i] = v
+	}
+	return list, nil
+}
+
+func unpackMap(buf *bytes.Buffer, size int) (interface{}, error) {
+	m := make(map[string]interface{}, size)
+	for i := 0; i < size; i++ {
+		k, err := unpack(buf)
+		if err != nil {
+			return nil, err
+		}
+		key, ok := k.(string)
+		if !ok {
+			return nil, fmt.Errorf("map key is not a string: %#v", k)
+		}
+		if m[key], err = unpack(buf); err != nil {
+			return nil, err
+		}
+	}
+	return m, nil
+}
@@ -0,0 +1,238 @@
+package bolttest
+
+import (
+	"database/sql/driver"
+	"io"
+	"sync"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+// RecordingPool wraps a DBPool capturing every statement made through its connections, along with the rows and
+// summaries returned. The captured interactions are written to a golden file when the pool is closed.
+type RecordingPool struct {
+	pool    bolt.DBPool
+	path    string
+	mutex   sync.Mutex
+	fixture Fixture
+}
+
+// NewRecordingPool creates a RecordingPool writing to the golden file at path.
+func NewRecordingPool(pool bolt.DBPool, path string) *RecordingPool {
+	return &RecordingPool{pool: pool, path: path}
+}
+
+// OpenPool opens a connection from the wrapped pool.
+func (p *RecordingPool) OpenPool() (neo4j.Conn, error) {
+	conn, err := p.pool.OpenPool()
+	if err != nil {
+		return nil, err
+	}
+	return &recordingConn{Conn: conn, pool: p}, nil
+}
+
+// Close closes the wrapped pool and writes the golden file.
+func (p *RecordingPool) Close() error {
+	closeErr := p.pool.Close()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	if err := writeFixture(p.path, &p.fixture); err != nil {
+		return err
+	}
+	return closeErr
+}
+
+// Interactions returns the interactions recorded so far.
+func (p *RecordingPool) Interactions() []*Interaction {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	return append([]*Interaction{}, p.fixture.Interactions...)
+}
+
+func (p *RecordingPool) add(i *Interaction) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.fixture.Interactions = append(p.fixture.Interactions, i)
+}
+
+func copyParams(params map[string]interface{}) map[string]interface{} {
+	if params == nil {
+		return nil
+	}
+	c := make(map[string]interface{}, len(params))
+	for k, v := range params {
+		c[k] = v
+	}
+	return c
+}
+
+type recordingConn struct {
+	neo4j.Conn
+	pool *RecordingPool
+}
+
+func (c *recordingConn) QueryNeo(query string, params map[string]interface{}) (neo4j.Rows, error) {
+	i := &Interaction{Type: QueryInteraction, Query: query, Params: copyParams(params)}
+	c.pool.add(i)
+
+	rows, err := c.Conn.QueryNeo(query, params)
+	if err != nil {
+		i.Error = err.Error()
+		return nil, err
+	}
+	return newRecordingRows(rows, i), nil
+}
+
+func (c *recordingConn) QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	rows, err := c.QueryNeo(query, params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer rows.Close()
+
+	data, summary, err := rows.All()
+	return data, rows.Metadata(), summary, err
+}
+
+func (c *recordingConn) ExecNeo(query string, params map[string]interface{}) (neo4j.Result, error) {
+	i := &Interaction{Type: ExecInteraction, Query: query, Params: copyParams(params)}
+	c.pool.add(i)
+
+	res, err := c.Conn.ExecNeo(query, params)
+	if err != nil {
+		i.Error = err.Error()
+		return nil, err
+	}
+	i.Summary = res.Metadata()
+	return res, nil
+}
+
+func (c *recordingConn) PrepareNeo(query string) (neo4j.Stmt, error) {
+	stmt, err := c.Conn.PrepareNeo(query)
+	if err != nil {
+		return nil, err
+	}
+	return &recordingStmt{Stmt: stmt, query: query, pool: c.pool}, nil
+}
+
+func (c *recordingConn) Begin() (driver.Tx, error) {
+	i := &Interaction{Type: BeginInteraction}
+	c.pool.add(i)
+
+	tx, err := c.Conn.Begin()
+	if err != nil {
+		i.Error = err.Error()
+		return nil, err
+	}
+	return &recordingTx{Tx: tx, pool: c.pool}, nil
+}
+
+type recordingStmt struct {
+	neo4j.Stmt
+	query string
+	pool  *RecordingPool
+}
+
+func (s *recordingStmt) QueryNeo(params map[string]interface{}) (neo4j.Rows, error) {
+	i := &Interaction{Type: QueryInteraction, Query: s.query, Params: copyParams(params)}
+	s.pool.add(i)
+
+	rows, err := s.Stmt.QueryNeo(params)
+	if err != nil {
+		i.Error = err.Error()
+		return nil, err
+	}
+	return newRecordingRows(rows, i), nil
+}
+
+func (s *recordingStmt) ExecNeo(params map[string]interface{}) (neo4j.Result, error) {
+	i := &Interaction{Type: ExecInteraction, Query: s.query, Params: copyParams(params)}
+	s.pool.add(i)
+
+	res, err := s.Stmt.ExecNeo(params)
+	if err != nil {
+		i.Error = err.Error()
+		return nil, err
+	}
+	i.Summary = res.Metadata()
+	return res, nil
+}
+
+type recordingTx struct {
+	driver.Tx
+	pool *RecordingPool
+}
+
+func (t *recordingTx) Commit() error {
+	return t.record(CommitInteraction, t.Tx.Commit)
+}
+
+func (t *recordingTx) Rollback() error {
+	return t.record(RollbackInteraction, t.Tx.Rollback)
+}
+
+func (t *recordingTx) record(typ string, f func() error) error {
+	i := &Interaction{Type: typ}
+	t.pool.add(i)
+	if err := f(); err != nil {
+		i.Error = err.Error()
+		return err
+	}
+	return nil
+}
+
+type recordingRows struct {
+	neo4j.Rows
+	interaction *Interaction
+}
+
+func newRecordingRows(rows neo4j.Rows, i *Interaction) *recordingRows {
+	i.Columns = rows.Columns()
+	i.Metadata = rows.Metadata()
+	return &recordingRows{Rows: rows, interaction: i}
+}
+
+func (r *recordingRows) NextNeo() ([]interface{}, map[string]interface{}, error) {
+	data, meta, err := r.Rows.NextNeo()
+	switch {
+	case err == io.EOF:
+		r.interaction.Summary = meta
+	case err != nil:
+		r.interaction.StreamError = err.Error()
+	default:
+		r.interaction.Records = append(r.interaction.Records, data)
+	}
+	return data, meta, err
+}
+
+func (r *recordingRows) All() ([][]interface{}, map[string]interface{}, error) {
+	output := [][]interface{}{}
+	for {
+		data, meta, err := r.NextNeo()
+		if err == io.EOF {
+			return output, meta, nil
+		}
+		if err != nil {
+			return output, meta, err
+		}
+		output = append(output, data)
+	}
+}
+
+// ErrPipelineNotSupported is returned by pipelined statements, which can not be recorded or replayed.
+var ErrPipelineNotSupported = errors.New("bolttest: pipelined statements can not be recorded or replayed")
+
+func (c *recordingConn) PreparePipeline(query ...string) (neo4j.PipelineStmt, error) {
+	return nil, ErrPipelineNotSupported
+}
+
+func (c *recordingConn) QueryPipeline(query []string, params ...map[string]interface{}) (neo4j.PipelineRows, error) {
+	return nil, ErrPipelineNotSupported
+}
+
+func (c *recordingConn) ExecPipeline(query []string, params ...map[string]interface{}) ([]neo4j.Result, error) {
+	return nil, ErrPipelineNotSupported
+}
@@ -0,0 +1,241 @@
+package bolttest
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	"github.com/pkg/errors"
+)
+
+// ReplayPool is a DBPool serving the interactions of a golden file in the order they were recorded. A statement that
+// differs from the next recorded interaction fails with a diff of the two.
+type ReplayPool struct {
+	path         string
+	mutex        sync.Mutex
+	interactions []*Interaction
+	next         int
+}
+
+// NewReplayPool creates a ReplayPool from the golden file at path.
+func NewReplayPool(path string) (*ReplayPool, error) {
+	f, err := readFixture(path)
+	if err != nil {
+		return nil, err
+	}
+	return &ReplayPool{path: path, interactions: f.Interactions}, nil
+}
+
+// OpenPool returns a connection replaying the golden file.
+func (p *ReplayPool) OpenPool() (neo4j.Conn, error) {
+	return &replayConn{pool: p}, nil
+}
+
+// Close returns an error if any recorded interaction was not replayed.
+func (p *ReplayPool) Close() error {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	remaining := len(p.interactions) - p.next
+	if remaining > 0 {
+		return errors.Errorf("bolttest: %d recorded interaction(s) in %s were not replayed, next: %s %q",
+			remaining, p.path, p.interactions[p.next].Type, p.interactions[p.next].Query)
+	}
+	return nil
+}
+
+func (p *ReplayPool) replay(typ, query string, params map[string]interface{}) (*Interaction, error) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.next >= len(p.interactions) {
+		return nil, errors.Errorf("bolttest: no recorded interaction left in %s for %s %q", p.path, typ, query)
+	}
+
+	recorded := p.interactions[p.next]
+	if diff := diffInteraction(recorded, typ, query, params); diff != "" {
+		return nil, errors.Errorf("bolttest: %s statement %d differs from the recording in %s (- recorded, + actual):\n%s",
+			typ, p.next+1, p.path, strings.TrimRight(diff, "\n"))
+	}
+	p.next++
+
+	if recorded.Error != "" {
+		return nil, errors.New(recorded.Error)
+	}
+	return recorded, nil
+}
+
+type replayConn struct {
+	pool   *ReplayPool
+	closed bool
+}
+
+func (c *replayConn) QueryNeo(query string, params map[string]interface{}) (neo4j.Rows, error) {
+	i, err := c.pool.replay(QueryInteraction, query, params)
+	if err != nil {
+		return nil, err
+	}
+	return &replayRows{interaction: i}, nil
+}
+
+func (c *replayConn) QueryNeoAll(query string, params map[string]interface{}) ([][]interface{}, map[string]interface{}, map[string]interface{}, error) {
+	rows, err := c.QueryNeo(query, params)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	defer rows.Close()
+
+	data, summary, err := rows.All()
+	return data, rows.Metadata(), summary, err
+}
+
+func (c *replayConn) ExecNeo(query string, params map[string]interface{}) (neo4j.Result, error) {
+	i, err := c.pool.replay(ExecInteraction, query, params)
+	if err != nil {
+		return nil, err
+	}
+	return replayResult{metadata: i.Summary}, nil
+}
+
+func (c *replayConn) PrepareNeo(query string) (neo4j.Stmt, error) {
+	return &replayStmt{conn: c, query: query}, nil
+}
+
+func (c *replayConn) Begin() (driver.Tx, error) {
+	if _, err := c.pool.replay(BeginInteraction, "", nil); err != nil {
+		return nil, err
+	}
+	return &replayTx{pool: c.pool}, nil
+}
+
+func (c *replayConn) Close() error {
+	c.closed = true
+	return nil
+}
+
+func (c *replayConn) SetChunkSize(uint16) {}
+
+func (c *replayConn) SetTimeout(time.Duration) {}
+
+func (c *replayConn) PreparePipeline(query ...string) (neo4j.PipelineStmt, error) {
+	return nil, ErrPipelineNotSupported
+}
+
+func (c *replayConn) QueryPipeline(query []string, params ...map[string]interface{}) (neo4j.PipelineRows, error) {
+	return nil, ErrPipelineNotSupported
+}
+
+func (c *replayConn) ExecPipeline(query []string, params ...map[string]interface{}) ([]neo4j.Result, error) {
+	return nil, ErrPipelineNotSupported
+}
+
+type replayStmt struct {
+	conn  *replayConn
+	query string
+}
+
+func (s *replayStmt) QueryNeo(params map[string]interface{}) (neo4j.Rows, error) {
+	return s.conn.QueryNeo(s.query, params)
+}
+
+func (s *replayStmt) ExecNeo(params map[string]interface{}) (neo4j.Result, error) {
+	return s.conn.ExecNeo(s.query, params)
+}
+
+func (s *replayStmt) Close() error {
+	return nil
+}
+
+type replayTx struct {
+	pool *ReplayPool
+}
+
+func (t *replayTx) Commit() error {
+	_, err := t.pool.replay(CommitInteraction, "", nil)
+	return err
+}
+
+func (t *replayTx) Rollback() error {
+	_, err := t.pool.replay(RollbackInteraction, "", nil)
+	return err
+}
+
+type replayRows struct {
+	interaction *Interaction
+	index       int
+	closed      bool
+}
+
+func (r *replayRows) Columns() []string {
+	return r.interaction.Columns
+}
+
+func (r *replayRows) Metadata() map[string]interface{} {
+	return r.interaction.Metadata
+}
+
+func (r *replayRows) Close() error {
+	r.closed = true
+	return nil
+}
+
+func (r *replayRows) NextNeo() ([]interface{}, map[string]interface{}, error) {
+	if r.closed {
+		return nil, nil, errors.New("Rows are already closed")
+	}
+	if r.index >= len(r.interaction.Records) {
+		if r.interaction.StreamError != "" {
+			return nil, nil, errors.New(r.interaction.StreamError)
+		}
+		return nil, r.interaction.Summary, io.EOF
+	}
+	data := r.interaction.Records[r.index]
+	r.index++
+	return data, nil, nil
+}
+
+func (r *replayRows) All() ([][]interface{}, map[string]interface{}, error) {
+	output := [][]interface{}{}
+	for {
+		data, meta, err := r.NextNeo()
+		if err == io.EOF {
+			return output, meta, nil
+		}
+		if err != nil {
+			return output, meta, err
+		}
+		output = append(output, data)
+	}
+}
+
+type replayResult struct {
+	metadata map[string]interface{}
+}
+
+func (r replayResult) Metadata() map[string]interface{} {
+	return r.metadata
+}
+
+func (r replayResult) LastInsertId() (int64, error) {
+	return -1, nil
+}
+
+// RowsAffected matches the driver, counting the nodes and relationships created and deleted.
+func (r replayResult) RowsAffected() (int64, error) {
+	stats, ok := r.metadata["stats"].(map[string]interface{})
+	if !ok {
+		return -1, fmt.Errorf("Unrecognized type for stats metadata: %#v", r.metadata)
+	}
+
+	var rowsAffected int64
+	for _, stat := range []string{"nodes-created", "relationships-created", "nodes-deleted", "relationships-deleted"} {
+		if n, ok := stats[stat].(int64); ok {
+			rowsAffected += n
+		}
+	}
+	return rowsAffected, nil
+}
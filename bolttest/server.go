@@ -0,0 +1,326 @@
+// Package bolttest provides an in-process server speaking Bolt v1 so the real driver and bolt.DB can be exercised in
+// tests without a Neo4j instance.
+package bolttest
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+	"net"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/encoding"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures"
+	"github.com/johnnadratowski/golang-neo4j-bolt-driver/structures/messages"
+	"github.com/pkg/errors"
+)
+
+const (
+	initSignature       = 0x01
+	ackFailureSignature = 0x0E
+	resetSignature      = 0x0F
+	runSignature        = 0x10
+	discardAllSignature = 0x2F
+	pullAllSignature    = 0x3F
+
+	// UnexpectedStatementCode is the failure code sent in response to a statement no expectation matched.
+	UnexpectedStatementCode = "Bolttest.ClientError.Statement.Unexpected"
+)
+
+var (
+	preamble     = []byte{0x60, 0x60, 0xB0, 0x17}
+	boltVersion1 = []byte{0x00, 0x00, 0x00, 0x01}
+	noVersion    = []byte{0x00, 0x00, 0x00, 0x00}
+
+	transactionStatements = map[string]bool{"BEGIN": true, "COMMIT": true, "ROLLBACK": true}
+)
+
+// Statement is a statement received by the server.
+type Statement struct {
+	Query  string
+	Params map[string]interface{}
+}
+
+// Server is a Bolt v1 server that responds to statements according to the expectations set on it. Transaction
+// statements (BEGIN, COMMIT and ROLLBACK) are acknowledged without an expectation.
+type Server struct {
+	listener     net.Listener
+	mutex        sync.Mutex
+	expectations []*Expectation
+	received     []Statement
+	unexpected   []Statement
+	conns        map[net.Conn]bool
+	closed       chan struct{}
+	wg           sync.WaitGroup
+}
+
+// NewServer starts a Server listening on a random local port.
+func NewServer() (*Server, error) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, errors.WithMessage(err, "error starting bolttest listener")
+	}
+
+	s := &Server{listener: listener, conns: make(map[net.Conn]bool), closed: make(chan struct{})}
+	s.wg.Add(1)
+	go s.serve()
+	return s, nil
+}
+
+// URL returns the bolt URL to connect to the server with.
+func (s *Server) URL() string {
+	return "bolt://" + s.listener.Addr().String()
+}
+
+// Close stops the server and closes every open connection.
+func (s *Server) Close() error {
+	err := s.listener.Close()
+
+	s.mutex.Lock()
+	select {
+	case <-s.closed:
+	default:
+		close(s.closed)
+	}
+	for conn := range s.conns {
+		conn.Close()
+	}
+	s.mutex.Unlock()
+
+	s.wg.Wait()
+	return err
+}
+
+// Expect adds an expectation for a statement matching query exactly, ignoring leading and trailing whitespace.
+func (s *Server) Expect(query string) *Expectation {
+	return s.expect(&Expectation{statement: query, times: 1})
+}
+
+// ExpectRegexp adds an expectation for a statement matching the provided regular expression.
+func (s *Server) ExpectRegexp(pattern string) *Expectation {
+	return s.expect(&Expectation{statement: pattern, re: regexp.MustCompile(pattern), times: 1})
+}
+
+func (s *Server) expect(e *Expectation) *Expectation {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.expectations = append(s.expectations, e)
+	return e
+}
+
+// Received returns every statement received by the server, in order.
+func (s *Server) Received() []Statement {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return append([]Statement{}, s.received...)
+}
+
+// ExpectationsWereMet returns an error describing any expectation that was not met and any statement received that
+// matched no expectation.
+func (s *Server) ExpectationsWereMet() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	var problems []string
+	for _, e := range s.expectations {
+		if !e.met() {
+			problems = append(problems, fmt.Sprintf("expected %s %d time(s) but received it %d time(s)", e, e.times, e.calls))
+		}
+	}
+	for _, stmt := range s.unexpected {
+		problems = append(problems, fmt.Sprintf("unexpected statement %q with params %v", stmt.Query, stmt.Params))
+	}
+
+	if len(problems) > 0 {
+		return errors.New("bolttest: " + strings.Join(problems, "\n\t"))
+	}
+	return nil
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+
+		s.mutex.Lock()
+		s.conns[conn] = true
+		s.mutex.Unlock()
+
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			s.handle(conn)
+
+			s.mutex.Lock()
+			delete(s.conns, conn)
+			s.mutex.Unlock()
+			conn.Close()
+		}()
+	}
+}
+
+// session holds the state of a single client connection.
+type session struct {
+	conn    net.Conn
+	failed  bool
+	pending *Expectation
+}
+
+func (s *Server) handle(conn net.Conn) {
+	if err := handshake(conn); err != nil {
+		return
+	}
+
+	sess := &session{conn: conn}
+	for {
+		buf, err := readMessage(conn)
+		if err != nil {
+			return
+		}
+
+		req, err := decodeRequest(buf)
+		if err != nil {
+			return
+		}
+
+		if err := s.respond(sess, req); err != nil {
+			return
+		}
+	}
+}
+
+func handshake(conn net.Conn) error {
+	b := make([]byte, 20)
+	if _, err := io.ReadFull(conn, b); err != nil {
+		return err
+	}
+
+	if !bytes.Equal(b[:4], preamble) {
+		return errors.New("bolttest: invalid preamble")
+	}
+
+	for i := 4; i < 20; i += 4 {
+		if binary.BigEndian.Uint32(b[i:i+4]) == 1 {
+			_, err := conn.Write(boltVersion1)
+			return err
+		}
+	}
+
+	conn.Write(noVersion)
+	return errors.New("bolttest: client does not support bolt v1")
+}
+
+func (s *Server) respond(sess *session, req *request) error {
+	switch req.signature {
+	case initSignature:
+		return sess.send(messages.NewSuccessMessage(map[string]interface{}{"server": "Neo4j/3.3.0"}))
+
+	case ackFailureSignature, resetSignature:
+		sess.failed = false
+		sess.pending = nil
+		return sess.send(messages.NewSuccessMessage(map[string]interface{}{}))
+
+	case runSignature:
+		if sess.failed {
+			return sess.send(messages.NewIgnoredMessage())
+		}
+		return s.run(sess, req)
+
+	case pullAllSignature, discardAllSignature:
+		if sess.failed {
+			return sess.send(messages.NewIgnoredMessage())
+		}
+
+		e := sess.pending
+		sess.pending = nil
+		if e == nil {
+			return sess.send(messages.NewSuccessMessage(map[string]interface{}{}))
+		}
+
+		if req.signature == pullAllSignature {
+			for _, record := range e.records {
+				if err := sess.send(messages.NewRecordMessage(record)); err != nil {
+					return err
+				}
+			}
+		}
+
+		metadata := e.metadata
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		return sess.send(messages.NewSuccessMessage(metadata))
+
+	default:
+		return errors.Errorf("bolttest: unsupported message signature %x", req.signature)
+	}
+}
+
+func (s *Server) run(sess *session, req *request) error {
+	if len(req.fields) != 2 {
+		return errors.New("bolttest: malformed RUN message")
+	}
+
+	query, _ := req.fields[0].(string)
+	params, _ := req.fields[1].(map[string]interface{})
+	stmt := Statement{Query: query, Params: params}
+
+	s.mutex.Lock()
+	s.received = append(s.received, stmt)
+	var matched *Expectation
+	for _, e := range s.expectations {
+		if !e.met() && e.matches(query, params) {
+			matched = e
+			break
+		}
+	}
+	if matched != nil {
+		matched.calls++
+	} else if !transactionStatements[strings.ToUpper(strings.TrimSpace(query))] {
+		s.unexpected = append(s.unexpected, stmt)
+	}
+	s.mutex.Unlock()
+
+	if matched == nil {
+		if transactionStatements[strings.ToUpper(strings.TrimSpace(query))] {
+			return sess.send(messages.NewSuccessMessage(map[string]interface{}{"fields": []interface{}{}}))
+		}
+		sess.failed = true
+		return sess.send(messages.NewFailureMessage(map[string]interface{}{
+			"code":    UnexpectedStatementCode,
+			"message": fmt.Sprintf("no expectation matched statement %q", query),
+		}))
+	}
+
+	if matched.delay > 0 {
+		select {
+		case <-time.After(matched.delay):
+		case <-s.closed:
+			return errors.New("bolttest: server closed")
+		}
+	}
+
+	if matched.failure != nil {
+		sess.failed = true
+		return sess.send(messages.NewFailureMessage(matched.failure))
+	}
+
+	sess.pending = matched
+	fields := matched.fields
+	if fields == nil {
+		fields = []interface{}{}
+	}
+	return sess.send(messages.NewSuccessMessage(map[string]interface{}{"fields": fields}))
+}
+
+func (sess *session) send(msg structures.Structure) error {
+	return encoding.NewEncoder(sess.conn, math.MaxUint16).Encode(msg)
+}
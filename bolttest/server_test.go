@@ -0,0 +1,163 @@
+package bolttest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/ONSdigital/dp-bolt/bolt"
+	neo4j "github.com/johnnadratowski/golang-neo4j-bolt-driver"
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func newTestDB(s *Server) *bolt.DB {
+	pool, err := neo4j.NewClosableDriverPool(s.URL(), 1)
+	So(err, ShouldBeNil)
+	return bolt.New(pool)
+}
+
+func TestServer_QueryForResult(t *testing.T) {
+	Convey("given a server expecting a query with params", t, func() {
+		s, err := NewServer()
+		So(err, ShouldBeNil)
+		defer s.Close()
+
+		s.Expect("MATCH (n:_code_list {id: {id}}) RETURN n.label, n.count").
+			WithParams(map[string]interface{}{"id": "cpih1dim1aggid"}).
+			WillReturn([]string{"n.label", "n.count"}, []interface{}{"CPIH", 42})
+
+		db := newTestDB(s)
+		defer db.Close()
+
+		Convey("when the query is made through bolt.DB", func() {
+			var label string
+			var count int64
+			err := db.QueryForResult("MATCH (n:_code_list {id: {id}}) RETURN n.label, n.count", bolt.Params{"id": "cpih1dim1aggid"}, func(r *bolt.Result) error {
+				label = r.Data[0].(string)
+				count = r.Data[1].(int64)
+				return nil
+			})
+
+			Convey("then the scripted record is returned and the expectations are met", func() {
+				So(err, ShouldBeNil)
+				So(label, ShouldEqual, "CPIH")
+				So(count, ShouldEqual, 42)
+				So(s.ExpectationsWereMet(), ShouldBeNil)
+			})
+		})
+	})
+}
+
+func TestServer_Exec(t *testing.T) {
+	Convey("given a server expecting a write statement matched by regexp", t, func() {
+		s, err := NewServer()
+		So(err, ShouldBeNil)
+		defer s.Close()
+
+		s.ExpectRegexp(`^CREATE \(n:_dataset`).
+			WithParam("id", MatchRegexp("^ds-")).
+			WithMetadata(map[string]interface{}{"stats": map[string]interface{}{"nodes-created": 1}})
+
+		db := newTestDB(s)
+		defer db.Close()
+
+		Convey("when the statement is executed then the rows affected are taken from the summary stats", func() {
+			rowsAffected, _, err := db.Exec(bolt.Stmt{Query: "CREATE (n:_dataset {id: {id}})", Params: bolt.Params{"id": "ds-1"}})
+
+			So(err, ShouldBeNil)
+			So(rowsAffected, ShouldEqual, 1)
+			So(s.ExpectationsWereMet(), ShouldBeNil)
+		})
+	})
+}
+
+func TestServer_Failure(t *testing.T) {
+	Convey("given a server with an expectation that fails", t, func() {
+		s, err := NewServer()
+		So(err, ShouldBeNil)
+		defer s.Close()
+
+		s.Expect("MATCH (n) RETURN n").WillFail("Neo.ClientError.Statement.SyntaxError", "invalid syntax")
+		s.Expect("MATCH (n) RETURN count(n)").WillReturn([]string{"count(n)"}, []interface{}{1})
+
+		db := newTestDB(s)
+		defer db.Close()
+
+		Convey("when the failing query is made then an error is returned and the connection can be reused", func() {
+			err := db.QueryForResults("MATCH (n) RETURN n", nil, nil)
+			So(err, ShouldNotBeNil)
+			So(err.Error(), ShouldContainSubstring, "Neo.ClientError.Statement.SyntaxError")
+
+			err = db.QueryForResult("MATCH (n) RETURN count(n)", nil, nil)
+			So(err, ShouldBeNil)
+			So(s.ExpectationsWereMet(), ShouldBeNil)
+		})
+	})
+}
+
+func TestServer_ExpectationsWereMet(t *testing.T) {
+	Convey("given a server with an expectation that is not met", t, func() {
+		s, err := NewServer()
+		So(err, ShouldBeNil)
+		defer s.Close()
+
+		s.Expect("MATCH (n) RETURN n")
+
+		db := newTestDB(s)
+		defer db.Close()
+
+		Convey("when an unexpected statement is sent", func() {
+			err := db.QueryForResults("MATCH (m) RETURN m", nil, nil)
+
+			Convey("then the statement fails and both problems are reported", func() {
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, UnexpectedStatementCode)
+
+				err := s.ExpectationsWereMet()
+				So(err, ShouldNotBeNil)
+				So(err.Error(), ShouldContainSubstring, `expected "MATCH (n) RETURN n" 1 time(s) but received it 0 time(s)`)
+				So(err.Error(), ShouldContainSubstring, `unexpected statement "MATCH (m) RETURN m"`)
+				So(s.Received(), ShouldHaveLength, 1)
+			})
+		})
+	})
+}
+
+func TestServer_Shutdown(t *testing.T) {
+	Convey("given a DB with a query the server is slow to answer", t, func() {
+		s, err := NewServer()
+		So(err, ShouldBeNil)
+		defer s.Close()
+
+		s.Expect("MATCH (n) RETURN n").WillDelayFor(time.Minute)
+
+		db := newTestDB(s)
+		queryErr := make(chan error, 1)
+		go func() {
+			queryErr <- db.QueryForResults("MATCH (n) RETURN n", nil, nil)
+		}()
+		for len(s.Received()) == 0 {
+			time.Sleep(time.Millisecond)
+		}
+
+		Convey("when the shutdown context expires before the query finishes", func() {
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+			defer cancel()
+			err := db.Shutdown(ctx)
+
+			Convey("then the query is interrupted and reported", func() {
+				shutdownErr, ok := err.(*bolt.ShutdownError)
+				So(ok, ShouldBeTrue)
+				So(shutdownErr.Interrupted, ShouldResemble, []string{"query MATCH (n) RETURN n"})
+
+				select {
+				case err := <-queryErr:
+					So(err, ShouldNotBeNil)
+				case <-time.After(5 * time.Second):
+					So("the query was not interrupted", ShouldBeEmpty)
+				}
+				So(db.QueryForResults("MATCH (n) RETURN n", nil, nil), ShouldEqual, bolt.ErrDBClosed)
+			})
+		})
+	})
+}